@@ -1,11 +1,185 @@
 package cmd
 
-import "sync"
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+)
 
 type NotificationService interface {
 	// send one time alert for validator
-	SendValidatorAlertNotification(config *HalfLifeConfig, vm *ValidatorMonitor, stats ValidatorStats, alertNotification *ValidatorAlertNotification)
+	SendValidatorAlertNotification(config *HalfLifeConfig, vm *ValidatorMonitor, stats ValidatorStats, alertNotification *ValidatorAlertNotification) error
 
 	// update (or create) realtime status for validator
-	UpdateValidatorRealtimeStatus(configFile string, config *HalfLifeConfig, vm *ValidatorMonitor, stats ValidatorStats, writeConfigMutex *sync.Mutex)
+	UpdateValidatorRealtimeStatus(configFile string, config *HalfLifeConfig, vm *ValidatorMonitor, stats ValidatorStats, writeConfigMutex *sync.Mutex) error
+
+	// check that the notification service is reachable and its credentials are accepted
+	CheckReachability() error
+}
+
+// getNotificationService returns the configured notification service, also sending alerts to a pager when configured
+func getNotificationService(config *HalfLifeConfig) (NotificationService, error) {
+	if config.Notifications == nil {
+		return nil, errors.New("notifications config is required, no notifier configured: add a notifications block with a service, e.g. service: discord, to config.yaml")
+	}
+	service, err := newNotificationService(config)
+	if err != nil {
+		return nil, err
+	}
+	service, err = withPaging(config, service)
+	if err != nil || !config.DryRun {
+		return service, err
+	}
+	// the configured services are still built above so that their config is validated
+	fmt.Println("Dry run: notifications will be logged instead of sent")
+	return DryRunNotificationService{}, nil
+}
+
+// TODO implement more notification services e.g. slack, email
+func newNotificationService(config *HalfLifeConfig) (NotificationService, error) {
+	switch config.Notifications.Service {
+	case "discord":
+		if config.Notifications.Discord == nil {
+			return nil, errors.New("discord configuration not present in config.yaml")
+		}
+		statusWebhook, alertWebhook := config.Notifications.Discord.getWebhooks()
+		if statusWebhook.ID == "" || alertWebhook.ID == "" {
+			return nil, errors.New("discord webhook not configured in config.yaml")
+		}
+		colors, err := config.Notifications.Discord.Colors.getColors()
+		if err != nil {
+			return nil, err
+		}
+		return withDigest(config, withRetries(config, NewDiscordNotificationService(statusWebhook, alertWebhook, colors, config.Notifications.Discord.Verbosity, newProxyHTTPClient(config.Proxy, discordHTTPTimeout))))
+	case "matrix":
+		if config.Notifications.Matrix == nil {
+			return nil, errors.New("matrix configuration not present in config.yaml")
+		}
+		matrixConfig := *config.Notifications.Matrix
+		if matrixConfig.HomeserverURL == "" || matrixConfig.AccessToken == "" || matrixConfig.RoomID == "" {
+			return nil, errors.New("matrix homeserver-url, access-token, and room-id are required in config.yaml")
+		}
+		return withDigest(config, withRetries(config, NewMatrixNotificationService(matrixConfig, newProxyHTTPClient(config.Proxy, matrixHTTPTimeout))))
+	case "teams":
+		if config.Notifications.Teams == nil || config.Notifications.Teams.WebhookURL == "" {
+			return nil, errors.New("teams webhook-url not configured in config.yaml")
+		}
+		return withDigest(config, withRetries(config, NewTeamsNotificationService(*config.Notifications.Teams, newProxyHTTPClient(config.Proxy, teamsHTTPTimeout))))
+	case "":
+		return nil, errors.New("no notifier configured: set notifications service, e.g. service: discord, in config.yaml")
+	default:
+		return nil, fmt.Errorf("notification service not supported: %s", config.Notifications.Service)
+	}
+}
+
+// getValidatorTitle returns the validator name with its uptime, for message titles
+func getValidatorTitle(vm *ValidatorMonitor, stats ValidatorStats) string {
+	if vm.FullNode {
+		return vm.Name
+	}
+	if stats.SlashingPeriodUptime > 0 {
+		return fmt.Sprintf("%s (%.02f%% up)", vm.Name, stats.SlashingPeriodUptime)
+	}
+	return fmt.Sprintf("%s (N/A%% up)", vm.Name)
+}
+
+// getRPCHealth describes a failing rpc with the failure streak and the time of the last successful check,
+// so that monitoring connectivity problems can be told apart from validator problems
+func getRPCHealth(stats ValidatorStats) string {
+	lastSuccess := "never"
+	if !stats.RPCLastSuccess.IsZero() {
+		lastSuccess = stats.RPCLastSuccess.UTC().Format(time.RFC3339)
+	}
+	return fmt.Sprintf("RPC failing for %d checks - last success %s", stats.RPCConsecutiveFailures, lastSuccess)
+}
+
+// unwrapNotificationService returns the notification service wrapped by retries, digests, or paging, or nil
+func unwrapNotificationService(service NotificationService) NotificationService {
+	switch wrapped := service.(type) {
+	case *DigestNotificationService:
+		return wrapped.NotificationService
+	case *RetryingNotificationService:
+		return wrapped.NotificationService
+	case *PagingNotificationService:
+		return wrapped.NotificationService
+	default:
+		return nil
+	}
+}
+
+// withRetries wraps the notification service with retries unless the retry window is set to 0
+func withRetries(config *HalfLifeConfig, service NotificationService) NotificationService {
+	retryWindow := defaultNotificationRetryWindow
+	if config.Notifications.RetryWindow != nil {
+		retryWindow = *config.Notifications.RetryWindow
+	}
+	if retryWindow <= 0 {
+		return service
+	}
+	return NewRetryingNotificationService(service, retryWindow)
+}
+
+// PagingNotificationService sends alerts to both the notification service and pagers, e.g. Opsgenie or Pushover.
+// Status updates only go to the notification service.
+type PagingNotificationService struct {
+	NotificationService
+	pagers []NotificationService
+}
+
+// implements NotificationService interface
+func (service *PagingNotificationService) SendValidatorAlertNotification(
+	config *HalfLifeConfig,
+	vm *ValidatorMonitor,
+	stats ValidatorStats,
+	alertNotification *ValidatorAlertNotification,
+) error {
+	var errs []string
+	if err := service.NotificationService.SendValidatorAlertNotification(config, vm, stats, alertNotification); err != nil {
+		errs = append(errs, err.Error())
+	}
+	for _, pager := range service.pagers {
+		if err := pager.SendValidatorAlertNotification(config, vm, stats, alertNotification); err != nil {
+			errs = append(errs, err.Error())
+		}
+	}
+	if len(errs) > 0 {
+		return errors.New(strings.Join(errs, ", "))
+	}
+	return nil
+}
+
+// implements NotificationService interface
+func (service *PagingNotificationService) CheckReachability() error {
+	if err := service.NotificationService.CheckReachability(); err != nil {
+		return err
+	}
+	for _, pager := range service.pagers {
+		if err := pager.CheckReachability(); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// withPaging also sends alerts to Opsgenie and Pushover when configured. Pages are sent as alerts happen, not batched into digests.
+func withPaging(config *HalfLifeConfig, service NotificationService) (NotificationService, error) {
+	var pagers []NotificationService
+	if opsgenieConfig := config.Notifications.Opsgenie; opsgenieConfig != nil {
+		if opsgenieConfig.APIKey == "" {
+			return nil, errors.New("opsgenie api-key not configured in config.yaml")
+		}
+		pagers = append(pagers, withRetries(config, NewOpsgenieNotificationService(*opsgenieConfig, newProxyHTTPClient(config.Proxy, opsgenieHTTPTimeout))))
+	}
+	if pushoverConfig := config.Notifications.Pushover; pushoverConfig != nil {
+		if pushoverConfig.Token == "" || pushoverConfig.UserKey == "" {
+			return nil, errors.New("pushover token and user-key not configured in config.yaml")
+		}
+		pagers = append(pagers, withRetries(config, NewPushoverNotificationService(*pushoverConfig, newProxyHTTPClient(config.Proxy, pushoverHTTPTimeout))))
+	}
+	if len(pagers) == 0 {
+		return service, nil
+	}
+	return &PagingNotificationService{NotificationService: service, pagers: pagers}, nil
 }
@@ -0,0 +1,366 @@
+package cmd
+
+import (
+	"crypto/subtle"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// The API types are the documented JSON schema of the API, and are kept separate from the
+// internal stats and alert state so that the schema stays stable when those change.
+const (
+	apiVersion        = "v1"
+	apiValidatorsPath = "/api/v1/validators"
+	apiAckPath        = "/api/v1/ack"
+)
+
+var sentryAlertTypeNames = map[SentryAlertType]string{
+	sentryAlertTypeNone:           "ok",
+	sentryAlertTypeGRPCError:      "grpc-error",
+	sentryAlertTypeOutOfSyncError: "out-of-sync",
+	sentryAlertTypeHalt:           "halted",
+	sentryAlertTypeLowPeers:       "low-peers",
+}
+
+// APIValidators is the response of GET /api/v1/validators
+type APIValidators struct {
+	Version    string              `json:"version"`
+	Validators []APIValidatorState `json:"validators"`
+}
+
+// APIValidatorState is the latest check of a validator, the state is empty until the validator has been checked
+type APIValidatorState struct {
+	Name       string             `json:"name"`
+	ChainID    string             `json:"chain-id"`
+	Group      string             `json:"group"`
+	Tags       []string           `json:"tags,omitempty"`
+	FullNode   bool               `json:"fullnode"`
+	Updated    *time.Time         `json:"updated"`
+	Stats      *APIValidatorStats `json:"stats"`
+	AlertState *APIAlertState     `json:"alert-state"`
+}
+
+type APIValidatorStats struct {
+	Timestamp                   time.Time        `json:"timestamp"`
+	Height                      int64            `json:"height"`
+	AlertLevel                  AlertLevel       `json:"alert-level"`
+	RPCError                    bool             `json:"rpc-error"`
+	SlashingPeriodUptime        float64          `json:"slashing-period-uptime"`
+	RecentMissedBlocks          int64            `json:"recent-missed-blocks"`
+	RecentBlocksChecked         int64            `json:"recent-blocks-checked"`
+	RecentMissedBlockAlertLevel AlertLevel       `json:"recent-missed-blocks-alert-level"`
+	LastSignedBlockHeight       int64            `json:"last-signed-block-height"`
+	LastSignedBlockTimestamp    time.Time        `json:"last-signed-block-timestamp"`
+	BondStatus                  string           `json:"bond-status,omitempty"`
+	BondedTokens                string           `json:"bonded-tokens,omitempty"`
+	VotingPower                 int64            `json:"voting-power"`
+	VotingPowerRank             int              `json:"voting-power-rank"`
+	UpgradeName                 string           `json:"upgrade-name,omitempty"`
+	UpgradeHeight               int64            `json:"upgrade-height,omitempty"`
+	BlockTimeSeconds            float64          `json:"block-time-seconds"`
+	Sentries                    []APISentryStats `json:"sentries"`
+
+	BlocksUntilJailed *int64 `json:"blocks-until-jailed,omitempty"` // missed blocks left before jailing, when known
+
+	RecentMissedBlockStreak int64 `json:"recent-missed-block-streak"`
+
+	UptimeTrend          *float64 `json:"uptime-trend,omitempty"` // percentage points per hour, when known
+	UptimeTrendDirection string   `json:"uptime-trend-direction,omitempty"`
+}
+
+type APISentryStats struct {
+	Name    string `json:"name"`
+	Version string `json:"version"`
+	Height  int64  `json:"height"`
+	Peers   int    `json:"peers"`  // -1 when unknown
+	Status  string `json:"status"` // ok, grpc-error, out-of-sync, halted, or low-peers
+}
+
+type APIAlertState struct {
+	ActiveAlertLevel       AlertLevel           `json:"active-alert-level"`
+	ActiveAlerts           []APIActiveAlert     `json:"active-alerts"`
+	RecentMissedBlocksMax  int64                `json:"recent-missed-blocks-max"`
+	VotingPowerMax         int64                `json:"voting-power-max"`
+	ConsecutiveRPCFailures int64                `json:"consecutive-rpc-failures"`
+	RPCFailingSince        *time.Time           `json:"rpc-failing-since,omitempty"`
+	RPCLastSuccess         *time.Time           `json:"rpc-last-success,omitempty"`
+	DoubleSignHeight       int64                `json:"double-sign-height,omitempty"`
+	LastSent               map[string]time.Time `json:"last-sent,omitempty"` // by alert type, only for alert types with a cooldown
+
+	RecentMissedBlockStreakMax int64 `json:"recent-missed-block-streak-max"`
+}
+
+// APIActiveAlert is an alert that was seen in consecutive checks, up to the latest check
+type APIActiveAlert struct {
+	AlertType AlertType `json:"alert-type"`
+	Sentry    string    `json:"sentry,omitempty"`
+	Checks    int64     `json:"checks"`
+	DedupKey  string    `json:"dedup-key"`
+	Acked     bool      `json:"acked,omitempty"`
+}
+
+// APIAck is the request and response of POST /api/v1/ack
+type APIAck struct {
+	DedupKey  string    `json:"dedup-key"`
+	Validator string    `json:"validator,omitempty"`
+	AlertType AlertType `json:"alert-type,omitempty"`
+	Sentry    string    `json:"sentry,omitempty"`
+}
+
+func getAPIValidatorStats(vm *ValidatorMonitor, stats ValidatorStats) *APIValidatorStats {
+	apiStats := &APIValidatorStats{
+		Timestamp:                   stats.Timestamp,
+		Height:                      stats.Height,
+		AlertLevel:                  stats.AlertLevel,
+		RPCError:                    stats.RPCError,
+		SlashingPeriodUptime:        stats.SlashingPeriodUptime,
+		RecentMissedBlocks:          stats.RecentMissedBlocks,
+		RecentBlocksChecked:         vm.RecentBlocksToCheck,
+		RecentMissedBlockAlertLevel: stats.RecentMissedBlockAlertLevel,
+		LastSignedBlockHeight:       stats.LastSignedBlockHeight,
+		LastSignedBlockTimestamp:    stats.LastSignedBlockTimestamp,
+		BondStatus:                  stats.BondStatus,
+		BondedTokens:                stats.BondedTokens,
+		VotingPower:                 stats.VotingPower,
+		VotingPowerRank:             stats.VotingPowerRank,
+		UpgradeName:                 stats.UpgradeName,
+		UpgradeHeight:               stats.UpgradeHeight,
+		BlockTimeSeconds:            stats.BlockTime.Seconds(),
+		Sentries:                    []APISentryStats{},
+
+		RecentMissedBlockStreak: stats.RecentMissedBlockStreak,
+	}
+	if stats.BlocksUntilJailed >= 0 && !vm.FullNode {
+		blocksUntilJailed := stats.BlocksUntilJailed
+		apiStats.BlocksUntilJailed = &blocksUntilJailed
+	}
+	if stats.UptimeTrendKnown {
+		uptimeTrend := stats.UptimeTrend
+		apiStats.UptimeTrend = &uptimeTrend
+		apiStats.UptimeTrendDirection = getUptimeTrendDirection(uptimeTrend)
+	}
+	for _, sentryStats := range stats.SentryStats {
+		apiStats.Sentries = append(apiStats.Sentries, APISentryStats{
+			Name:    sentryStats.Name,
+			Version: sentryStats.Version,
+			Height:  sentryStats.Height,
+			Peers:   sentryStats.Peers,
+			Status:  sentryAlertTypeNames[sentryStats.SentryAlertType],
+		})
+	}
+	return apiStats
+}
+
+// requires locked alertState
+func getAPIAlertState(vm *ValidatorMonitor, alertState *ValidatorAlertState) *APIAlertState {
+	apiAlertState := &APIAlertState{
+		ActiveAlertLevel:       alertState.ActiveAlertLevel,
+		ActiveAlerts:           []APIActiveAlert{},
+		RecentMissedBlocksMax:  alertState.RecentMissedBlocksCounterMax,
+		VotingPowerMax:         alertState.VotingPowerMax,
+		ConsecutiveRPCFailures: alertState.ConsecutiveRPCFailures,
+		DoubleSignHeight:       alertState.DoubleSignHeight,
+
+		RecentMissedBlockStreakMax: alertState.RecentMissedBlockStreakMax,
+	}
+	if alertState.ConsecutiveRPCFailures > 0 {
+		rpcFailingSince := alertState.RPCFailingSince
+		apiAlertState.RPCFailingSince = &rpcFailingSince
+	}
+	if !alertState.RPCLastSuccess.IsZero() {
+		rpcLastSuccess := alertState.RPCLastSuccess
+		apiAlertState.RPCLastSuccess = &rpcLastSuccess
+	}
+	for _, alertType := range alertTypes {
+		if count := alertState.AlertTypeCounts[alertType]; count > 0 {
+			apiAlertState.ActiveAlerts = append(apiAlertState.ActiveAlerts, APIActiveAlert{AlertType: alertType, Checks: count})
+		}
+	}
+	// sentry alert counts are kept in maps, so they are sorted for a stable order
+	var sentryAlerts []APIActiveAlert
+	for alertType, counts := range map[AlertType]map[string]int64{
+		alertTypeSentryGRPC:      alertState.SentryGRPCErrorCounts,
+		alertTypeSentryOutOfSync: alertState.SentryOutOfSyncErrorCounts,
+		alertTypeSentryHalt:      alertState.SentryHaltErrorCounts,
+		alertTypeSentryLowPeers:  alertState.SentryLowPeersErrorCounts,
+		alertTypeSentryHost:      alertState.SentryHostErrorCounts,
+	} {
+		for sentry, count := range counts {
+			if count > 0 {
+				sentryAlerts = append(sentryAlerts, APIActiveAlert{AlertType: alertType, Sentry: sentry, Checks: count})
+			}
+		}
+	}
+	sort.Slice(sentryAlerts, func(i, j int) bool {
+		if sentryAlerts[i].AlertType != sentryAlerts[j].AlertType {
+			return sentryAlerts[i].AlertType < sentryAlerts[j].AlertType
+		}
+		return sentryAlerts[i].Sentry < sentryAlerts[j].Sentry
+	})
+	apiAlertState.ActiveAlerts = append(apiAlertState.ActiveAlerts, sentryAlerts...)
+	for i, alert := range apiAlertState.ActiveAlerts {
+		key := AlertKey{AlertType: alert.AlertType, Sentry: alert.Sentry}
+		apiAlertState.ActiveAlerts[i].DedupKey = key.dedupKey(vm)
+		_, apiAlertState.ActiveAlerts[i].Acked = alertState.AckedAlerts[key]
+	}
+	if len(alertState.AlertLastSent) > 0 {
+		apiAlertState.LastSent = make(map[string]time.Time)
+		for alertType, lastSent := range alertState.AlertLastSent {
+			apiAlertState.LastSent[string(alertType)] = lastSent
+		}
+	}
+	return apiAlertState
+}
+
+// validatorStates holds the state served by the API for each validator by validator name
+var validatorStates = validatorStateRegistry{states: make(map[string]APIValidatorState)}
+
+type validatorStateRegistry struct {
+	lock   sync.Mutex
+	states map[string]APIValidatorState
+}
+
+// requires locked alertState
+func (registry *validatorStateRegistry) update(vm *ValidatorMonitor, stats ValidatorStats, alertState *ValidatorAlertState) {
+	state := APIValidatorState{
+		Name:       vm.Name,
+		ChainID:    vm.ChainID,
+		Group:      vm.getGroup(),
+		Tags:       vm.Tags,
+		FullNode:   vm.FullNode,
+		Stats:      getAPIValidatorStats(vm, stats),
+		AlertState: getAPIAlertState(vm, alertState),
+	}
+	updated := time.Now()
+	state.Updated = &updated
+	registry.lock.Lock()
+	registry.states[vm.Name] = state
+	registry.lock.Unlock()
+}
+
+func (registry *validatorStateRegistry) get(vm *ValidatorMonitor) APIValidatorState {
+	registry.lock.Lock()
+	defer registry.lock.Unlock()
+	if state, ok := registry.states[vm.Name]; ok {
+		return state
+	}
+	return APIValidatorState{
+		Name:     vm.Name,
+		ChainID:  vm.ChainID,
+		Group:    vm.getGroup(),
+		Tags:     vm.Tags,
+		FullNode: vm.FullNode,
+	}
+}
+
+// withBearerToken requires the bearer token for requests to the handler when the token is not empty
+func withBearerToken(token string, handler http.HandlerFunc) http.HandlerFunc {
+	if token == "" {
+		return handler
+	}
+	return func(w http.ResponseWriter, r *http.Request) {
+		auth := r.Header.Get("Authorization")
+		if !strings.HasPrefix(auth, "Bearer ") || subtle.ConstantTimeCompare([]byte(strings.TrimPrefix(auth, "Bearer ")), []byte(token)) != 1 {
+			w.Header().Set("WWW-Authenticate", "Bearer")
+			http.Error(w, "unauthorized", http.StatusUnauthorized)
+			return
+		}
+		handler(w, r)
+	}
+}
+
+// serveAPIValidators serves the state of all validators, or of a single validator at /api/v1/validators/<name>
+func serveAPIValidators(validators []*ValidatorMonitor) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet {
+			http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+		name := strings.Trim(strings.TrimPrefix(r.URL.Path, apiValidatorsPath), "/")
+		if name == "" {
+			res := APIValidators{Version: apiVersion, Validators: []APIValidatorState{}}
+			for _, vm := range validators {
+				res.Validators = append(res.Validators, validatorStates.get(vm))
+			}
+			writeJSON(w, res)
+			return
+		}
+		for _, vm := range validators {
+			if vm.Name == name {
+				writeJSON(w, validatorStates.get(vm))
+				return
+			}
+		}
+		http.Error(w, fmt.Sprintf("validator %s not found", name), http.StatusNotFound)
+	}
+}
+
+// serveAPIAck acknowledges the active alert with the dedup key, so that it is not re-notified until it clears
+func serveAPIAck(
+	validators []*ValidatorMonitor,
+	alertState map[string]*ValidatorAlertState,
+	alertStateLocks map[string]*sync.Mutex,
+) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost {
+			http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+		var ack APIAck
+		if err := json.NewDecoder(r.Body).Decode(&ack); err != nil || ack.DedupKey == "" {
+			http.Error(w, "expected a json body with a dedup-key", http.StatusBadRequest)
+			return
+		}
+		for _, vm := range validators {
+			lock := alertStateLocks[vm.Name]
+			lock.Lock()
+			for _, alert := range getAPIAlertState(vm, alertState[vm.Name]).ActiveAlerts {
+				if alert.DedupKey != ack.DedupKey {
+					continue
+				}
+				alertState[vm.Name].AckedAlerts[AlertKey{AlertType: alert.AlertType, Sentry: alert.Sentry}] = time.Now()
+				lock.Unlock()
+				fmt.Printf("Acknowledged %s alert %s for %s\n", alert.AlertType, ack.DedupKey, vm.Name)
+				writeJSON(w, APIAck{DedupKey: ack.DedupKey, Validator: vm.Name, AlertType: alert.AlertType, Sentry: alert.Sentry})
+				return
+			}
+			lock.Unlock()
+		}
+		http.Error(w, fmt.Sprintf("no active alert with dedup-key %s", ack.DedupKey), http.StatusNotFound)
+	}
+}
+
+// startAPIServer serves the API in the background when a listen address is configured.
+// Acks are accepted when the alert state is provided and a bearer token is set.
+func startAPIServer(
+	config *HalfLifeConfig,
+	validators []*ValidatorMonitor,
+	alertState map[string]*ValidatorAlertState,
+	alertStateLocks map[string]*sync.Mutex,
+) {
+	if config.API == nil || config.API.Listen == "" {
+		return
+	}
+	handler := withBearerToken(config.API.BearerToken, serveAPIValidators(validators))
+	mux := http.NewServeMux()
+	mux.HandleFunc(apiValidatorsPath, handler)
+	mux.HandleFunc(apiValidatorsPath+"/", handler)
+	if alertState != nil {
+		if config.API.BearerToken == "" {
+			fmt.Println("API bearer-token is not set, not accepting alert acks")
+		} else {
+			mux.HandleFunc(apiAckPath, withBearerToken(config.API.BearerToken, serveAPIAck(validators, alertState, alertStateLocks)))
+		}
+	}
+	go func() {
+		fmt.Printf("Serving API on %s\n", config.API.Listen)
+		if err := http.ListenAndServe(config.API.Listen, mux); err != nil {
+			fmt.Printf("Error serving API: %s\n", redactError(err))
+		}
+	}()
+}
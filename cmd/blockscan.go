@@ -0,0 +1,61 @@
+package cmd
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+const (
+	defaultRecentBlocksConcurrency = 10
+	recentBlocksScanTimeout        = checkInterval // the scan is cut short rather than delay the next check
+)
+
+// blockFetcher returns the signing info of the block at the height
+type blockFetcher func(ctx context.Context, height int64) (blockSigningInfo, error)
+
+// recentBlock is the result of fetching a block in the recent blocks scan
+type recentBlock struct {
+	height  int64
+	info    blockSigningInfo
+	err     error
+	skipped bool // not fetched before the scan timed out
+}
+
+// scanRecentBlocks fetches the blocks from the height down, count blocks, with at most concurrency requests at a time.
+// The results are in order from the height down. Blocks that were not fetched before the context is done are skipped.
+func scanRecentBlocks(ctx context.Context, height int64, count int64, concurrency int, fetch blockFetcher) []recentBlock {
+	var blocks []recentBlock
+	for i := height; i > height-count && i > 0; i-- {
+		blocks = append(blocks, recentBlock{height: i})
+	}
+	if concurrency < 1 {
+		concurrency = 1
+	}
+	sem := make(chan struct{}, concurrency)
+	wg := sync.WaitGroup{}
+	for i := range blocks {
+		select {
+		case <-ctx.Done():
+		case sem <- struct{}{}:
+		}
+		if ctx.Err() != nil {
+			for j := i; j < len(blocks); j++ {
+				blocks[j].skipped = true
+			}
+			break
+		}
+		wg.Add(1)
+		go func(block *recentBlock) {
+			defer func() {
+				<-sem
+				wg.Done()
+			}()
+			blockCtx, blockCtxCancel := context.WithTimeout(ctx, time.Duration(time.Second*RPCTimeoutSeconds))
+			defer blockCtxCancel()
+			block.info, block.err = fetch(blockCtx, block.height)
+		}(&blocks[i])
+	}
+	wg.Wait()
+	return blocks
+}
@@ -0,0 +1,107 @@
+package cmd
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestScanRecentBlocksSlidingWindow(t *testing.T) {
+	const (
+		height      = int64(100)
+		count       = int64(23) // not a multiple of the concurrency
+		concurrency = 5
+	)
+	// there are no batches: at most 5 fetches are in flight, and each slot is reused as soon as its fetch returns.
+	// The fetches complete out of order, so later heights are fetched while earlier ones are still in flight, and
+	// the results must still come back in order from the height down, with the streaks counted across them
+	missed := map[int64]bool{96: true, 95: true, 91: true, 90: true, 89: true, 87: true}
+	const failedHeight = int64(88)
+
+	var lock sync.Mutex
+	inFlight, maxInFlight := 0, 0
+	// heights in flight, to count the fetches started in a slot freed while an earlier height was still in flight
+	running := make(map[int64]bool)
+	reused := 0
+	fetch := func(ctx context.Context, h int64) (blockSigningInfo, error) {
+		lock.Lock()
+		inFlight++
+		if inFlight > maxInFlight {
+			maxInFlight = inFlight
+		}
+		for other := range running {
+			if other > h+concurrency-1 {
+				reused++
+				break
+			}
+		}
+		running[h] = true
+		lock.Unlock()
+		defer func() {
+			lock.Lock()
+			inFlight--
+			delete(running, h)
+			lock.Unlock()
+		}()
+		// complete out of order
+		time.Sleep(time.Duration(h%3) * time.Millisecond)
+		if h == failedHeight {
+			return blockSigningInfo{}, errors.New("block fetch failed")
+		}
+		return blockSigningInfo{height: h, signed: !missed[h]}, nil
+	}
+
+	blocks := scanRecentBlocks(context.Background(), height, count, concurrency, fetch)
+
+	if int64(len(blocks)) != count {
+		t.Fatalf("scanned %d blocks, want %d", len(blocks), count)
+	}
+	for i, block := range blocks {
+		if want := height - int64(i); block.height != want {
+			t.Errorf("block %d has height %d, want %d", i, block.height, want)
+		}
+		if block.skipped {
+			t.Errorf("block %d skipped", block.height)
+		}
+		if (block.err != nil) != (block.height == failedHeight) {
+			t.Errorf("block %d has error %v", block.height, block.err)
+		}
+	}
+	if maxInFlight > concurrency {
+		t.Errorf("%d fetches in flight, want at most %d", maxInFlight, concurrency)
+	}
+	if reused == 0 {
+		t.Errorf("no fetch started while an earlier height was still in flight")
+	}
+
+	vm := newTestValidatorMonitor()
+	vm.RecentBlocksToCheck = count
+	stats := &ValidatorStats{LastSignedBlockHeight: -1}
+	errs := stats.determineRecentBlocks(vm, blocks)
+	if stats.RecentMissedBlocks != int64(len(missed)) {
+		t.Errorf("RecentMissedBlocks = %d, want %d", stats.RecentMissedBlocks, len(missed))
+	}
+	// the failed block neither extends nor ends the streak of 91 down to 87
+	if stats.RecentMissedBlockStreak != 4 {
+		t.Errorf("RecentMissedBlockStreak = %d, want 4", stats.RecentMissedBlockStreak)
+	}
+	if stats.LastSignedBlockHeight != height {
+		t.Errorf("LastSignedBlockHeight = %d, want %d", stats.LastSignedBlockHeight, height)
+	}
+	var rpcErrs, missedErrs int
+	for _, err := range errs {
+		switch err.(type) {
+		case *GenericRPCError:
+			rpcErrs++
+		case *MissedRecentBlocksError:
+			missedErrs++
+		default:
+			t.Errorf("unexpected error %v", err)
+		}
+	}
+	if rpcErrs != 1 || missedErrs != 1 {
+		t.Errorf("got %d rpc errors and %d missed blocks errors, want 1 of each", rpcErrs, missedErrs)
+	}
+}
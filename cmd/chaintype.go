@@ -0,0 +1,132 @@
+package cmd
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/cosmos/cosmos-sdk/types/bech32"
+	stakingtypes "github.com/cosmos/cosmos-sdk/x/staking/types"
+	"github.com/tendermint/tendermint/crypto/ed25519"
+	"github.com/tendermint/tendermint/crypto/secp256k1"
+	rpcclient "github.com/tendermint/tendermint/rpc/client"
+	tmtypes "github.com/tendermint/tendermint/types"
+)
+
+type ChainType string
+
+const (
+	// cosmos-sdk chains, the default
+	chainTypeCosmos ChainType = "cosmos"
+	// cosmos-sdk chains with an EVM, e.g. Evmos. Consensus keys are tendermint ed25519 keys,
+	// so the consensus address and block signatures are the same as cosmos
+	chainTypeEvmos ChainType = "evmos"
+	// chains using CometBFT consensus without the cosmos-sdk modules, e.g. Berachain.
+	// Signing and jailing are determined from blocks and the validator set only.
+	chainTypeCometBFT ChainType = "cometbft"
+
+	validatorSetPageLimit = 100
+)
+
+var chainTypes = []ChainType{chainTypeCosmos, chainTypeEvmos, chainTypeCometBFT}
+
+func (vm *ValidatorMonitor) getChainType() ChainType {
+	if vm.ChainType == "" {
+		return chainTypeCosmos
+	}
+	return vm.ChainType
+}
+
+func (ct ChainType) validate() error {
+	for _, chainType := range chainTypes {
+		if ct == chainType {
+			return nil
+		}
+	}
+	return fmt.Errorf("unsupported chain-type %q", ct)
+}
+
+// hasSDKModules returns true if the chain has the cosmos-sdk slashing, staking and upgrade modules
+func (ct ChainType) hasSDKModules() bool {
+	return ct != chainTypeCometBFT
+}
+
+// consensusAddress decodes the validator consensus address, a bech32 valcons address,
+// or a hex address as shown by CometBFT for cometbft chains
+func (ct ChainType) consensusAddress(address string) ([]byte, error) {
+	if ct == chainTypeCometBFT {
+		hexAddress, err := hex.DecodeString(strings.TrimPrefix(address, "0x"))
+		if err != nil {
+			return nil, fmt.Errorf("invalid hex consensus address %s: %w", address, err)
+		}
+		return hexAddress, nil
+	}
+	_, hexAddress, err := bech32.DecodeAndConvert(address)
+	return hexAddress, err
+}
+
+// parseConsensusAddress decodes the consensus-address of a validator: a hex consensus address, a base64 ed25519 or
+// secp256k1 consensus pubkey, or the pubkey json as shown by the node's show-validator command
+func parseConsensusAddress(value string) ([]byte, error) {
+	value = strings.TrimSpace(value)
+	if strings.HasPrefix(value, "{") {
+		var pubKey struct {
+			Key string `json:"key"`
+		}
+		if err := json.Unmarshal([]byte(value), &pubKey); err != nil || pubKey.Key == "" {
+			return nil, fmt.Errorf("invalid consensus pubkey json %s", value)
+		}
+		value = pubKey.Key
+	}
+	if hexAddress, err := hex.DecodeString(strings.TrimPrefix(value, "0x")); err == nil && len(hexAddress) == 20 {
+		return hexAddress, nil
+	}
+	pubKey, err := base64.StdEncoding.DecodeString(value)
+	if err != nil {
+		return nil, fmt.Errorf("invalid consensus-address %s, expected a hex address or base64 pubkey", value)
+	}
+	switch len(pubKey) {
+	case ed25519.PubKeySize:
+		return ed25519.PubKey(pubKey).Address(), nil
+	case secp256k1.PubKeySize:
+		return secp256k1.PubKey(pubKey).Address(), nil
+	default:
+		return nil, fmt.Errorf("invalid consensus pubkey %s, unsupported key length %d", value, len(pubKey))
+	}
+}
+
+// getValidatorSet fetches every validator in the consensus validator set at the height, following pagination
+func getValidatorSet(ctx context.Context, node rpcclient.Client, height int64) ([]*tmtypes.Validator, error) {
+	var validators []*tmtypes.Validator
+	perPage := validatorSetPageLimit
+	for page := 1; ; page++ {
+		pageCtx, cancel := context.WithTimeout(ctx, time.Duration(time.Second*RPCTimeoutSeconds))
+		res, err := node.Validators(pageCtx, &height, &page, &perPage)
+		cancel()
+		if err != nil {
+			return nil, err
+		}
+		validators = append(validators, res.Validators...)
+		if len(validators) >= res.Total || len(res.Validators) == 0 {
+			return validators, nil
+		}
+	}
+}
+
+// determineValidatorSetPower records the validator's voting power and rank from the consensus validator set,
+// for chains without the staking module. A validator outside of the set is reported as unbonded.
+func (stats *ValidatorStats) determineValidatorSetPower(validators []*tmtypes.Validator, hexAddress []byte) {
+	stats.BondStatus = stakingtypes.Unbonded.String()
+	for i, validator := range validators {
+		if strings.EqualFold(validator.Address.String(), hex.EncodeToString(hexAddress)) {
+			stats.BondStatus = stakingtypes.Bonded.String()
+			stats.VotingPower = validator.VotingPower
+			stats.VotingPowerRank = i + 1
+			return
+		}
+	}
+}
@@ -2,27 +2,64 @@ package cmd
 
 import (
 	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"net/http"
 	"os"
+	"strings"
 	"time"
 
 	cosmosClient "github.com/cosmos/cosmos-sdk/client"
 	tmservice "github.com/cosmos/cosmos-sdk/client/grpc/tmservice"
+	codectypes "github.com/cosmos/cosmos-sdk/codec/types"
+	cryptocodec "github.com/cosmos/cosmos-sdk/crypto/codec"
+	querytypes "github.com/cosmos/cosmos-sdk/types/query"
 	slashingtypes "github.com/cosmos/cosmos-sdk/x/slashing/types"
+	stakingtypes "github.com/cosmos/cosmos-sdk/x/staking/types"
+	upgradetypes "github.com/cosmos/cosmos-sdk/x/upgrade/types"
 	rpcclient "github.com/tendermint/tendermint/rpc/client"
 	rpchttp "github.com/tendermint/tendermint/rpc/client/http"
+	ctypes "github.com/tendermint/tendermint/rpc/core/types"
 	libclient "github.com/tendermint/tendermint/rpc/jsonrpc/client"
 	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials"
 )
 
 const (
 	sentryGRPCTimeoutSeconds = 5
 	RPCTimeoutSeconds        = 5
+	validatorsPageLimit      = 200
 )
 
-func newClient(addr string) (rpcclient.Client, error) {
-	httpClient, err := libclient.DefaultHTTPClient(addr)
-	if err != nil {
-		return nil, err
+// interfaceRegistry is used to unpack validator consensus pubkeys from staking query responses
+var interfaceRegistry = newInterfaceRegistry()
+
+func newInterfaceRegistry() codectypes.InterfaceRegistry {
+	registry := codectypes.NewInterfaceRegistry()
+	cryptocodec.RegisterInterfaces(registry)
+	return registry
+}
+
+func newClient(addr string, proxyConfig string, rpcTLS *RPCTLSConfig) (rpcclient.Client, error) {
+	httpClient := newProxyHTTPClient(proxyConfig, 0)
+	if rpcTLS != nil && strings.HasPrefix(addr, "https://") {
+		tlsConfig, err := rpcTLS.tlsConfig()
+		if err != nil {
+			return nil, err
+		}
+		if httpClient == nil {
+			httpClient = &http.Client{Transport: &http.Transport{DisableCompression: true}}
+		}
+		httpClient.Transport.(*http.Transport).TLSClientConfig = tlsConfig
+	}
+	if httpClient == nil || !(strings.HasPrefix(addr, "http://") || strings.HasPrefix(addr, "https://")) {
+		var err error
+		httpClient, err = libclient.DefaultHTTPClient(addr)
+		if err != nil {
+			return nil, err
+		}
 	}
 
 	httpClient.Timeout = 10 * time.Second
@@ -34,8 +71,8 @@ func newClient(addr string) (rpcclient.Client, error) {
 	return rpcClient, nil
 }
 
-func getCosmosClient(rpcAddress string, chainID string) (*cosmosClient.Context, error) {
-	client, err := newClient(rpcAddress)
+func getCosmosClient(rpcAddress string, chainID string, proxyConfig string, rpcTLS *RPCTLSConfig) (*cosmosClient.Context, error) {
+	client, err := newClient(rpcAddress, proxyConfig, rpcTLS)
 	if err != nil {
 		return nil, err
 	}
@@ -48,28 +85,161 @@ func getCosmosClient(rpcAddress string, chainID string) (*cosmosClient.Context,
 	}, nil
 }
 
-func getSlashingInfo(client *cosmosClient.Context) (*slashingtypes.QueryParamsResponse, error) {
-	ctx, cancel := context.WithTimeout(context.Background(), time.Duration(time.Second*RPCTimeoutSeconds))
+func getSlashingInfo(ctx context.Context, client *cosmosClient.Context) (*slashingtypes.QueryParamsResponse, error) {
+	ctx, cancel := context.WithTimeout(ctx, time.Duration(time.Second*RPCTimeoutSeconds))
 	defer cancel()
 	return slashingtypes.NewQueryClient(client).Params(ctx, &slashingtypes.QueryParamsRequest{})
 }
 
-func getSigningInfo(client *cosmosClient.Context, address string) (*slashingtypes.QuerySigningInfoResponse, error) {
-	ctx, cancel := context.WithTimeout(context.Background(), time.Duration(time.Second*RPCTimeoutSeconds))
+func getSigningInfo(ctx context.Context, client *cosmosClient.Context, address string) (*slashingtypes.QuerySigningInfoResponse, error) {
+	ctx, cancel := context.WithTimeout(ctx, time.Duration(time.Second*RPCTimeoutSeconds))
 	defer cancel()
 	return slashingtypes.NewQueryClient(client).SigningInfo(ctx, &slashingtypes.QuerySigningInfoRequest{
 		ConsAddress: address,
 	})
 }
 
-func getSentryInfo(grpcAddr string) (*tmservice.GetNodeInfoResponse, *tmservice.GetLatestBlockResponse, error) {
-	conn, err := grpc.Dial(grpcAddr, grpc.WithInsecure())
+func getUpgradePlan(ctx context.Context, client *cosmosClient.Context) (*upgradetypes.Plan, error) {
+	ctx, cancel := context.WithTimeout(ctx, time.Duration(time.Second*RPCTimeoutSeconds))
+	defer cancel()
+	res, err := upgradetypes.NewQueryClient(client).CurrentPlan(ctx, &upgradetypes.QueryCurrentPlanRequest{})
+	if err != nil {
+		return nil, err
+	}
+	return res.Plan, nil
+}
+
+func getSentryPeerCount(ctx context.Context, rpcAddr string, proxyConfig string, rpcTLS *RPCTLSConfig) (int, error) {
+	client, err := newClient(rpcAddr, proxyConfig, rpcTLS)
+	if err != nil {
+		return 0, err
+	}
+	ctx, cancel := context.WithTimeout(ctx, time.Duration(time.Second*RPCTimeoutSeconds))
+	defer cancel()
+	netInfo, err := client.NetInfo(ctx)
+	if err != nil {
+		return 0, err
+	}
+	return netInfo.NPeers, nil
+}
+
+func getRPCStatus(ctx context.Context, rpcAddr string, proxyConfig string, rpcTLS *RPCTLSConfig) (*ctypes.ResultStatus, error) {
+	client, err := newClient(rpcAddr, proxyConfig, rpcTLS)
+	if err != nil {
+		return nil, err
+	}
+	ctx, cancel := context.WithTimeout(ctx, time.Duration(time.Second*RPCTimeoutSeconds))
+	defer cancel()
+	return client.Status(ctx)
+}
+
+// tlsConfig returns the TLS config for https RPC endpoints, trusting the ca-cert in addition to the system roots
+func (c *RPCTLSConfig) tlsConfig() (*tls.Config, error) {
+	tlsConfig := &tls.Config{InsecureSkipVerify: c.InsecureSkipVerify}
+	if c.CACert == "" {
+		return tlsConfig, nil
+	}
+	caCert, err := os.ReadFile(c.CACert)
+	if err != nil {
+		return nil, fmt.Errorf("error reading rpc ca-cert: %w", err)
+	}
+	certPool, err := x509.SystemCertPool()
+	if err != nil || certPool == nil {
+		certPool = x509.NewCertPool()
+	}
+	if !certPool.AppendCertsFromPEM(caCert) {
+		return nil, fmt.Errorf("no certificates found in rpc ca-cert %s", c.CACert)
+	}
+	tlsConfig.RootCAs = certPool
+	return tlsConfig, nil
+}
+
+// grpcTransportCredentials returns the TLS credentials for the sentry, or nil for a plaintext connection
+func (sentry Sentry) grpcTransportCredentials(useTLS bool) (credentials.TransportCredentials, error) {
+	if !useTLS {
+		if sentry.CACert != "" || sentry.ClientCert != "" || sentry.ClientKey != "" || sentry.InsecureSkipVerify {
+			return nil, errors.New("ca-cert, client-cert, client-key and insecure-skip-verify require a tls connection, set tls: true, scheme: tls, or a grpcs:// address")
+		}
+		return nil, nil
+	}
+	tlsConfig := &tls.Config{InsecureSkipVerify: sentry.InsecureSkipVerify}
+	if sentry.CACert != "" {
+		caCert, err := os.ReadFile(sentry.CACert)
+		if err != nil {
+			return nil, fmt.Errorf("error reading ca-cert: %w", err)
+		}
+		certPool := x509.NewCertPool()
+		if !certPool.AppendCertsFromPEM(caCert) {
+			return nil, fmt.Errorf("no certificates found in ca-cert %s", sentry.CACert)
+		}
+		tlsConfig.RootCAs = certPool
+	}
+	if sentry.ClientCert != "" || sentry.ClientKey != "" {
+		if sentry.ClientCert == "" || sentry.ClientKey == "" {
+			return nil, errors.New("client-cert and client-key must be provided together")
+		}
+		clientCert, err := tls.LoadX509KeyPair(sentry.ClientCert, sentry.ClientKey)
+		if err != nil {
+			return nil, fmt.Errorf("error loading client certificate: %w", err)
+		}
+		tlsConfig.Certificates = []tls.Certificate{clientCert}
+	}
+	return credentials.NewTLS(tlsConfig), nil
+}
+
+func getSentryInfo(ctx context.Context, sentry Sentry, connKey string, proxyConfig string, globalGRPCConfig *SentryGRPCConfig) (*tmservice.GetNodeInfoResponse, *tmservice.GetLatestBlockResponse, error) {
+	target, useTLS, err := sentry.grpcTarget()
+	if err != nil {
+		return nil, nil, err
+	}
+	transportCredentials, err := sentry.grpcTransportCredentials(useTLS)
+	if err != nil {
+		return nil, nil, err
+	}
+	dialOption := grpc.WithInsecure()
+	if transportCredentials != nil {
+		dialOption = grpc.WithTransportCredentials(transportCredentials)
+	}
+	dialOptions := []grpc.DialOption{dialOption}
+	if proxyDialOption := grpcProxyDialOption(proxyConfig); proxyDialOption != nil {
+		dialOptions = append(dialOptions, proxyDialOption)
+	}
+	grpcConfig := sentry.getSentryGRPCConfig(globalGRPCConfig)
+	if keepaliveDialOption := grpcConfig.keepaliveDialOption(); keepaliveDialOption != nil {
+		dialOptions = append(dialOptions, keepaliveDialOption)
+	}
+
+	if !grpcConfig.reuseConnection() {
+		conn, err := grpc.Dial(target, dialOptions...)
+		if err != nil {
+			return nil, nil, err
+		}
+		defer conn.Close()
+		return querySentryInfo(ctx, conn)
+	}
+
+	conn, reused, err := sentryConns.get(connKey, target, dialOptions)
 	if err != nil {
 		return nil, nil, err
 	}
-	defer conn.Close()
+	nodeInfo, syncingInfo, err := querySentryInfo(ctx, conn)
+	if err != nil && isGRPCTransportError(err) {
+		// reconnect rather than reusing a dead channel
+		sentryConns.drop(connKey, conn)
+		if reused {
+			conn, _, err = sentryConns.get(connKey, target, dialOptions)
+			if err != nil {
+				return nil, nil, err
+			}
+			return querySentryInfo(ctx, conn)
+		}
+	}
+	return nodeInfo, syncingInfo, err
+}
+
+func querySentryInfo(ctx context.Context, conn *grpc.ClientConn) (*tmservice.GetNodeInfoResponse, *tmservice.GetLatestBlockResponse, error) {
 	serviceClient := tmservice.NewServiceClient(conn)
-	ctx, cancel := context.WithTimeout(context.Background(), time.Duration(time.Second*sentryGRPCTimeoutSeconds))
+	ctx, cancel := context.WithTimeout(ctx, time.Duration(time.Second*sentryGRPCTimeoutSeconds))
 	defer cancel()
 	nodeInfo, err := serviceClient.GetNodeInfo(ctx, &tmservice.GetNodeInfoRequest{})
 	if err != nil {
@@ -81,3 +251,31 @@ func getSentryInfo(grpcAddr string) (*tmservice.GetNodeInfoResponse, *tmservice.
 	}
 	return nodeInfo, syncingInfo, nil
 }
+
+// getStakingValidators fetches every validator in the staking module, following pagination
+func getStakingValidators(ctx context.Context, client *cosmosClient.Context) (stakingtypes.Validators, error) {
+	var validators stakingtypes.Validators
+	var nextKey []byte
+	for {
+		pageCtx, cancel := context.WithTimeout(ctx, time.Duration(time.Second*RPCTimeoutSeconds))
+		res, err := stakingtypes.NewQueryClient(client).Validators(pageCtx, &stakingtypes.QueryValidatorsRequest{
+			Pagination: &querytypes.PageRequest{
+				Key:   nextKey,
+				Limit: validatorsPageLimit,
+			},
+		})
+		cancel()
+		if err != nil {
+			return nil, err
+		}
+		validators = append(validators, res.Validators...)
+		if res.Pagination == nil || len(res.Pagination.NextKey) == 0 {
+			break
+		}
+		nextKey = res.Pagination.NextKey
+	}
+	if err := validators.UnpackInterfaces(interfaceRegistry); err != nil {
+		return nil, err
+	}
+	return validators, nil
+}
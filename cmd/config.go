@@ -1,9 +1,15 @@
 package cmd
 
 import (
+	"crypto/sha256"
+	"encoding/hex"
 	"errors"
 	"fmt"
+	"math"
 	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
 	"sync"
 	"time"
 
@@ -20,6 +26,24 @@ const (
 	sentryGRPCErrorNotifyThreshold                      = 1 // will notify with error for any more than this number of consecutive grpc errors for a given sentry
 	sentryOutOfSyncErrorNotifyThreshold                 = 1 // will notify with error for any more than this number of consecutive out of sync errors for a given sentry
 	sentryHaltErrorNotifyThreshold                      = 1 // will notify with error for any more than this number of consecutive halt errors for a given sentry
+	sentryLowPeersErrorNotifyThreshold                  = 1 // will notify with error for any more than this number of consecutive low peer count errors for a given sentry
+	defaultSentryMinPeers                               = 2
+	defaultVotingPowerDropThreshold             float64 = 10 // percent drop from the highest observed voting power
+	defaultUpgradeAlertBlocks                   int64   = 1000
+	defaultSentryNotifyEvery                    int64   = 120 // ~1 hour between repeats of an unchanged sentry alert
+	defaultRPCFailureStreak                     int64   = 10  // consecutive checks with rpc errors before escalating
+	defaultSentryDivergenceThreshold            int64   = 10  // blocks between the fastest and slowest sentry
+	defaultJailConfirmChecks                    int64   = 1   // consecutive checks reporting jailed or tombstoned before alerting
+)
+
+const defaultConfigFileMode os.FileMode = 0600
+
+// default recent missed blocks color bands of the status message
+const (
+	defaultMissedBlocksGreenTo    int64 = 49
+	defaultMissedBlocksYellowFrom int64 = 50
+	defaultMissedBlocksYellowTo   int64 = 99
+	defaultMissedBlocksRedFrom    int64 = 100
 )
 
 type AlertLevel int8
@@ -31,6 +55,55 @@ const (
 	alertLevelCritical
 )
 
+var alertLevelNames = map[AlertLevel]string{
+	alertLevelNone:     "none",
+	alertLevelWarning:  "warning",
+	alertLevelHigh:     "high",
+	alertLevelCritical: "critical",
+}
+
+func (al AlertLevel) String() string {
+	if name, ok := alertLevelNames[al]; ok {
+		return name
+	}
+	return fmt.Sprintf("AlertLevel(%d)", al)
+}
+
+func (al *AlertLevel) UnmarshalYAML(unmarshal func(interface{}) error) error {
+	alertLevel := ""
+	err := unmarshal(&alertLevel)
+	if err != nil {
+		return err
+	}
+
+	for level, name := range alertLevelNames {
+		if name == alertLevel {
+			*al = level
+			return nil
+		}
+	}
+
+	return errors.New("Invalid AlertLevel")
+}
+
+func (al AlertLevel) MarshalYAML() (interface{}, error) {
+	return al.String(), nil
+}
+
+func (al AlertLevel) MarshalText() ([]byte, error) {
+	return []byte(al.String()), nil
+}
+
+func (al *AlertLevel) UnmarshalText(text []byte) error {
+	for level, name := range alertLevelNames {
+		if name == string(text) {
+			*al = level
+			return nil
+		}
+	}
+	return errors.New("Invalid AlertLevel")
+}
+
 type AlertType string
 
 const (
@@ -42,8 +115,36 @@ const (
 	alertTypeGenericRPC         AlertType = "alertTypeGenericRPC"
 	alertTypeHalt               AlertType = "alertTypeHalt"
 	alertTypeSlashingSLA        AlertType = "alertTypeSlashingSLA"
+	alertTypeVotingPower        AlertType = "alertTypeVotingPower"
+	alertTypeUpgrade            AlertType = "alertTypeUpgrade"
+	alertTypeDoubleSign         AlertType = "alertTypeDoubleSign"
+	alertTypeRPCUnreachable     AlertType = "alertTypeRPCUnreachable"
+	alertTypeSentryDivergence   AlertType = "alertTypeSentryDivergence"
+	alertTypeUnbonding          AlertType = "alertTypeUnbonding"
+	alertTypeChainIDMismatch    AlertType = "alertTypeChainIDMismatch"
+	alertTypeMissedBlockStreak  AlertType = "alertTypeMissedBlockStreak"
+	alertTypeNetworkHalt        AlertType = "alertTypeNetworkHalt"
+	alertTypeUptimeDecline      AlertType = "alertTypeUptimeDecline"
 )
 
+// sentry alert types are tracked per sentry, so are not included in alertTypes
+const (
+	alertTypeSentryGRPC      AlertType = "alertTypeSentryGRPC"
+	alertTypeSentryOutOfSync AlertType = "alertTypeSentryOutOfSync"
+	alertTypeSentryHalt      AlertType = "alertTypeSentryHalt"
+	alertTypeSentryLowPeers  AlertType = "alertTypeSentryLowPeers"
+	alertTypeSentryHost      AlertType = "alertTypeSentryHost"
+)
+
+// sentryRecoveries describes the recovery of each sentry alert type in its cleared message
+var sentryRecoveries = map[AlertType]string{
+	alertTypeSentryGRPC:      "gRPC reachable again",
+	alertTypeSentryOutOfSync: "back in sync",
+	alertTypeSentryHalt:      "producing blocks again",
+	alertTypeSentryLowPeers:  "peer count recovered",
+	alertTypeSentryHost:      "host resources recovered",
+}
+
 var alertTypes = []AlertType{
 	alertTypeJailed,
 	alertTypeTombstoned,
@@ -53,6 +154,16 @@ var alertTypes = []AlertType{
 	alertTypeGenericRPC,
 	alertTypeHalt,
 	alertTypeSlashingSLA,
+	alertTypeVotingPower,
+	alertTypeUpgrade,
+	alertTypeDoubleSign,
+	alertTypeRPCUnreachable,
+	alertTypeSentryDivergence,
+	alertTypeUnbonding,
+	alertTypeChainIDMismatch,
+	alertTypeMissedBlockStreak,
+	alertTypeNetworkHalt,
+	alertTypeUptimeDecline,
 }
 
 func (at *AlertType) UnmarshalYAML(unmarshal func(interface{}) error) error {
@@ -78,6 +189,74 @@ func (at *AlertType) UnmarshalYAML(unmarshal func(interface{}) error) error {
 	return nil
 }
 
+// CheckCount is a number of checks, configured either as a count or as a duration, e.g. 10m,
+// which is converted to a number of checks using the check interval
+type CheckCount int64
+
+func (cc *CheckCount) UnmarshalYAML(unmarshal func(interface{}) error) error {
+	var count int64
+	if err := unmarshal(&count); err == nil {
+		*cc = CheckCount(count)
+		return nil
+	}
+	value := ""
+	if err := unmarshal(&value); err != nil {
+		return err
+	}
+	duration, err := time.ParseDuration(value)
+	if err != nil || duration <= 0 {
+		return fmt.Errorf("invalid check count %s, use a number of checks or a duration such as 10m", value)
+	}
+	checks := int64((duration + checkInterval - 1) / checkInterval)
+	*cc = CheckCount(checks)
+	return nil
+}
+
+// BlockThreshold is a number of blocks, configured either as a count or as a percentage of the recent blocks to
+// check, e.g. 50%, so that the same threshold can be used across chains with different recent_blocks_to_check
+type BlockThreshold struct {
+	count   int64
+	percent float64 // used instead of the count when set
+}
+
+func (bt *BlockThreshold) UnmarshalYAML(unmarshal func(interface{}) error) error {
+	var count int64
+	if err := unmarshal(&count); err == nil {
+		*bt = BlockThreshold{count: count}
+		return nil
+	}
+	value := ""
+	if err := unmarshal(&value); err != nil {
+		return err
+	}
+	percent, err := strconv.ParseFloat(strings.TrimSuffix(strings.TrimSpace(value), "%"), 64)
+	if err != nil || !strings.HasSuffix(value, "%") || percent <= 0 || percent > 100 {
+		return fmt.Errorf("invalid block threshold %s, use a number of blocks or a percentage such as 50%%", value)
+	}
+	*bt = BlockThreshold{percent: percent}
+	return nil
+}
+
+// MarshalYAML keeps a percentage as configured when the config is saved
+func (bt BlockThreshold) MarshalYAML() (interface{}, error) {
+	if bt.percent > 0 {
+		return strconv.FormatFloat(bt.percent, 'f', -1, 64) + "%", nil
+	}
+	return bt.count, nil
+}
+
+func (bt BlockThreshold) isZero() bool {
+	return bt.count == 0 && bt.percent == 0
+}
+
+// blocks returns the threshold as a number of blocks, a percentage is rounded to the nearest block
+func (bt BlockThreshold) blocks(recentBlocksToCheck int64) int64 {
+	if bt.percent > 0 {
+		return int64(math.Round(bt.percent / 100 * float64(recentBlocksToCheck)))
+	}
+	return bt.count
+}
+
 type SentryAlertType int8
 
 const (
@@ -85,13 +264,22 @@ const (
 	sentryAlertTypeGRPCError
 	sentryAlertTypeOutOfSyncError
 	sentryAlertTypeHalt
+	sentryAlertTypeLowPeers
 )
 
 type SentryStats struct {
 	Name            string
 	Version         string
 	Height          int64
+	Peers           int // -1 when the peer count is unknown
 	SentryAlertType SentryAlertType
+
+	blockTime time.Time // of the latest block, for the sentry halt check
+}
+
+// grpcReachable is false when the sentry's gRPC endpoint could not be queried at the check
+func (sentryStats *SentryStats) grpcReachable() bool {
+	return sentryStats.SentryAlertType != sentryAlertTypeGRPCError
 }
 
 type ValidatorStats struct {
@@ -105,6 +293,25 @@ type ValidatorStats struct {
 	SentryStats                 []*SentryStats
 	AlertLevel                  AlertLevel
 	RPCError                    bool
+	BondStatus                  string
+	BondedTokens                string
+	VotingPower                 int64
+	VotingPowerRank             int
+	UpgradeName                 string
+	UpgradeHeight               int64
+	BlockTime                   time.Duration
+
+	RPCLastSuccess         time.Time // zero until a check has succeeded against the rpc
+	RPCConsecutiveFailures int64
+
+	BlocksUntilJailed int64 // missed blocks left in the signing window before jailing, -1 when unknown
+
+	RecentMissedBlockStreak int64 // longest run of consecutive missed blocks within the recent blocks
+
+	UptimeTrend      float64 // slope of the slashing period uptime over the trend window, in percentage points per hour
+	UptimeTrendKnown bool    // false until the uptime samples span half the trend window
+
+	span *traceSpan // span of the check, nil when tracing is not enabled
 }
 
 type ValidatorAlertState struct {
@@ -112,27 +319,148 @@ type ValidatorAlertState struct {
 	SentryGRPCErrorCounts        map[string]int64
 	SentryOutOfSyncErrorCounts   map[string]int64
 	SentryHaltErrorCounts        map[string]int64
+	SentryLowPeersErrorCounts    map[string]int64
+	SentryHostErrorCounts        map[string]int64
 	SentryLatestHeight           map[string]int64
 	RecentMissedBlocksCounter    int64
 	RecentMissedBlocksCounterMax int64
 	LatestBlockChecked           int64
 	LatestBlockSigned            int64
+	VotingPowerMax               int64
+	UpgradeHeight                int64
+	UpgradeMilestone             int64
+	ActiveAlertLevel             AlertLevel // highest alert level notified since all alerts were last cleared
+	LastHeight                   int64
+	LastTimestamp                time.Time
+	BlockTimeEstimate            time.Duration
+
+	SentryLastNotified map[AlertKey]SentryNotifyState
+
+	AlertLastSent map[AlertType]time.Time // when each alert type was last notified, for cooldowns
+
+	DoubleSignHeight int64 // infraction height of the last double sign evidence notified
+
+	ConsecutiveRPCFailures int64
+	RPCFailingSince        time.Time
+	RPCLastSuccess         time.Time
+
+	BondStatus string // bond status at the last check it was queried
+
+	SlashingSLAArmed      bool // uptime fell under the error threshold and has not yet recovered above the clear threshold
+	SlashingSLAJailBuffer bool // the slashing sla alert last notified was for the jail buffer, not uptime under the sla
+
+	PendingConfirmCounts map[AlertType]int64 // consecutive checks jailed or tombstoned was seen while not yet confirmed
+
+	AckedAlerts map[AlertKey]time.Time // active alerts acknowledged through the API, not re-notified until they clear
+
+	StartupGraceUntil time.Time         // out-of-sync and halt alerts are counted but not notified until then
+	StartupSuppressed map[AlertKey]bool // alerts seen during the startup grace period that were not notified
+
+	RecentMissedBlockStreakMax int64 // longest missed block streak while the streak alert is active
+}
+
+// SentryNotifyState is the state of a sentry alert when it was last notified
+type SentryNotifyState struct {
+	Count  int64
+	Height int64
+	Level  AlertLevel
+}
+
+// AlertKey identifies an alert by type, and by sentry for sentry alerts
+type AlertKey struct {
+	AlertType AlertType
+	Sentry    string
+}
+
+// dedupKey identifies the alert of the validator for correlation outside of half-life. It is the same for every
+// notification of the alert and for its clear: the hex of the first 16 bytes of the SHA-256 of the validator name,
+// chain-id, alert type, and sentry for sentry alerts, each followed by a newline.
+func (key AlertKey) dedupKey(vm *ValidatorMonitor) string {
+	var b strings.Builder
+	for _, part := range []string{vm.Name, vm.ChainID, string(key.AlertType)} {
+		b.WriteString(part + "\n")
+	}
+	if key.Sentry != "" {
+		b.WriteString(key.Sentry + "\n")
+	}
+	sum := sha256.Sum256([]byte(b.String()))
+	return hex.EncodeToString(sum[:16])
 }
 
 type ValidatorAlertNotification struct {
-	Alerts         []string
-	ClearedAlerts  []string
-	NotifyForClear bool
-	AlertLevel     AlertLevel
+	Alerts            []string
+	AlertKeys         []AlertKey   // parallel to Alerts
+	AlertLevels       []AlertLevel // parallel to Alerts
+	ClearedAlerts     []string
+	ClearedAlertKeys  []AlertKey // parallel to ClearedAlerts
+	NotifyForClear    bool
+	AlertLevel        AlertLevel
+	ClearedAlertLevel AlertLevel
+	Transitions       []AlertTransition
+}
+
+// AlertTransition records an alert firing for the first time or clearing
+type AlertTransition struct {
+	Cleared    bool
+	AlertType  AlertType
+	Sentry     string
+	AlertLevel AlertLevel
+	Message    string
+	DedupKey   string
 }
 
 type NotificationsConfig struct {
-	Service string                `yaml:"service"`
-	Discord *DiscordChannelConfig `yaml:"discord"`
+	Service        string                `yaml:"service"`
+	MinNotifyLevel *AlertLevel           `yaml:"min-notify-level"`
+	RetryWindow    *time.Duration        `yaml:"retry-window"`
+	DigestWindow   *time.Duration        `yaml:"digest-window"`
+	Discord        *DiscordChannelConfig `yaml:"discord"`
+	Matrix         *MatrixConfig         `yaml:"matrix"`
+	Teams          *TeamsConfig          `yaml:"teams"`
+	Opsgenie       *OpsgenieConfig       `yaml:"opsgenie"` // also send alerts to Opsgenie
+	Pushover       *PushoverConfig       `yaml:"pushover"` // also send alerts to Pushover
+
+	StatusSummary bool `yaml:"status-summary"` // post a single status summary grouped by validator group
+
+	QuietHours *QuietHoursConfig `yaml:"quiet-hours"`
+
+	FleetHealthStatus bool `yaml:"fleet-health-status"` // post a single message with the worst alert level of all validators
+	FleetHealthNotify bool `yaml:"fleet-health-notify"` // notify when the worst alert level of all validators changes
+
+	DisableStatusMessage bool `yaml:"disable-status-message"` // only send alerts, never create or edit status messages
+
+	LifecycleNotify bool `yaml:"lifecycle-notify"` // notify when the monitor starts and shuts down
+}
+
+// QuietHoursConfig is a recurring daily window during which notifications are suppressed, alerts are still tracked
+type QuietHoursConfig struct {
+	Start         string   `yaml:"start"`          // HH:MM, the window runs over midnight when end is before start
+	End           string   `yaml:"end"`            // HH:MM
+	Timezone      string   `yaml:"timezone"`       // IANA timezone, e.g. America/New_York, the local timezone when empty
+	Weekdays      []string `yaml:"weekdays"`       // weekdays the window starts on, e.g. [mon, tue], every day when empty
+	AllowCritical bool     `yaml:"allow-critical"` // critical alerts are still notified
 }
 
 type AlertConfig struct {
 	IgnoreAlerts []*AlertType `yaml:"ignore-alerts"`
+
+	// minimum time between notifications of each alert type for a validator, clears are always sent
+	Cooldowns map[AlertType]time.Duration `yaml:"cooldowns"`
+
+	// out-of-sync and halt alerts are not notified for this long after startup, while nodes catch up
+	StartupGracePeriod *time.Duration `yaml:"startup-grace-period"`
+
+	// alert types not notified while another alert type is active, the default rules when not set
+	InhibitRules *[]InhibitRule `yaml:"inhibit-rules"`
+}
+
+// startupGraceAlertTypes are the alert types that are not notified during the startup grace period
+var startupGraceAlertTypes = map[AlertType]bool{
+	alertTypeOutOfSync:       true,
+	alertTypeHalt:            true,
+	alertTypeNetworkHalt:     true,
+	alertTypeSentryOutOfSync: true,
+	alertTypeSentryHalt:      true,
 }
 
 func (at *AlertConfig) AlertActive(alert AlertType) bool {
@@ -144,46 +472,204 @@ func (at *AlertConfig) AlertActive(alert AlertType) bool {
 	return true
 }
 
+type HistoryConfig struct {
+	File       string         `yaml:"file"`
+	MaxHistory int            `yaml:"max-history"`
+	MaxAge     *time.Duration `yaml:"max-age"`
+
+	UptimeFile     string         `yaml:"uptime-file"`
+	UptimeInterval *time.Duration `yaml:"uptime-interval"`
+	UptimeMaxAge   *time.Duration `yaml:"uptime-max-age"`
+}
+
+// RPCTLSConfig verifies https RPC endpoints behind a private CA
+type RPCTLSConfig struct {
+	CACert             string `yaml:"ca-cert"`              // PEM CA bundle trusted in addition to the system roots
+	InsecureSkipVerify bool   `yaml:"insecure-skip-verify"` // for testing only
+}
+
+type HTTPConfig struct {
+	Listen string `yaml:"listen"`
+}
+
+type APIConfig struct {
+	Listen      string `yaml:"listen"`
+	BearerToken string `yaml:"bearer-token"` // required as "Authorization: Bearer <token>" when set
+}
+
 type HalfLifeConfig struct {
 	AlertConfig   AlertConfig          `yaml:"alerts"`
 	Notifications *NotificationsConfig `yaml:"notifications"`
+	History       *HistoryConfig       `yaml:"history"`
+	HTTP          *HTTPConfig          `yaml:"http"`
+	API           *APIConfig           `yaml:"api"`
 	Validators    []*ValidatorMonitor  `yaml:"validators"`
+
+	SaveFile string `yaml:"save-file"` // local path to save the config to instead of its source
+	FileMode string `yaml:"file-mode"` // octal permissions for the saved config, e.g. 0640
+	Proxy    string `yaml:"proxy"`
+
+	SecretsFile string `yaml:"secrets-file"` // local path to credentials merged over the config, never saved to it
+
+	SentryGRPC *SentryGRPCConfig `yaml:"sentry-grpc"`
+
+	StartJitter *time.Duration `yaml:"start-jitter"` // spread the first check of each validator over this duration
+
+	StateFile string `yaml:"state-file"` // local path to save status message IDs to, so that the config is never rewritten
+
+	DryRun bool `yaml:"dry-run"` // log notifications instead of sending them
+
+	// how often the slashing params, staking validators, and upgrade plan are queried, default every check
+	MetadataRefreshInterval *time.Duration `yaml:"metadata-refresh-interval"`
+
+	RPCTLS *RPCTLSConfig `yaml:"rpc-tls"` // custom CA or skipped verification for https RPC endpoints
+
+	Profiles map[string]yaml.MapSlice `yaml:"profiles,omitempty"` // shared validator settings by name
+
+	ReferenceRPCs map[string]string `yaml:"reference-rpcs"` // independent rpc by chain-id, to tell a network halt from a node halt
+
+	MaxConcurrentChecks *int `yaml:"max-concurrent-checks"` // validator checks run at a time, higher priority first, unlimited when not set
+
+	Tracing *TracingConfig `yaml:"tracing"` // OpenTelemetry traces of each monitoring cycle, not exported when not set
+
+	CheckDeadline *time.Duration `yaml:"check-deadline"` // longest a check of a validator may run before it is abandoned
+	CycleDeadline *time.Duration `yaml:"cycle-deadline"` // longest a cycle may run, checks not done by then are abandoned
+
+	source   []byte
+	readOnly bool
+	secrets  yaml.MapSlice
+}
+
+// isEnabled returns whether the validator should be monitored, validators are enabled unless set to false
+func (vm *ValidatorMonitor) isEnabled() bool {
+	return vm.Enabled == nil || *vm.Enabled
+}
+
+// enabledValidators returns the validators to monitor
+func (c *HalfLifeConfig) enabledValidators() []*ValidatorMonitor {
+	var validators []*ValidatorMonitor
+	for _, vm := range c.Validators {
+		if vm.isEnabled() {
+			validators = append(validators, vm)
+		} else {
+			fmt.Printf("Validator %s is disabled, skipping\n", vm.Name)
+		}
+	}
+	return validators
 }
 
+// getUnsetDefaults sets the thresholds that are not configured to their defaults, and warns when a validator
+// has no thresholds configured at all, e.g. when they are misindented and not applied
 func (c *HalfLifeConfig) getUnsetDefaults() {
-	fmt.Printf("%+v", *c.Notifications)
+	const thresholds = 9
 	for idx := range c.Validators {
+		var defaulted []string
 		if c.Validators[idx].SlashingPeriodUptimeWarningThreshold == 0 {
 			c.Validators[idx].SlashingPeriodUptimeWarningThreshold = defaultSlashingPeriodUptimeWarningThreshold
+			defaulted = append(defaulted, "slashing_warn_threshold")
 		}
 		if c.Validators[idx].SlashingPeriodUptimeErrorThreshold == 0 {
 			c.Validators[idx].SlashingPeriodUptimeErrorThreshold = defaultSlashingPeriodUptimeErrorThreshold
+			defaulted = append(defaulted, "slashing_error_threshold")
 		}
 		if c.Validators[idx].RecentBlocksToCheck == 0 {
 			c.Validators[idx].RecentBlocksToCheck = defaultRecentBlocksToCheck
+			defaulted = append(defaulted, "recent_blocks_to_check")
 		}
 		if c.Validators[idx].NotifyEvery == 0 {
-			c.Validators[idx].NotifyEvery = defaultNotifyEvery
+			c.Validators[idx].NotifyEvery = CheckCount(defaultNotifyEvery)
+			defaulted = append(defaulted, "notify_every")
 		}
-		if c.Validators[idx].RecentMissedBlocksNotifyThreshold == 0 {
-			c.Validators[idx].RecentMissedBlocksNotifyThreshold = defaultRecentMissedBlocksNotifyThreshold
+		if c.Validators[idx].RecentMissedBlocksNotifyThreshold.isZero() {
+			c.Validators[idx].RecentMissedBlocksNotifyThreshold = BlockThreshold{count: defaultRecentMissedBlocksNotifyThreshold}
+			defaulted = append(defaulted, "recent_missed_blocks_notify_threshold")
 		}
 		if c.Validators[idx].MissedBlocksGreenTo == nil {
-			defaultVal := int64(49)
+			defaultVal := defaultMissedBlocksGreenTo
 			c.Validators[idx].MissedBlocksGreenTo = &defaultVal
+			defaulted = append(defaulted, "missed-blocks-green-to")
 		}
 		if c.Validators[idx].MissedBlocksYellowFrom == nil {
-			defaultVal := int64(50)
+			defaultVal := defaultMissedBlocksYellowFrom
 			c.Validators[idx].MissedBlocksYellowFrom = &defaultVal
+			defaulted = append(defaulted, "missed-blocks-yellow-from")
 		}
 		if c.Validators[idx].MissedBlocksYellowTo == nil {
-			defaultVal := int64(99)
+			defaultVal := defaultMissedBlocksYellowTo
 			c.Validators[idx].MissedBlocksYellowTo = &defaultVal
+			defaulted = append(defaulted, "missed-blocks-yellow-to")
 		}
 		if c.Validators[idx].MissedBlocksRedFrom == nil {
-			defaultVal := int64(100)
+			defaultVal := defaultMissedBlocksRedFrom
 			c.Validators[idx].MissedBlocksRedFrom = &defaultVal
+			defaulted = append(defaulted, "missed-blocks-red-from")
 		}
+		if len(defaulted) == thresholds {
+			fmt.Printf("Validator %s has no thresholds configured, using the defaults for %s. If thresholds are set in config.yaml, check their names and indentation.\n",
+				c.Validators[idx].Name, strings.Join(defaulted, ", "))
+		}
+	}
+}
+
+// missedBlocksBands are the inclusive ranges of recent missed blocks shown as green, yellow, and red
+type missedBlocksBands struct {
+	greenTo    int64
+	yellowFrom int64
+	yellowTo   int64
+	redFrom    int64
+}
+
+func (vm *ValidatorMonitor) getMissedBlocksBands() missedBlocksBands {
+	bands := missedBlocksBands{
+		greenTo:    defaultMissedBlocksGreenTo,
+		yellowFrom: defaultMissedBlocksYellowFrom,
+		yellowTo:   defaultMissedBlocksYellowTo,
+		redFrom:    defaultMissedBlocksRedFrom,
+	}
+	if vm.MissedBlocksGreenTo != nil {
+		bands.greenTo = *vm.MissedBlocksGreenTo
+	}
+	if vm.MissedBlocksYellowFrom != nil {
+		bands.yellowFrom = *vm.MissedBlocksYellowFrom
+	}
+	if vm.MissedBlocksYellowTo != nil {
+		bands.yellowTo = *vm.MissedBlocksYellowTo
+	}
+	if vm.MissedBlocksRedFrom != nil {
+		bands.redFrom = *vm.MissedBlocksRedFrom
+	}
+	return bands
+}
+
+// validate checks that the bands are in order and that each band starts right after the previous one,
+// so that every number of missed blocks has exactly one color
+func (bands missedBlocksBands) validate() error {
+	switch {
+	case bands.greenTo < 0:
+		return fmt.Errorf("missed-blocks-green-to %d must not be negative", bands.greenTo)
+	case bands.yellowTo < bands.yellowFrom:
+		return fmt.Errorf("missed-blocks-yellow-to %d is below missed-blocks-yellow-from %d", bands.yellowTo, bands.yellowFrom)
+	case bands.yellowFrom != bands.greenTo+1:
+		return fmt.Errorf("missed-blocks-yellow-from %d must be missed-blocks-green-to + 1 (%d) so the green and yellow bands neither overlap nor leave a gap", bands.yellowFrom, bands.greenTo+1)
+	case bands.redFrom != bands.yellowTo+1:
+		return fmt.Errorf("missed-blocks-red-from %d must be missed-blocks-yellow-to + 1 (%d) so the yellow and red bands neither overlap nor leave a gap", bands.redFrom, bands.yellowTo+1)
+	}
+	return nil
+}
+
+// alertLevel returns the alert level of the band for the missed blocks. The missed blocks are capped to
+// the recent blocks checked, in case more were counted than checked.
+func (bands missedBlocksBands) alertLevel(recentMissedBlocks int64, recentBlocksToCheck int64) AlertLevel {
+	if recentMissedBlocks > recentBlocksToCheck {
+		recentMissedBlocks = recentBlocksToCheck
+	}
+	switch {
+	case recentMissedBlocks <= bands.greenTo:
+		return alertLevelNone
+	case recentMissedBlocks >= bands.redFrom:
+		return alertLevelHigh
+	default:
+		return alertLevelWarning
 	}
 }
 
@@ -193,52 +679,557 @@ type DiscordWebhookConfig struct {
 }
 
 type DiscordChannelConfig struct {
-	Webhook      DiscordWebhookConfig `yaml:"webhook"`
-	AlertUserIDs []string             `yaml:"alert-user-ids"`
-	Username     string               `yaml:"username"`
+	Webhook       DiscordWebhookConfig  `yaml:"webhook"`
+	StatusWebhook *DiscordWebhookConfig `yaml:"status-webhook"`
+	AlertWebhook  *DiscordWebhookConfig `yaml:"alert-webhook"`
+	AlertUserIDs  []string              `yaml:"alert-user-ids"`
+	Username      string                `yaml:"username"`
+	Colors        *DiscordColorsConfig  `yaml:"colors"`
+
+	StatusSummaryMessageID *string `yaml:"status-summary-message-id"`
+	FleetHealthMessageID   *string `yaml:"fleet-health-message-id"`
+
+	AlertMentions map[AlertLevel][]string `yaml:"alert-mentions"`
+
+	Verbosity Verbosity `yaml:"verbosity,omitempty"` // minimal, normal (default), or detailed
+}
+
+// getMentions returns the discord mentions for an alert level. An alert level mentions the
+// alert-mentions IDs for its level and every lower level, so that more severe alerts escalate
+// to more people. When none apply to the level, alert-user-ids are mentioned.
+func (c *DiscordChannelConfig) getMentions(alertLevel AlertLevel) string {
+	var ids []string
+	for level := alertLevelWarning; level <= alertLevel; level++ {
+		ids = append(ids, c.AlertMentions[level]...)
+	}
+	if len(ids) == 0 {
+		ids = c.AlertUserIDs
+	}
+	return formatDiscordMentions(ids)
+}
+
+// getValidatorMentions returns the discord mentions for a validator's alert. The validator's alert-user-ids
+// override the channel mentions when set. An empty list mentions no one.
+func (c *DiscordChannelConfig) getValidatorMentions(vm *ValidatorMonitor, alertLevel AlertLevel) string {
+	if vm.AlertUserIDs == nil {
+		return c.getMentions(alertLevel)
+	}
+	return formatDiscordMentions(vm.AlertUserIDs)
+}
+
+// formatDiscordMentions formats the IDs as mentions, without duplicates
+func formatDiscordMentions(ids []string) string {
+	var mentions []string
+	seen := make(map[string]bool)
+	for _, id := range ids {
+		mention := formatDiscordMention(id)
+		if !seen[mention] {
+			seen[mention] = true
+			mentions = append(mentions, mention)
+		}
+	}
+	return strings.Join(mentions, " ")
+}
+
+// formatDiscordMention formats a user ID, a role ID prefixed with "role:", or passes through
+// @everyone, @here and already formatted mentions
+func formatDiscordMention(id string) string {
+	switch {
+	case id == "@everyone" || id == "@here" || strings.HasPrefix(id, "<"):
+		return id
+	case strings.HasPrefix(id, "role:"):
+		return fmt.Sprintf("<@&%s>", strings.TrimPrefix(id, "role:"))
+	default:
+		return fmt.Sprintf("<@%s>", id)
+	}
+}
+
+type MatrixConfig struct {
+	HomeserverURL string   `yaml:"homeserver-url"`
+	AccessToken   string   `yaml:"access-token"`
+	RoomID        string   `yaml:"room-id"`
+	StatusRoomID  string   `yaml:"status-room-id"` // defaults to room-id
+	AlertUserIDs  []string `yaml:"alert-user-ids"` // matrix user IDs, e.g. @ops:example.org
+
+	Verbosity Verbosity `yaml:"verbosity,omitempty"` // minimal, normal (default), or detailed
+}
+
+// getStatusRoomID returns the room for status messages, which is the alert room unless configured
+func (c *MatrixConfig) getStatusRoomID() string {
+	if c.StatusRoomID != "" {
+		return c.StatusRoomID
+	}
+	return c.RoomID
+}
+
+type TeamsConfig struct {
+	WebhookURL     string         `yaml:"webhook-url"`
+	StatusInterval *time.Duration `yaml:"status-interval"` // repost status cards at this interval, disabled when not set
+
+	Verbosity Verbosity `yaml:"verbosity,omitempty"` // minimal, normal (default), or detailed
+}
+
+type OpsgenieConfig struct {
+	APIKey string `yaml:"api-key"`
+	Region string `yaml:"region"` // us (default) or eu
+
+	Verbosity Verbosity `yaml:"verbosity,omitempty"` // minimal, normal (default), or detailed
+}
+
+type PushoverConfig struct {
+	Token         string `yaml:"token"`
+	UserKey       string `yaml:"user-key"`
+	CriticalSound string `yaml:"critical-sound"` // sound for critical alerts, defaults to siren
+
+	Verbosity Verbosity `yaml:"verbosity,omitempty"` // minimal, normal (default), or detailed
+}
+
+// DiscordColorsConfig holds hex embed colors, e.g. "#00FF00", for each alert level
+type DiscordColorsConfig struct {
+	None     string `yaml:"none"`
+	Warning  string `yaml:"warning"`
+	High     string `yaml:"high"`
+	Critical string `yaml:"critical"`
+}
+
+func parseHexColor(hexColor string) (int, error) {
+	trimmed := strings.TrimPrefix(strings.TrimPrefix(hexColor, "#"), "0x")
+	if len(trimmed) != 6 {
+		return 0, fmt.Errorf("invalid hex color %q, expected format #RRGGBB", hexColor)
+	}
+	color, err := strconv.ParseUint(trimmed, 16, 32)
+	if err != nil {
+		return 0, fmt.Errorf("invalid hex color %q, expected format #RRGGBB", hexColor)
+	}
+	return int(color), nil
+}
+
+// getColors returns the embed color for each alert level, using defaults for any not configured
+func (c *DiscordColorsConfig) getColors() (map[AlertLevel]int, error) {
+	colors := make(map[AlertLevel]int)
+	for alertLevel, color := range defaultAlertLevelColors {
+		colors[alertLevel] = color
+	}
+	if c == nil {
+		return colors, nil
+	}
+	for alertLevel, hexColor := range map[AlertLevel]string{
+		alertLevelNone:     c.None,
+		alertLevelWarning:  c.Warning,
+		alertLevelHigh:     c.High,
+		alertLevelCritical: c.Critical,
+	} {
+		if hexColor == "" {
+			continue
+		}
+		color, err := parseHexColor(hexColor)
+		if err != nil {
+			return nil, fmt.Errorf("discord %s color: %w", alertLevel, err)
+		}
+		colors[alertLevel] = color
+	}
+	return colors, nil
+}
+
+// getWebhooks returns the webhooks for the status message and for alerts,
+// falling back to any single configured webhook for both
+func (c *DiscordChannelConfig) getWebhooks() (statusWebhook DiscordWebhookConfig, alertWebhook DiscordWebhookConfig) {
+	statusWebhook, alertWebhook = c.Webhook, c.Webhook
+	if c.StatusWebhook != nil {
+		statusWebhook = *c.StatusWebhook
+		if c.AlertWebhook == nil && c.Webhook.ID == "" {
+			alertWebhook = *c.StatusWebhook
+		}
+	}
+	if c.AlertWebhook != nil {
+		alertWebhook = *c.AlertWebhook
+		if c.StatusWebhook == nil && c.Webhook.ID == "" {
+			statusWebhook = *c.AlertWebhook
+		}
+	}
+	return
 }
 
 type Sentry struct {
 	Name string `yaml:"name"`
 	GRPC string `yaml:"grpc"`
+	RPC  string `yaml:"rpc"`
+
+	TLS                bool   `yaml:"tls"`
+	CACert             string `yaml:"ca-cert"`
+	ClientCert         string `yaml:"client-cert"`
+	ClientKey          string `yaml:"client-key"`
+	InsecureSkipVerify bool   `yaml:"insecure-skip-verify"`
+
+	Scheme    ConnectionScheme `yaml:"scheme,omitempty"`     // tls or plaintext for grpc, overriding the URL scheme and tls
+	RPCScheme ConnectionScheme `yaml:"rpc-scheme,omitempty"` // tls or plaintext for rpc, overriding the URL scheme
+
+	NodeExporterURL           string   `yaml:"node-exporter-url"` // prometheus metrics with the node-exporter disk and memory metrics
+	DiskMountpoint            string   `yaml:"disk-mountpoint"`
+	MinDiskFreePercent        *float64 `yaml:"min-disk-free-percent"`
+	MinMemoryAvailablePercent *float64 `yaml:"min-memory-available-percent"`
+
+	SentryGRPCConfig `yaml:",inline"`
+}
+
+// SentryGRPCConfig tunes sentry grpc connections, globally or for each sentry
+type SentryGRPCConfig struct {
+	KeepaliveTime    *time.Duration `yaml:"keepalive-time"`
+	KeepaliveTimeout *time.Duration `yaml:"keepalive-timeout"`
+	ReuseConnection  *bool          `yaml:"reuse-connection"`
 }
 
 type ValidatorMonitor struct {
 	Name                           string    `yaml:"name"`
 	RPC                            string    `yaml:"rpc"`
-	FullNode                       bool      `yaml:"fullnode"`
+	FullNode                       bool      `yaml:"fullnode"` // no signing checks: jailed, tombstoned, missed blocks, slashing SLA, double sign, voting power
 	Address                        string    `yaml:"address"`
 	ChainID                        string    `yaml:"chain-id"`
 	DiscordStatusMessageID         *string   `yaml:"discord-status-message-id"`
+	MatrixStatusEventID            *string   `yaml:"matrix-status-event-id"`
 	RPCRetries                     *int      `yaml:"rpc-retries"`
-	MissedBlocksThreshold          *int64    `yaml:"missed-blocks-threshold"`
 	SentryGRPCErrorThreshold       *int64    `yaml:"sentry-grpc-error-threshold"`
 	SentryOutOfSyncBlocksThreshold *int64    `yaml:"sentry-out-of-sync-blocks-threshold"`
+	SentryHaltThreshold            *int64    `yaml:"sentry-halt-threshold"`
+	SentryMinPeers                 *int      `yaml:"min-peers"`
 	Sentries                       *[]Sentry `yaml:"sentries"`
 
-	SlashingPeriodUptimeWarningThreshold float64 `yaml:"slashing_warn_threshold"`
-	SlashingPeriodUptimeErrorThreshold   float64 `yaml:"slashing_error_threshold"`
-	RecentBlocksToCheck                  int64   `yaml:"recent_blocks_to_check"`
-	NotifyEvery                          int64   `yaml:"notify_every"`
-	RecentMissedBlocksNotifyThreshold    int64   `yaml:"recent_missed_blocks_notify_threshold"`
+	SentryOutOfSyncDuration *time.Duration `yaml:"sentry-out-of-sync-duration"` // takes precedence over the block count when the block time is known
+
+	AlertUserIDs []string `yaml:"alert-user-ids"` // discord mentions for this validator's alerts, overriding the channel mentions
+
+	SlashingPeriodUptimeClearThreshold *float64 `yaml:"slashing_clear_threshold"` // uptime the slashing sla alert clears above, default slashing_error_threshold
+
+	JailConfirmChecks *int64 `yaml:"jail-confirm-checks"` // consecutive checks jailed or tombstoned must be seen before alerting
+	ConfirmRPC        string `yaml:"confirm-rpc"`         // second rpc that jailed or tombstoned is verified against
+
+	NotifySentryRecovery bool `yaml:"notify-sentry-recovery"` // mention for every recovery of a notified sentry alert
+
+	StartJitter *time.Duration `yaml:"start-jitter"` // overrides the global start-jitter
+
+	CheckDeadline *time.Duration `yaml:"check-deadline"` // overrides the global check-deadline
+
+	RecentBlocksConcurrency *int `yaml:"recent-blocks-concurrency"` // blocks fetched at a time in the recent blocks scan
+
+	JailBufferBlocks *int64 `yaml:"jail-buffer-blocks"` // alert when this few missed blocks are left before jailing
+
+	MissedBlockStreakThreshold  *BlockThreshold `yaml:"missed-block-streak-threshold"` // a number of blocks, or a percentage of recent_blocks_to_check
+	MissedBlockStreakAlertLevel *AlertLevel     `yaml:"missed-block-streak-alert-level"`
+
+	DisableStatusMessage *bool `yaml:"disable-status-message"` // overrides the global disable-status-message
+
+	RPCTLS *RPCTLSConfig `yaml:"rpc-tls"` // overrides the global rpc-tls
+
+	SlashingPeriodUptimeWarningThreshold float64    `yaml:"slashing_warn_threshold"`
+	SlashingPeriodUptimeErrorThreshold   float64    `yaml:"slashing_error_threshold"`
+	RecentBlocksToCheck                  int64      `yaml:"recent_blocks_to_check"`
+	NotifyEvery                          CheckCount `yaml:"notify_every"`
+
+	// a number of blocks, or a percentage of recent_blocks_to_check
+	MissedBlocksThreshold             *BlockThreshold `yaml:"missed-blocks-threshold"`
+	RecentMissedBlocksNotifyThreshold BlockThreshold  `yaml:"recent_missed_blocks_notify_threshold"`
 
 	MissedBlocksGreenTo    *int64 `yaml:"missed-blocks-green-to"`
 	MissedBlocksYellowFrom *int64 `yaml:"missed-blocks-yellow-from"`
 	MissedBlocksYellowTo   *int64 `yaml:"missed-blocks-yellow-to"`
 	MissedBlocksRedFrom    *int64 `yaml:"missed-blocks-red-from"`
+
+	MinVotingPower           *int64   `yaml:"min-voting-power"`
+	VotingPowerDropThreshold *float64 `yaml:"voting-power-drop-threshold"`
+	UpgradeAlertBlocks       *int64   `yaml:"upgrade-alert-blocks"`
+
+	RPCRetryBaseDelay *time.Duration `yaml:"rpc-retry-base-delay"`
+	RPCRetryMaxDelay  *time.Duration `yaml:"rpc-retry-max-delay"`
+	BlockTime         *time.Duration `yaml:"block-time"`
+
+	MinNotifyLevel *AlertLevel `yaml:"min-notify-level"`
+
+	SentryNotifyEvery *int64 `yaml:"sentry-notify-every"`
+	RPCFailureStreak  *int64 `yaml:"rpc-failure-streak"`
+
+	SentryDivergenceThreshold *int64 `yaml:"sentry-divergence-threshold"`
+
+	Proxy *string `yaml:"proxy"`
+
+	ChainType ChainType `yaml:"chain-type"`
+
+	SDKVersion SDKVersion `yaml:"sdk-version,omitempty"` // auto (default), legacy, or v0.50, how recent missed blocks are read
+
+	SubscribeBlocks bool `yaml:"subscribe-blocks"`
+
+	Enabled *bool `yaml:"enabled"` // disabled validators are kept in the config but not monitored
+
+	Group string   `yaml:"group"`
+	Tags  []string `yaml:"tags"`
+
+	Moniker        string `yaml:"moniker"`
+	MonikerChainID string `yaml:"moniker-chain-id,omitempty"` // chain-id the address was resolved from the moniker on
+
+	ConsensusAddress string `yaml:"consensus-address"` // hex consensus address or consensus pubkey, used in place of address
+
+	QuietHours *QuietHoursConfig `yaml:"quiet-hours"`
+
+	Profile string `yaml:"profile,omitempty"` // name of the profile that provides the settings not set on the validator
+
+	ReferenceRPC string `yaml:"reference-rpc"` // overrides the reference-rpcs entry for the chain-id
+
+	Priority int `yaml:"priority,omitempty"` // checked first when max-concurrent-checks is reached, higher first, default 0
+
+	RPCScheme ConnectionScheme `yaml:"rpc-scheme,omitempty"` // tls or plaintext, overriding the URL scheme of rpc
+
+	UptimeDeclineThreshold *float64       `yaml:"uptime-decline-threshold"` // alert when uptime falls faster than this many percentage points per hour
+	UptimeTrendWindow      *time.Duration `yaml:"uptime-trend-window"`      // window the uptime trend is determined over, default 1h
+
+	profileKeys []string // settings provided by the profile, not saved to the config while they match it
+}
+
+func loadConfig(configFile string) (*HalfLifeConfig, error) {
+	// a remote config source can carry a token in its URL
+	secrets.register(urlSecrets(configFile)...)
+	dat, err := readConfigSource(configFile)
+	if err != nil {
+		return nil, fmt.Errorf("error reading %s: %w", configFile, err)
+	}
+	config := HalfLifeConfig{}
+	err = yaml.Unmarshal(dat, &config)
+	if err != nil {
+		return nil, fmt.Errorf("error parsing %s: %w", configFile, err)
+	}
+	mergedDat := dat
+	if secretsFile := config.SecretsFile; secretsFile != "" {
+		var secrets yaml.MapSlice
+		mergedDat, secrets, err = loadSecrets(dat, secretsFile)
+		if err != nil {
+			return nil, err
+		}
+		config = HalfLifeConfig{}
+		if err := yaml.Unmarshal(mergedDat, &config); err != nil {
+			return nil, fmt.Errorf("error parsing %s with secrets from %s: %w", configFile, secretsFile, err)
+		}
+		config.secrets = secrets
+	}
+	if config.hasProfileReferences() {
+		if err := config.applyProfiles(mergedDat); err != nil {
+			return nil, fmt.Errorf("invalid config %s: %w", configFile, err)
+		}
+	}
+	config.source = dat
+	config.readOnly = isConfigReadOnly(configFile)
+	if config.StateFile != "" {
+		config.loadStateFile()
+	} else if config.SaveFile != "" {
+		config.loadSavedState()
+		if config.readOnly {
+			fmt.Printf("Config source %s is read-only, saving config to %s\n", redactSecrets(configFile), config.SaveFile)
+		}
+	} else if config.readOnly {
+		fmt.Printf("Config source %s is read-only, discord status message IDs will not be saved. Set state-file to save them to a local file.\n", redactSecrets(configFile))
+	}
+	config.getUnsetDefaults()
+	config.registerSecrets()
+	if err := config.validate(); err != nil {
+		return nil, fmt.Errorf("invalid config %s: %w", configFile, err)
+	}
+	return &config, nil
+}
+
+// validate checks config values that can't be verified while parsing
+func (c *HalfLifeConfig) validate() error {
+	if c.Notifications != nil && c.Notifications.Discord != nil {
+		if _, err := c.Notifications.Discord.Colors.getColors(); err != nil {
+			return err
+		}
+	}
+	if _, err := c.getFileMode(); err != nil {
+		return err
+	}
+	if err := validateProxy(c.Proxy); err != nil {
+		return err
+	}
+	if c.RPCTLS != nil {
+		if _, err := c.RPCTLS.tlsConfig(); err != nil {
+			return err
+		}
+	}
+	if c.Notifications != nil && c.Notifications.QuietHours != nil {
+		if err := c.Notifications.QuietHours.validate(); err != nil {
+			return err
+		}
+	}
+	if c.Notifications != nil {
+		if err := c.Notifications.validateVerbosities(); err != nil {
+			return err
+		}
+	}
+	if c.MaxConcurrentChecks != nil && *c.MaxConcurrentChecks < 1 {
+		return errors.New("max-concurrent-checks must be at least 1")
+	}
+	if c.Tracing != nil {
+		if err := c.Tracing.validate(); err != nil {
+			return err
+		}
+	}
+	if c.CheckDeadline != nil && *c.CheckDeadline <= 0 {
+		return errors.New("check-deadline must be positive")
+	}
+	if c.CycleDeadline != nil && *c.CycleDeadline <= 0 {
+		return errors.New("cycle-deadline must be positive")
+	}
+	for _, rule := range c.AlertConfig.getInhibitRules() {
+		if err := rule.validate(); err != nil {
+			return fmt.Errorf("inhibit-rules: %w", err)
+		}
+	}
+	for _, vm := range c.Validators {
+		if err := vm.getChainType().validate(); err != nil {
+			return fmt.Errorf("validator %s: %w", vm.Name, err)
+		}
+		if err := vm.getSDKVersion().validate(); err != nil {
+			return fmt.Errorf("validator %s: %w", vm.Name, err)
+		}
+		if err := vm.getMissedBlocksBands().validate(); err != nil {
+			return fmt.Errorf("validator %s: %w", vm.Name, err)
+		}
+		if !vm.FullNode && vm.Address == "" && vm.Moniker == "" && vm.ConsensusAddress == "" {
+			return fmt.Errorf("validator %s: one of address, consensus-address, or moniker is required", vm.Name)
+		}
+		if vm.ConsensusAddress != "" {
+			if _, err := parseConsensusAddress(vm.ConsensusAddress); err != nil {
+				return fmt.Errorf("validator %s: %w", vm.Name, err)
+			}
+		}
+		if vm.QuietHours != nil {
+			if err := vm.QuietHours.validate(); err != nil {
+				return fmt.Errorf("validator %s: %w", vm.Name, err)
+			}
+		}
+		if vm.RPCTLS != nil {
+			if _, err := vm.RPCTLS.tlsConfig(); err != nil {
+				return fmt.Errorf("validator %s: %w", vm.Name, err)
+			}
+		}
+		if vm.MissedBlockStreakAlertLevel != nil && *vm.MissedBlockStreakAlertLevel == alertLevelNone {
+			return fmt.Errorf("validator %s: missed-block-streak-alert-level must be warning, high, or critical", vm.Name)
+		}
+		if vm.UptimeDeclineThreshold != nil && *vm.UptimeDeclineThreshold <= 0 {
+			return fmt.Errorf("validator %s: uptime-decline-threshold must be greater than 0", vm.Name)
+		}
+		if vm.UptimeTrendWindow != nil && *vm.UptimeTrendWindow < uptimeTrendSamples*checkInterval {
+			return fmt.Errorf("validator %s: uptime-trend-window must be at least %s", vm.Name, uptimeTrendSamples*checkInterval)
+		}
+		if err := vm.RPCScheme.validate(); err != nil {
+			return fmt.Errorf("validator %s: rpc-scheme: %w", vm.Name, err)
+		}
+		if vm.CheckDeadline != nil && *vm.CheckDeadline <= 0 {
+			return fmt.Errorf("validator %s: check-deadline must be positive", vm.Name)
+		}
+		if vm.RecentBlocksConcurrency != nil && *vm.RecentBlocksConcurrency < 1 {
+			return fmt.Errorf("validator %s: recent-blocks-concurrency must be at least 1", vm.Name)
+		}
+		if clear := vm.getSlashingPeriodUptimeClearThreshold(); clear < vm.SlashingPeriodUptimeErrorThreshold || clear > 100 {
+			return fmt.Errorf("validator %s: slashing_clear_threshold (%.02f) must be between slashing_error_threshold (%.02f) and 100", vm.Name, clear, vm.SlashingPeriodUptimeErrorThreshold)
+		}
+		if vm.Proxy != nil {
+			if err := validateProxy(*vm.Proxy); err != nil {
+				return fmt.Errorf("validator %s: %w", vm.Name, err)
+			}
+		}
+		if vm.Sentries == nil {
+			continue
+		}
+		for _, sentry := range *vm.Sentries {
+			if err := sentry.Scheme.validate(); err != nil {
+				return fmt.Errorf("sentry %s of %s: %w", sentry.Name, vm.Name, err)
+			}
+			if err := sentry.RPCScheme.validate(); err != nil {
+				return fmt.Errorf("sentry %s of %s: rpc-scheme: %w", sentry.Name, vm.Name, err)
+			}
+			_, useTLS, err := sentry.grpcTarget()
+			if err != nil {
+				return fmt.Errorf("sentry %s of %s: %w", sentry.Name, vm.Name, err)
+			}
+			if _, err := sentry.grpcTransportCredentials(useTLS); err != nil {
+				return fmt.Errorf("sentry %s of %s: %w", sentry.Name, vm.Name, err)
+			}
+			if err := sentry.getSentryGRPCConfig(c.SentryGRPC).validate(); err != nil {
+				return fmt.Errorf("sentry %s of %s: %w", sentry.Name, vm.Name, err)
+			}
+		}
+	}
+	return nil
 }
 
 func saveConfig(configFile string, config *HalfLifeConfig, writeConfigMutex *sync.Mutex) {
 	writeConfigMutex.Lock()
 	defer writeConfigMutex.Unlock()
 
-	yamlBytes, err := yaml.Marshal(config)
+	if config.StateFile != "" {
+		if err := config.saveStateFile(); err != nil {
+			fmt.Printf("Error saving state file %v\n", err)
+		}
+		return
+	}
+	if config.SaveFile != "" {
+		configFile = config.SaveFile
+	} else if config.readOnly {
+		fmt.Printf("Not saving config, %s is read-only\n", configFile)
+		return
+	}
+
+	yamlBytes, err := config.marshalWithoutSecrets()
 	if err != nil {
 		fmt.Printf("Error during config yaml marshal %v\n", err)
+		return
 	}
 
-	err = os.WriteFile(configFile, yamlBytes, 0600)
+	fileMode, err := config.getFileMode()
 	if err != nil {
 		fmt.Printf("Error saving config yaml %v\n", err)
+		return
+	}
+	err = writeFileAtomic(configFile, yamlBytes, fileMode)
+	if err != nil {
+		fmt.Printf("Error saving config yaml %v\n", err)
+	}
+}
+
+// getFileMode returns the permissions to save the config with, defaulting to 0600
+func (c *HalfLifeConfig) getFileMode() (os.FileMode, error) {
+	if c.FileMode == "" {
+		return defaultConfigFileMode, nil
+	}
+	mode, err := strconv.ParseUint(c.FileMode, 8, 32)
+	if err != nil || mode > 0777 {
+		return 0, fmt.Errorf("invalid file-mode %s, use octal permissions such as 0640", c.FileMode)
+	}
+	return os.FileMode(mode), nil
+}
+
+// writeFileAtomic writes to a temp file in the same directory and renames it over the file,
+// so a crash mid-write leaves either the old or the new file intact
+func writeFileAtomic(file string, data []byte, mode os.FileMode) error {
+	tmp, err := os.CreateTemp(filepath.Dir(file), filepath.Base(file)+".tmp")
+	if err != nil {
+		return err
+	}
+	if err := tmp.Chmod(mode); err != nil {
+		tmp.Close()
+		os.Remove(tmp.Name())
+		return err
+	}
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		os.Remove(tmp.Name())
+		return err
+	}
+	if err := tmp.Sync(); err != nil {
+		tmp.Close()
+		os.Remove(tmp.Name())
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		os.Remove(tmp.Name())
+		return err
 	}
+	return os.Rename(tmp.Name(), file)
 }
@@ -0,0 +1,53 @@
+package cmd
+
+import (
+	"testing"
+)
+
+func TestMissedBlocksBandsValidate(t *testing.T) {
+	tests := []struct {
+		name    string
+		bands   missedBlocksBands
+		wantErr bool
+	}{
+		{name: "contiguous", bands: missedBlocksBands{greenTo: 2, yellowFrom: 3, yellowTo: 9, redFrom: 10}},
+		{name: "single block yellow", bands: missedBlocksBands{greenTo: 0, yellowFrom: 1, yellowTo: 1, redFrom: 2}},
+		{name: "negative green", bands: missedBlocksBands{greenTo: -1, yellowFrom: 0, yellowTo: 9, redFrom: 10}, wantErr: true},
+		{name: "inverted yellow", bands: missedBlocksBands{greenTo: 2, yellowFrom: 9, yellowTo: 3, redFrom: 4}, wantErr: true},
+		{name: "green overlaps yellow", bands: missedBlocksBands{greenTo: 4, yellowFrom: 3, yellowTo: 9, redFrom: 10}, wantErr: true},
+		{name: "yellow overlaps red", bands: missedBlocksBands{greenTo: 2, yellowFrom: 3, yellowTo: 9, redFrom: 8}, wantErr: true},
+		{name: "gap after green", bands: missedBlocksBands{greenTo: 2, yellowFrom: 5, yellowTo: 9, redFrom: 10}, wantErr: true},
+		{name: "gap after yellow", bands: missedBlocksBands{greenTo: 2, yellowFrom: 3, yellowTo: 9, redFrom: 12}, wantErr: true},
+	}
+	for _, test := range tests {
+		err := test.bands.validate()
+		if (err != nil) != test.wantErr {
+			t.Errorf("%s: validate() = %v, want error %t", test.name, err, test.wantErr)
+		}
+	}
+}
+
+func TestMissedBlocksBandsAlertLevel(t *testing.T) {
+	bands := missedBlocksBands{greenTo: 2, yellowFrom: 3, yellowTo: 9, redFrom: 10}
+	tests := []struct {
+		name                string
+		recentMissedBlocks  int64
+		recentBlocksToCheck int64
+		want                AlertLevel
+	}{
+		{name: "none missed", recentMissedBlocks: 0, recentBlocksToCheck: 20, want: alertLevelNone},
+		{name: "green to", recentMissedBlocks: 2, recentBlocksToCheck: 20, want: alertLevelNone},
+		{name: "yellow from", recentMissedBlocks: 3, recentBlocksToCheck: 20, want: alertLevelWarning},
+		{name: "yellow to", recentMissedBlocks: 9, recentBlocksToCheck: 20, want: alertLevelWarning},
+		{name: "red from", recentMissedBlocks: 10, recentBlocksToCheck: 20, want: alertLevelHigh},
+		{name: "all missed", recentMissedBlocks: 20, recentBlocksToCheck: 20, want: alertLevelHigh},
+		{name: "capped into yellow", recentMissedBlocks: 50, recentBlocksToCheck: 5, want: alertLevelWarning},
+		{name: "capped into green", recentMissedBlocks: 50, recentBlocksToCheck: 2, want: alertLevelNone},
+		{name: "capped at red from", recentMissedBlocks: 11, recentBlocksToCheck: 10, want: alertLevelHigh},
+	}
+	for _, test := range tests {
+		if got := bands.alertLevel(test.recentMissedBlocks, test.recentBlocksToCheck); got != test.want {
+			t.Errorf("%s: alertLevel(%d, %d) = %s, want %s", test.name, test.recentMissedBlocks, test.recentBlocksToCheck, got, test.want)
+		}
+	}
+}
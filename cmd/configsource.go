@@ -0,0 +1,115 @@
+package cmd
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+
+	"gopkg.in/yaml.v2"
+)
+
+const remoteConfigTimeout = 10 * time.Second
+
+func isRemoteConfig(configFile string) bool {
+	return strings.HasPrefix(configFile, "http://") || strings.HasPrefix(configFile, "https://")
+}
+
+// readConfigSource reads the config from a local file, or fetches it when the source is an http(s) URL
+func readConfigSource(configFile string) ([]byte, error) {
+	if !isRemoteConfig(configFile) {
+		return os.ReadFile(configFile)
+	}
+	client := http.Client{Timeout: remoteConfigTimeout}
+	resp, err := client.Get(configFile)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("unexpected response status: %s", resp.Status)
+	}
+	return io.ReadAll(resp.Body)
+}
+
+// isConfigReadOnly returns true when the config can't be written back to its source,
+// e.g. a remote URL or a read-only secret mount
+func isConfigReadOnly(configFile string) bool {
+	if isRemoteConfig(configFile) {
+		return true
+	}
+	f, err := os.OpenFile(configFile, os.O_WRONLY, 0)
+	if err != nil {
+		return true
+	}
+	f.Close()
+	return false
+}
+
+// loadSavedState restores the discord status message IDs from the save file, if it exists
+func (c *HalfLifeConfig) loadSavedState() {
+	dat, err := os.ReadFile(c.SaveFile)
+	if err != nil {
+		if !os.IsNotExist(err) {
+			fmt.Printf("Error reading save file %s: %v\n", c.SaveFile, err)
+		}
+		return
+	}
+	saved := HalfLifeConfig{}
+	if err := yaml.Unmarshal(dat, &saved); err != nil {
+		fmt.Printf("Error parsing save file %s: %v\n", c.SaveFile, err)
+		return
+	}
+	if c.Notifications != nil && c.Notifications.Discord != nil && saved.Notifications != nil && saved.Notifications.Discord != nil {
+		if c.Notifications.Discord.StatusSummaryMessageID == nil {
+			c.Notifications.Discord.StatusSummaryMessageID = saved.Notifications.Discord.StatusSummaryMessageID
+		}
+		if c.Notifications.Discord.FleetHealthMessageID == nil {
+			c.Notifications.Discord.FleetHealthMessageID = saved.Notifications.Discord.FleetHealthMessageID
+		}
+	}
+	for _, vm := range c.Validators {
+		for _, savedVM := range saved.Validators {
+			if savedVM.Name == vm.Name {
+				if vm.DiscordStatusMessageID == nil {
+					vm.DiscordStatusMessageID = savedVM.DiscordStatusMessageID
+				}
+				if vm.MatrixStatusEventID == nil {
+					vm.MatrixStatusEventID = savedVM.MatrixStatusEventID
+				}
+				break
+			}
+		}
+	}
+}
+
+// watchConfigSource polls the config source and shuts down when it changes,
+// so that the new config is loaded when halflife is restarted by its supervisor
+func watchConfigSource(
+	notificationService NotificationService,
+	configFile string,
+	config *HalfLifeConfig,
+	validators []*ValidatorMonitor,
+	interval time.Duration,
+) {
+	if !config.readOnly && config.SaveFile == "" && config.StateFile == "" {
+		fmt.Println("Config is saved back to its source, ignoring config poll interval")
+		return
+	}
+	for {
+		time.Sleep(interval)
+		dat, err := readConfigSource(configFile)
+		if err != nil {
+			fmt.Printf("Error polling config source %s: %s\n", redactSecrets(configFile), redactError(err))
+			continue
+		}
+		if !bytes.Equal(dat, config.source) {
+			fmt.Printf("Config source %s changed, exiting to load the new config on restart\n", redactSecrets(configFile))
+			// exits with an error so that supervisors that only restart on failure load the new config too
+			shutdown(notificationService, config, validators, "config changed", 1)
+		}
+	}
+}
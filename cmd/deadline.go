@@ -0,0 +1,56 @@
+package cmd
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+)
+
+// defaultCheckDeadline leaves room for the rpc retries and the recent blocks scan of a slow but responsive rpc,
+// while still bounding a check of an rpc that hangs just under its timeouts
+const defaultCheckDeadline = 3 * checkInterval
+
+var errCheckAbandoned = errors.New("check abandoned")
+
+// getCheckDeadline returns the longest a check of the validator may run, preferring the validator setting over the
+// global setting
+func getCheckDeadline(config *HalfLifeConfig, vm *ValidatorMonitor) time.Duration {
+	if vm.CheckDeadline != nil {
+		return *vm.CheckDeadline
+	}
+	if config.CheckDeadline != nil {
+		return *config.CheckDeadline
+	}
+	return defaultCheckDeadline
+}
+
+// newCycleContext returns the context of a cycle, which is done once the cycle deadline passes when it is set
+func newCycleContext(config *HalfLifeConfig) (context.Context, context.CancelFunc) {
+	if config.CycleDeadline == nil {
+		return context.WithCancel(context.Background())
+	}
+	return context.WithTimeout(context.Background(), *config.CycleDeadline)
+}
+
+// logAbandonedCheck logs a check that did not complete within its deadline or the cycle deadline. The validator is
+// treated as not checked this cycle, its alert state and status are left as they were.
+func logAbandonedCheck(config *HalfLifeConfig, vm *ValidatorMonitor, cycleCtx context.Context, started time.Time) {
+	reason := fmt.Sprintf("check-deadline %s", getCheckDeadline(config, vm))
+	if cycleCtx.Err() != nil {
+		reason = fmt.Sprintf("cycle-deadline %s", *config.CycleDeadline)
+	}
+	fmt.Printf("Abandoning check of %s after %s, %s passed, not checked this cycle\n", vm.Name, time.Since(started).Round(time.Millisecond), reason)
+}
+
+// sleepContext sleeps for the duration, returning false when the context is done first
+func sleepContext(ctx context.Context, d time.Duration) bool {
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-timer.C:
+		return true
+	case <-ctx.Done():
+		return false
+	}
+}
@@ -0,0 +1,229 @@
+package cmd
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+)
+
+// AlertDigestSender is implemented by notification services that can send
+// the alerts of many validators as a single notification
+type AlertDigestSender interface {
+	SendAlertDigest(config *HalfLifeConfig, digest *AlertDigest) error
+}
+
+// AlertDigestEntry is a single alert or cleared alert within a digest
+type AlertDigestEntry struct {
+	Validator  string
+	ChainID    string
+	AlertKey   AlertKey
+	AlertLevel AlertLevel
+	Message    string
+}
+
+// AlertDigestGroup is the digest entries sharing an alert type and alert level
+type AlertDigestGroup struct {
+	AlertType  AlertType
+	AlertLevel AlertLevel
+	Entries    []AlertDigestEntry
+}
+
+// AlertDigest aggregates alert notifications across validators over a window
+type AlertDigest struct {
+	Start          time.Time
+	End            time.Time
+	Alerts         []AlertDigestEntry
+	ClearedAlerts  []AlertDigestEntry
+	AlertLevel     AlertLevel
+	NotifyForClear bool
+}
+
+// groupDigestEntries groups entries by alert type and alert level, most severe first
+func groupDigestEntries(entries []AlertDigestEntry) []AlertDigestGroup {
+	var groups []AlertDigestGroup
+	for _, entry := range entries {
+		found := false
+		for i, group := range groups {
+			if group.AlertType == entry.AlertKey.AlertType && group.AlertLevel == entry.AlertLevel {
+				groups[i].Entries = append(groups[i].Entries, entry)
+				found = true
+				break
+			}
+		}
+		if !found {
+			groups = append(groups, AlertDigestGroup{
+				AlertType:  entry.AlertKey.AlertType,
+				AlertLevel: entry.AlertLevel,
+				Entries:    []AlertDigestEntry{entry},
+			})
+		}
+	}
+	sort.SliceStable(groups, func(i, j int) bool {
+		if groups[i].AlertLevel != groups[j].AlertLevel {
+			return groups[i].AlertLevel > groups[j].AlertLevel
+		}
+		return groups[i].AlertType < groups[j].AlertType
+	})
+	return groups
+}
+
+func (digest *AlertDigest) AlertGroups() []AlertDigestGroup {
+	return groupDigestEntries(digest.Alerts)
+}
+
+func (digest *AlertDigest) ClearedAlertGroups() []AlertDigestGroup {
+	return groupDigestEntries(digest.ClearedAlerts)
+}
+
+// Validators returns the number of distinct validators in the digest
+func (digest *AlertDigest) Validators() int {
+	validators := make(map[string]bool)
+	for _, entry := range digest.Alerts {
+		validators[entry.Validator] = true
+	}
+	for _, entry := range digest.ClearedAlerts {
+		validators[entry.Validator] = true
+	}
+	return len(validators)
+}
+
+// removeDigestEntry removes the entry for the validator and alert key, returns the remaining entries
+func removeDigestEntry(entries []AlertDigestEntry, validator string, key AlertKey) []AlertDigestEntry {
+	if key.AlertType == "" {
+		return entries
+	}
+	remaining := entries[:0]
+	for _, entry := range entries {
+		if entry.Validator == validator && entry.AlertKey == key {
+			continue
+		}
+		remaining = append(remaining, entry)
+	}
+	return remaining
+}
+
+// DigestNotificationService wraps a NotificationService, batching alert notifications
+// across all validators for a window and sending them as one digest. Status updates
+// are passed through per validator.
+type DigestNotificationService struct {
+	NotificationService
+	sender AlertDigestSender
+	window time.Duration
+	lock   sync.Mutex
+	config *HalfLifeConfig
+	digest *AlertDigest
+}
+
+func NewDigestNotificationService(service NotificationService, sender AlertDigestSender, window time.Duration) *DigestNotificationService {
+	return &DigestNotificationService{
+		NotificationService: service,
+		sender:              sender,
+		window:              window,
+	}
+}
+
+// implements NotificationService interface
+func (service *DigestNotificationService) SendValidatorAlertNotification(
+	config *HalfLifeConfig,
+	vm *ValidatorMonitor,
+	stats ValidatorStats,
+	alertNotification *ValidatorAlertNotification,
+) error {
+	service.lock.Lock()
+	defer service.lock.Unlock()
+	service.config = config
+	if service.digest == nil {
+		service.digest = &AlertDigest{Start: time.Now()}
+		time.AfterFunc(service.window, service.Flush)
+	}
+	digest := service.digest
+
+	// the latest alert for a validator and alert key replaces any earlier one in the window
+	for i, alert := range alertNotification.Alerts {
+		var key AlertKey
+		if i < len(alertNotification.AlertKeys) {
+			key = alertNotification.AlertKeys[i]
+		}
+		alertLevel := alertNotification.AlertLevel
+		if i < len(alertNotification.AlertLevels) {
+			alertLevel = alertNotification.AlertLevels[i]
+		}
+		digest.Alerts = removeDigestEntry(digest.Alerts, vm.Name, key)
+		digest.ClearedAlerts = removeDigestEntry(digest.ClearedAlerts, vm.Name, key)
+		digest.Alerts = append(digest.Alerts, AlertDigestEntry{
+			Validator:  vm.Name,
+			ChainID:    vm.ChainID,
+			AlertKey:   key,
+			AlertLevel: alertLevel,
+			Message:    alert,
+		})
+	}
+	for i, clearedAlert := range alertNotification.ClearedAlerts {
+		var key AlertKey
+		if i < len(alertNotification.ClearedAlertKeys) {
+			key = alertNotification.ClearedAlertKeys[i]
+		}
+		digest.Alerts = removeDigestEntry(digest.Alerts, vm.Name, key)
+		digest.ClearedAlerts = removeDigestEntry(digest.ClearedAlerts, vm.Name, key)
+		digest.ClearedAlerts = append(digest.ClearedAlerts, AlertDigestEntry{
+			Validator:  vm.Name,
+			ChainID:    vm.ChainID,
+			AlertKey:   key,
+			AlertLevel: alertNotification.ClearedAlertLevel,
+			Message:    clearedAlert,
+		})
+	}
+	if alertNotification.NotifyForClear {
+		digest.NotifyForClear = true
+	}
+	return nil
+}
+
+// Flush sends the pending digest, if any
+func (service *DigestNotificationService) Flush() {
+	service.lock.Lock()
+	digest := service.digest
+	config := service.config
+	service.digest = nil
+	service.lock.Unlock()
+	if digest == nil || (len(digest.Alerts) == 0 && len(digest.ClearedAlerts) == 0) {
+		return
+	}
+
+	digest.End = time.Now()
+	digest.AlertLevel = alertLevelNone
+	for _, entry := range digest.Alerts {
+		if entry.AlertLevel > digest.AlertLevel {
+			digest.AlertLevel = entry.AlertLevel
+		}
+	}
+	fmt.Printf("Sending alert digest with %d alerts and %d cleared alerts\n", len(digest.Alerts), len(digest.ClearedAlerts))
+	if err := service.sender.SendAlertDigest(config, digest); err != nil {
+		fmt.Printf("Error sending alert digest: %s\n", redactError(err))
+	}
+}
+
+// flushNotifications sends any batched notifications, used before exiting
+func flushNotifications(service NotificationService) {
+	for service != nil {
+		if digestService, ok := service.(*DigestNotificationService); ok {
+			digestService.Flush()
+			return
+		}
+		service = unwrapNotificationService(service)
+	}
+}
+
+// withDigest wraps the notification service with digest batching when a digest window is configured
+func withDigest(config *HalfLifeConfig, service NotificationService) (NotificationService, error) {
+	if config.Notifications.DigestWindow == nil || *config.Notifications.DigestWindow <= 0 {
+		return service, nil
+	}
+	sender, ok := service.(AlertDigestSender)
+	if !ok {
+		return nil, errors.New("alert digests are not supported by the configured notification service")
+	}
+	return NewDigestNotificationService(service, sender, *config.Notifications.DigestWindow), nil
+}
@@ -2,7 +2,9 @@ package cmd
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"net/http"
 	"strings"
 	"sync"
 	"time"
@@ -22,40 +24,59 @@ const (
 	iconGood    = "🟢" // green circle
 	iconWarning = "🟡" // yellow circle
 	iconError   = "🔴" // red circle
+
+	discordEmbedDescriptionLimit = 4000
+	discordMaxEmbeds             = 10
 )
 
 type DiscordNotificationService struct {
-	webhookID    string
-	webhookToken string
-	postMutex    *sync.Mutex
+	statusWebhook DiscordWebhookConfig
+	alertWebhook  DiscordWebhookConfig
+	colors        map[AlertLevel]int
+	verbosity     Verbosity
+	httpClient    *http.Client
+	rateLimiter   *discordRateLimiter
 }
 
 func formattedTime(t time.Time) string {
 	return fmt.Sprintf("<t:%d:R>", t.Unix())
 }
 
-func NewDiscordNotificationService(webhookID, webhookToken string) *DiscordNotificationService {
+func NewDiscordNotificationService(statusWebhook, alertWebhook DiscordWebhookConfig, colors map[AlertLevel]int, verbosity Verbosity, httpClient *http.Client) *DiscordNotificationService {
+	// all webhook calls share one rate limiter, so the client is copied to pace its transport
+	if httpClient == nil {
+		httpClient = &http.Client{Timeout: discordHTTPTimeout}
+	}
+	rateLimitedClient := *httpClient
+	rateLimiter := newDiscordRateLimiter(httpClient.Transport)
+	rateLimitedClient.Transport = rateLimiter
 	return &DiscordNotificationService{
-		webhookID:    webhookID,
-		webhookToken: webhookToken,
-		postMutex:    &sync.Mutex{},
+		statusWebhook: statusWebhook,
+		alertWebhook:  alertWebhook,
+		colors:        colors,
+		verbosity:     getVerbosity(verbosity),
+		httpClient:    &rateLimitedClient,
+		rateLimiter:   rateLimiter,
 	}
 }
 
-func getColorForAlertLevel(alertLevel AlertLevel) int {
-	switch alertLevel {
-	case alertLevelNone:
-		return colorGood
-	case alertLevelWarning:
-		return colorWarning
-	case alertLevelCritical:
-		return colorCritical
-	default:
-		return colorError
+var defaultAlertLevelColors = map[AlertLevel]int{
+	alertLevelNone:     colorGood,
+	alertLevelWarning:  colorWarning,
+	alertLevelHigh:     colorError,
+	alertLevelCritical: colorCritical,
+}
+
+func getColorForAlertLevel(colors map[AlertLevel]int, alertLevel AlertLevel) int {
+	if color, ok := colors[alertLevel]; ok {
+		return color
 	}
+	return colors[alertLevelHigh]
 }
 
-func getCurrentStatsEmbed(stats ValidatorStats, vm *ValidatorMonitor) discord.Embed {
+// getCurrentStatsEmbed renders the status of the validator. Minimal verbosity leaves out the sentries,
+// and detailed verbosity adds the bond status and voting power.
+func getCurrentStatsEmbed(stats ValidatorStats, vm *ValidatorMonitor, colors map[AlertLevel]int, verbosity Verbosity) discord.Embed {
 	var uptime string
 	var title string
 	if vm.FullNode {
@@ -73,7 +94,7 @@ func getCurrentStatsEmbed(stats ValidatorStats, vm *ValidatorMonitor) discord.Em
 	var description string
 	sentryString := ""
 
-	if vm.Sentries != nil {
+	if vm.Sentries != nil && verbosity != verbosityMinimal {
 		for _, vmSentry := range *vm.Sentries {
 			sentryFound := false
 			for _, sentryStats := range stats.SentryStats {
@@ -99,6 +120,12 @@ func getCurrentStatsEmbed(stats ValidatorStats, vm *ValidatorMonitor) discord.Em
 					}
 
 					sentryString += fmt.Sprintf("\n%s **%s** - Height **%s** - Version **%s**", statusIcon, sentryStats.Name, height, version)
+					if sentryStats.Peers >= 0 {
+						sentryString += fmt.Sprintf(" - Peers **%d**", sentryStats.Peers)
+					}
+					if !sentryStats.grpcReachable() {
+						sentryString += " - gRPC **unreachable**"
+					}
 					sentryFound = true
 					break
 				}
@@ -131,9 +158,41 @@ func getCurrentStatsEmbed(stats ValidatorStats, vm *ValidatorMonitor) discord.Em
 					recentSignedBlocksIcon = iconGood
 				}
 				recentSignedBlocks = fmt.Sprintf("%s Latest Blocks Signed: **%d/%d**", recentSignedBlocksIcon, vm.RecentBlocksToCheck-stats.RecentMissedBlocks, vm.RecentBlocksToCheck)
+				if stats.RecentMissedBlockStreak > 1 {
+					recentSignedBlocks += fmt.Sprintf(" (longest miss streak **%d**)", stats.RecentMissedBlockStreak)
+				}
+				if stats.BlocksUntilJailed >= 0 {
+					jailIcon := iconGood
+					if vm.JailBufferBlocks != nil && stats.BlocksUntilJailed < *vm.JailBufferBlocks {
+						jailIcon = iconError
+					} else if stats.SlashingPeriodUptime < vm.SlashingPeriodUptimeWarningThreshold {
+						jailIcon = iconWarning
+					}
+					recentSignedBlocks += fmt.Sprintf("\n%s Missed Blocks Until Jailed: **%d**", jailIcon, stats.BlocksUntilJailed)
+				}
+				if stats.UptimeTrendKnown {
+					trendIcon := iconGood
+					if getUptimeTrendDirection(stats.UptimeTrend) == uptimeTrendFalling {
+						trendIcon = iconWarning
+					}
+					recentSignedBlocks += fmt.Sprintf("\n%s Uptime Trend: **%s**", trendIcon, formatUptimeTrend(stats.UptimeTrend))
+				}
 			}
 		}
 		latestBlock = fmt.Sprintf("%s Height **%s** - **%s**", rpcStatusIcon, fmt.Sprint(stats.Height), formattedTime(stats.Timestamp))
+		if stats.BlockTime > 0 {
+			latestBlock += fmt.Sprintf(" - Block Time **%s**", stats.BlockTime.Round(10*time.Millisecond))
+		}
+	}
+	if stats.RPCError {
+		lastSuccess := "never"
+		if !stats.RPCLastSuccess.IsZero() {
+			lastSuccess = formattedTime(stats.RPCLastSuccess)
+		}
+		latestBlock += fmt.Sprintf("\n%s RPC failing for **%d** checks - Last success **%s**", iconError, stats.RPCConsecutiveFailures, lastSuccess)
+	}
+	if verbosity == verbosityDetailed && stats.BondStatus != "" {
+		latestBlock += fmt.Sprintf("\n%s Bond Status **%s** - Voting Power **%d** (rank **%d**)", iconGood, stats.BondStatus, stats.VotingPower, stats.VotingPowerRank)
 	}
 
 	if vm.FullNode {
@@ -154,7 +213,7 @@ func getCurrentStatsEmbed(stats ValidatorStats, vm *ValidatorMonitor) discord.Em
 		}
 	}
 
-	color := getColorForAlertLevel(stats.AlertLevel)
+	color := getColorForAlertLevel(colors, stats.AlertLevel)
 
 	return discord.Embed{
 		Title:       title,
@@ -163,8 +222,29 @@ func getCurrentStatsEmbed(stats ValidatorStats, vm *ValidatorMonitor) discord.Em
 	}
 }
 
-func (service *DiscordNotificationService) client() *webhook.Client {
-	return webhook.NewClient(snowflake.Snowflake(service.webhookID), service.webhookToken)
+func (service *DiscordNotificationService) webhookClient(webhookConfig DiscordWebhookConfig) *webhook.Client {
+	restConfig := rest.DefaultConfig
+	restConfig.HTTPClient = service.httpClient
+	return webhook.NewClient(snowflake.Snowflake(webhookConfig.ID), webhookConfig.Token, webhook.WithRestClientConfig(restConfig))
+}
+
+// implements NotificationService interface
+func (service *DiscordNotificationService) CheckReachability() error {
+	webhooks := []DiscordWebhookConfig{service.statusWebhook}
+	if service.alertWebhook != service.statusWebhook {
+		webhooks = append(webhooks, service.alertWebhook)
+	}
+	for _, webhookConfig := range webhooks {
+		ctx, cancel := context.WithTimeout(context.Background(), time.Duration(time.Second*4))
+		client := service.webhookClient(webhookConfig)
+		_, err := client.GetWebhook(rest.WithCtx(ctx))
+		client.Close(ctx)
+		cancel()
+		if err != nil {
+			return fmt.Errorf("discord webhook %s could not be reached or token was rejected: %w", webhookConfig.ID, err)
+		}
+	}
+	return nil
 }
 
 // implements NotificationService interface
@@ -174,41 +254,38 @@ func (service *DiscordNotificationService) UpdateValidatorRealtimeStatus(
 	vm *ValidatorMonitor,
 	stats ValidatorStats,
 	writeConfigMutex *sync.Mutex,
-) {
-	ctx, cancel := context.WithTimeout(context.Background(), time.Duration(time.Second*4))
-	defer cancel()
-	client := service.client()
-	defer client.Close(ctx)
+) error {
+	client := service.webhookClient(service.statusWebhook)
+	defer client.Close(context.Background())
 	if vm.DiscordStatusMessageID != nil {
-		service.postMutex.Lock()
+		ctx, release := service.rateLimiter.acquire(discordPriorityStatus)
 		_, err := client.UpdateMessage(snowflake.Snowflake(*vm.DiscordStatusMessageID), discord.WebhookMessageUpdate{
 			Embeds: &[]discord.Embed{
-				getCurrentStatsEmbed(stats, vm),
+				getCurrentStatsEmbed(stats, vm, service.colors, service.verbosity),
 			},
 		}, rest.WithCtx(ctx))
-		service.postMutex.Unlock()
+		release()
 		if err != nil {
-			fmt.Printf("Error updating discord message: %v\n", err)
-			return
+			return fmt.Errorf("error updating discord message: %w", err)
 		}
 	} else {
-		service.postMutex.Lock()
+		ctx, release := service.rateLimiter.acquire(discordPriorityStatus)
 		message, err := client.CreateMessage(discord.WebhookMessageCreate{
 			Username: config.Notifications.Discord.Username,
 			Embeds: []discord.Embed{
-				getCurrentStatsEmbed(stats, vm),
+				getCurrentStatsEmbed(stats, vm, service.colors, service.verbosity),
 			},
 		}, rest.WithCtx(ctx))
-		service.postMutex.Unlock()
+		release()
 		if err != nil {
-			fmt.Printf("Error sending discord message: %v\n", err)
-			return
+			return fmt.Errorf("error sending discord message: %w", err)
 		}
 		messageID := string(message.ID)
 		vm.DiscordStatusMessageID = &messageID
 		fmt.Printf("Saved message ID: %s\n", messageID)
 		saveConfig(configFile, config, writeConfigMutex)
 	}
+	return nil
 }
 
 // implements NotificationService interface
@@ -217,83 +294,327 @@ func (service *DiscordNotificationService) SendValidatorAlertNotification(
 	vm *ValidatorMonitor,
 	stats ValidatorStats,
 	alertNotification *ValidatorAlertNotification,
-) {
-	tagUser := ""
-	for _, userID := range config.Notifications.Discord.AlertUserIDs {
-		tagUser += fmt.Sprintf("<@%s> ", userID)
-	}
+) error {
+	var errs []string
 
-	var embedTitle string
-	if vm.FullNode {
-		embedTitle = vm.Name
-	} else {
-		if stats.SlashingPeriodUptime > 0 {
-			embedTitle = fmt.Sprintf("%s (%.02f%% up)", vm.Name, stats.SlashingPeriodUptime)
-		} else {
-			embedTitle = fmt.Sprintf("%s (N/A%% up)", vm.Name)
-		}
-	}
+	embedTitle := getValidatorTitle(vm, stats)
 
 	if len(alertNotification.Alerts) > 0 {
-		alertString := ""
-		for _, alert := range alertNotification.Alerts {
-			alertString += fmt.Sprintf("\n• %s", alert)
-		}
-		alertColor := getColorForAlertLevel(alertNotification.AlertLevel)
-		toNotify := ""
-		if alertNotification.AlertLevel > alertLevelWarning {
-			toNotify = strings.Trim(tagUser, " ")
+		description := fmt.Sprintf("**Errors:**\n%s", getDiscordAlertsList(alertNotification.Alerts))
+		switch service.verbosity {
+		case verbosityMinimal:
+			description = getAlertsSummary(alertNotification.Alerts)
+		case verbosityDetailed:
+			details := getCurrentStatsEmbed(stats, vm, service.colors, verbosityDetailed).Description
+			if len(description)+len(details) < discordEmbedDescriptionLimit {
+				description += "\n\n**Details:**\n" + details
+			}
 		}
-		ctx, cancel := context.WithTimeout(context.Background(), time.Duration(time.Second*4))
-		defer cancel()
-		client := service.client()
-		defer client.Close(ctx)
-		service.postMutex.Lock()
+		alertColor := getColorForAlertLevel(service.colors, alertNotification.AlertLevel)
+		toNotify := config.Notifications.Discord.getValidatorMentions(vm, alertNotification.AlertLevel)
+		client := service.webhookClient(service.alertWebhook)
+		defer client.Close(context.Background())
+		ctx, release := service.rateLimiter.acquire(discordPriorityAlert)
 		_, err := client.CreateMessage(discord.WebhookMessageCreate{
 			Username: config.Notifications.Discord.Username,
 			Content:  toNotify,
 			Embeds: []discord.Embed{
 				discord.Embed{
 					Title:       embedTitle,
-					Description: fmt.Sprintf("**Errors:**\n%s", strings.Trim(alertString, "\n")),
+					Description: description,
 					Color:       alertColor,
 				},
 			},
 		}, rest.WithCtx(ctx))
-		service.postMutex.Unlock()
+		release()
 		if err != nil {
-			fmt.Printf("Error sending discord message: %v\n", err)
+			errs = append(errs, fmt.Sprintf("error sending discord alert message: %v", err))
 		}
 	}
 
 	if len(alertNotification.ClearedAlerts) > 0 {
-		clearedAlertsString := ""
-		for _, alert := range alertNotification.ClearedAlerts {
-			clearedAlertsString += fmt.Sprintf("\n• %s", alert)
+		description := fmt.Sprintf("**Errors cleared:**\n%s", getDiscordAlertsList(alertNotification.ClearedAlerts))
+		if service.verbosity == verbosityMinimal {
+			description = "Cleared: " + getAlertsSummary(alertNotification.ClearedAlerts)
 		}
 		toNotify := ""
 		if alertNotification.NotifyForClear {
-			toNotify = strings.Trim(tagUser, " ")
+			toNotify = config.Notifications.Discord.getValidatorMentions(vm, alertNotification.ClearedAlertLevel)
 		}
-		ctx, cancel := context.WithTimeout(context.Background(), time.Duration(time.Second*4))
-		defer cancel()
-		client := service.client()
-		defer client.Close(ctx)
-		service.postMutex.Lock()
+		client := service.webhookClient(service.alertWebhook)
+		defer client.Close(context.Background())
+		ctx, release := service.rateLimiter.acquire(discordPriorityAlert)
 		_, err := client.CreateMessage(discord.WebhookMessageCreate{
 			Username: config.Notifications.Discord.Username,
 			Content:  toNotify,
 			Embeds: []discord.Embed{
 				discord.Embed{
 					Title:       embedTitle,
-					Description: fmt.Sprintf("**Errors cleared:**\n%s", strings.Trim(clearedAlertsString, "\n")),
-					Color:       colorGood,
+					Description: description,
+					Color:       getColorForAlertLevel(service.colors, alertLevelNone),
 				},
 			},
 		}, rest.WithCtx(ctx))
-		service.postMutex.Unlock()
+		release()
+		if err != nil {
+			errs = append(errs, fmt.Sprintf("error sending discord cleared alerts message: %v", err))
+		}
+	}
+
+	if len(errs) > 0 {
+		return errors.New(strings.Join(errs, ", "))
+	}
+	return nil
+}
+
+// getDiscordAlertsList returns the alerts as a bulleted list
+func getDiscordAlertsList(alerts []string) string {
+	return "• " + strings.Join(alerts, "\n• ")
+}
+
+func getDigestGroupsDescription(heading string, groups []AlertDigestGroup) string {
+	description := heading
+	for _, group := range groups {
+		alertType := string(group.AlertType)
+		if alertType == "" {
+			alertType = "other"
+		}
+		groupString := fmt.Sprintf("\n\n**%s - %s** (%d)", group.AlertLevel, alertType, len(group.Entries))
+		for _, entry := range group.Entries {
+			groupString += fmt.Sprintf("\n• **%s**: %s", entry.Validator, entry.Message)
+		}
+		if len(description)+len(groupString) > discordEmbedDescriptionLimit {
+			description += "\n\n…"
+			break
+		}
+		description += groupString
+	}
+	return description
+}
+
+// implements AlertDigestSender interface
+func (service *DiscordNotificationService) SendAlertDigest(config *HalfLifeConfig, digest *AlertDigest) error {
+	var embeds []discord.Embed
+	if len(digest.Alerts) > 0 {
+		embeds = append(embeds, discord.Embed{
+			Title:       fmt.Sprintf("Alert digest (%d validators)", digest.Validators()),
+			Description: getDigestGroupsDescription("**Errors:**", digest.AlertGroups()),
+			Color:       getColorForAlertLevel(service.colors, digest.AlertLevel),
+		})
+	}
+	if len(digest.ClearedAlerts) > 0 {
+		embeds = append(embeds, discord.Embed{
+			Title:       fmt.Sprintf("Alert digest (%d validators)", digest.Validators()),
+			Description: getDigestGroupsDescription("**Errors cleared:**", digest.ClearedAlertGroups()),
+			Color:       getColorForAlertLevel(service.colors, alertLevelNone),
+		})
+	}
+
+	mentionLevel := digest.AlertLevel
+	if digest.NotifyForClear {
+		for _, entry := range digest.ClearedAlerts {
+			if entry.AlertLevel > mentionLevel {
+				mentionLevel = entry.AlertLevel
+			}
+		}
+	}
+	toNotify := config.Notifications.Discord.getMentions(mentionLevel)
+
+	client := service.webhookClient(service.alertWebhook)
+	defer client.Close(context.Background())
+	ctx, release := service.rateLimiter.acquire(discordPriorityAlert)
+	_, err := client.CreateMessage(discord.WebhookMessageCreate{
+		Username: config.Notifications.Discord.Username,
+		Content:  toNotify,
+		Embeds:   embeds,
+	}, rest.WithCtx(ctx))
+	release()
+	if err != nil {
+		return fmt.Errorf("error sending discord alert digest message: %w", err)
+	}
+	return nil
+}
+
+// implements FleetHealthSender interface
+func (service *DiscordNotificationService) UpdateFleetHealth(
+	configFile string,
+	config *HalfLifeConfig,
+	fleet *FleetHealth,
+	writeConfigMutex *sync.Mutex,
+) error {
+	description := fleet.countsString()
+	for _, status := range fleet.Unhealthy {
+		line := fmt.Sprintf("\n• **%s** (%s) - %s", status.Name, status.ChainID, status.AlertLevel)
+		if len(description)+len(line) > discordEmbedDescriptionLimit {
+			description += "\n…"
+			break
+		}
+		description += line
+	}
+	embeds := []discord.Embed{{
+		Title:       fmt.Sprintf("Fleet health: %s (%d validators)", strings.ToUpper(fleet.AlertLevel.String()), fleet.Validators),
+		Description: description,
+		Color:       getColorForAlertLevel(service.colors, fleet.AlertLevel),
+	}}
+
+	client := service.webhookClient(service.statusWebhook)
+	defer client.Close(context.Background())
+	discordConfig := config.Notifications.Discord
+	if discordConfig.FleetHealthMessageID != nil {
+		ctx, release := service.rateLimiter.acquire(discordPriorityStatus)
+		_, err := client.UpdateMessage(snowflake.Snowflake(*discordConfig.FleetHealthMessageID), discord.WebhookMessageUpdate{
+			Embeds: &embeds,
+		}, rest.WithCtx(ctx))
+		release()
+		if err != nil {
+			return fmt.Errorf("error updating discord fleet health message: %w", err)
+		}
+		return nil
+	}
+	ctx, release := service.rateLimiter.acquire(discordPriorityStatus)
+	message, err := client.CreateMessage(discord.WebhookMessageCreate{
+		Username: discordConfig.Username,
+		Embeds:   embeds,
+	}, rest.WithCtx(ctx))
+	release()
+	if err != nil {
+		return fmt.Errorf("error sending discord fleet health message: %w", err)
+	}
+	messageID := string(message.ID)
+	discordConfig.FleetHealthMessageID = &messageID
+	fmt.Printf("Saved fleet health message ID: %s\n", messageID)
+	saveConfig(configFile, config, writeConfigMutex)
+	return nil
+}
+
+// implements FleetHealthSender interface
+func (service *DiscordNotificationService) SendFleetHealthTransition(config *HalfLifeConfig, previous AlertLevel, fleet *FleetHealth) error {
+	content := fmt.Sprintf("Fleet health changed from **%s** to **%s**: %s", previous, fleet.AlertLevel, fleet.countsString())
+	if fleet.AlertLevel > previous {
+		if mentions := config.Notifications.Discord.getMentions(fleet.AlertLevel); mentions != "" {
+			content += " " + mentions
+		}
+	}
+
+	client := service.webhookClient(service.alertWebhook)
+	defer client.Close(context.Background())
+	ctx, release := service.rateLimiter.acquire(discordPriorityAlert)
+	_, err := client.CreateMessage(discord.WebhookMessageCreate{
+		Username: config.Notifications.Discord.Username,
+		Content:  content,
+	}, rest.WithCtx(ctx))
+	release()
+	if err != nil {
+		return fmt.Errorf("error sending discord fleet health message: %w", err)
+	}
+	return nil
+}
+
+// implements LifecycleSender interface
+func (service *DiscordNotificationService) SendLifecycleNotification(config *HalfLifeConfig, lifecycle *LifecycleNotification) error {
+	content := lifecycle.Message
+	for _, line := range lifecycle.validatorLines() {
+		content += "\n- " + line
+	}
+
+	client := service.webhookClient(service.alertWebhook)
+	defer client.Close(context.Background())
+	ctx, release := service.rateLimiter.acquire(discordPriorityAlert)
+	_, err := client.CreateMessage(discord.WebhookMessageCreate{
+		Username: config.Notifications.Discord.Username,
+		Content:  content,
+	}, rest.WithCtx(ctx))
+	release()
+	if err != nil {
+		return fmt.Errorf("error sending discord %s message: %w", lifecycle.Event, err)
+	}
+	return nil
+}
+
+func getStatusGroupDescription(group StatusGroup) string {
+	description := ""
+	for _, status := range group.Validators {
+		var icon string
+		switch {
+		case status.Updated.IsZero():
+			icon = iconWarning
+		case status.AlertLevel >= alertLevelHigh:
+			icon = iconError
+		case status.AlertLevel == alertLevelWarning:
+			icon = iconWarning
+		default:
+			icon = iconGood
+		}
+		line := fmt.Sprintf("\n%s **%s** (%s)", icon, status.Name, status.ChainID)
+		switch {
+		case status.Updated.IsZero():
+			line += " - **N/A**"
+		case status.RPCError:
+			line += " - Height **N/A**"
+		default:
+			line += fmt.Sprintf(" - Height **%d**", status.Height)
+			if !status.FullNode {
+				if status.Uptime > 0 {
+					line += fmt.Sprintf(" - **%.02f%%** up", status.Uptime)
+				}
+				line += fmt.Sprintf(" - Signed **%d/%d**", status.RecentBlocksChecked-status.RecentMissedBlocks, status.RecentBlocksChecked)
+			}
+		}
+		if len(description)+len(line) > discordEmbedDescriptionLimit {
+			description += "\n…"
+			break
+		}
+		description += line
+	}
+	return strings.TrimPrefix(description, "\n")
+}
+
+// implements StatusSummarySender interface
+func (service *DiscordNotificationService) UpdateStatusSummary(
+	configFile string,
+	config *HalfLifeConfig,
+	summary *StatusSummary,
+	writeConfigMutex *sync.Mutex,
+) error {
+	var embeds []discord.Embed
+	for _, group := range summary.Groups {
+		if len(embeds) == discordMaxEmbeds {
+			fmt.Printf("Status summary has more than %d groups, not all groups are shown\n", discordMaxEmbeds)
+			break
+		}
+		embeds = append(embeds, discord.Embed{
+			Title:       fmt.Sprintf("%s (%d validators)", group.Name, len(group.Validators)),
+			Description: getStatusGroupDescription(group),
+			Color:       getColorForAlertLevel(service.colors, group.AlertLevel),
+		})
+	}
+
+	client := service.webhookClient(service.statusWebhook)
+	defer client.Close(context.Background())
+	discordConfig := config.Notifications.Discord
+	if discordConfig.StatusSummaryMessageID != nil {
+		ctx, release := service.rateLimiter.acquire(discordPriorityStatus)
+		_, err := client.UpdateMessage(snowflake.Snowflake(*discordConfig.StatusSummaryMessageID), discord.WebhookMessageUpdate{
+			Embeds: &embeds,
+		}, rest.WithCtx(ctx))
+		release()
 		if err != nil {
-			fmt.Printf("Error sending discord message: %v\n", err)
+			return fmt.Errorf("error updating discord status summary message: %w", err)
 		}
+		return nil
+	}
+	ctx, release := service.rateLimiter.acquire(discordPriorityStatus)
+	message, err := client.CreateMessage(discord.WebhookMessageCreate{
+		Username: discordConfig.Username,
+		Embeds:   embeds,
+	}, rest.WithCtx(ctx))
+	release()
+	if err != nil {
+		return fmt.Errorf("error sending discord status summary message: %w", err)
 	}
+	messageID := string(message.ID)
+	discordConfig.StatusSummaryMessageID = &messageID
+	fmt.Printf("Saved status summary message ID: %s\n", messageID)
+	saveConfig(configFile, config, writeConfigMutex)
+	return nil
 }
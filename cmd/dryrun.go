@@ -0,0 +1,140 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"sync"
+)
+
+// DryRunNotificationService logs the notifications that would have been sent instead of sending them,
+// so that threshold changes can be tried against live data without notifying anyone
+type DryRunNotificationService struct{}
+
+// dryRunEntry is a single logged notification
+type dryRunEntry struct {
+	Notification string             `json:"notification"` // alert, status, status-summary, fleet-health, fleet-health-change, started, or stopping
+	Validator    string             `json:"validator,omitempty"`
+	ChainID      string             `json:"chain-id,omitempty"`
+	AlertLevel   AlertLevel         `json:"alert-level"`
+	Alerts       []dryRunAlert      `json:"alerts,omitempty"`
+	Cleared      []dryRunAlert      `json:"cleared,omitempty"`
+	Height       int64              `json:"height,omitempty"`
+	Uptime       float64            `json:"uptime,omitempty"`
+	Previous     *AlertLevel        `json:"previous-alert-level,omitempty"`
+	Counts       map[AlertLevel]int `json:"counts,omitempty"`
+
+	Message    string   `json:"message,omitempty"`
+	Validators []string `json:"validators,omitempty"`
+}
+
+type dryRunAlert struct {
+	AlertType  AlertType  `json:"alert-type,omitempty"`
+	Sentry     string     `json:"sentry,omitempty"`
+	AlertLevel AlertLevel `json:"alert-level"`
+	Message    string     `json:"message"`
+	DedupKey   string     `json:"dedup-key,omitempty"`
+}
+
+func (entry dryRunEntry) log() {
+	dat, err := json.Marshal(entry)
+	if err != nil {
+		fmt.Printf("Error logging dry run %s notification: %v\n", entry.Notification, err)
+		return
+	}
+	fmt.Printf("DRY RUN %s\n", dat)
+}
+
+// implements NotificationService interface
+func (DryRunNotificationService) SendValidatorAlertNotification(
+	config *HalfLifeConfig,
+	vm *ValidatorMonitor,
+	stats ValidatorStats,
+	alertNotification *ValidatorAlertNotification,
+) error {
+	entry := dryRunEntry{
+		Notification: "alert",
+		Validator:    vm.Name,
+		ChainID:      vm.ChainID,
+		AlertLevel:   alertNotification.AlertLevel,
+	}
+	for i, alert := range alertNotification.Alerts {
+		key := alertNotification.AlertKeys[i]
+		entry.Alerts = append(entry.Alerts, dryRunAlert{
+			AlertType:  key.AlertType,
+			Sentry:     key.Sentry,
+			AlertLevel: alertNotification.AlertLevels[i],
+			Message:    alert,
+			DedupKey:   key.dedupKey(vm),
+		})
+	}
+	for i, cleared := range alertNotification.ClearedAlerts {
+		key := alertNotification.ClearedAlertKeys[i]
+		entry.Cleared = append(entry.Cleared, dryRunAlert{
+			AlertType:  key.AlertType,
+			Sentry:     key.Sentry,
+			AlertLevel: alertNotification.ClearedAlertLevel,
+			Message:    cleared,
+			DedupKey:   key.dedupKey(vm),
+		})
+	}
+	entry.log()
+	return nil
+}
+
+// implements NotificationService interface
+func (DryRunNotificationService) UpdateValidatorRealtimeStatus(
+	configFile string,
+	config *HalfLifeConfig,
+	vm *ValidatorMonitor,
+	stats ValidatorStats,
+	writeConfigMutex *sync.Mutex,
+) error {
+	dryRunEntry{
+		Notification: "status",
+		Validator:    vm.Name,
+		ChainID:      vm.ChainID,
+		AlertLevel:   stats.AlertLevel,
+		Height:       stats.Height,
+		Uptime:       stats.SlashingPeriodUptime,
+	}.log()
+	return nil
+}
+
+// implements NotificationService interface
+func (DryRunNotificationService) CheckReachability() error {
+	return nil
+}
+
+// implements StatusSummarySender interface
+func (DryRunNotificationService) UpdateStatusSummary(
+	configFile string,
+	config *HalfLifeConfig,
+	summary *StatusSummary,
+	writeConfigMutex *sync.Mutex,
+) error {
+	dryRunEntry{Notification: "status-summary", AlertLevel: summary.AlertLevel}.log()
+	return nil
+}
+
+// implements FleetHealthSender interface
+func (DryRunNotificationService) UpdateFleetHealth(
+	configFile string,
+	config *HalfLifeConfig,
+	fleet *FleetHealth,
+	writeConfigMutex *sync.Mutex,
+) error {
+	dryRunEntry{Notification: "fleet-health", AlertLevel: fleet.AlertLevel, Counts: fleet.Counts}.log()
+	return nil
+}
+
+// implements FleetHealthSender interface
+func (DryRunNotificationService) SendFleetHealthTransition(config *HalfLifeConfig, previous AlertLevel, fleet *FleetHealth) error {
+	dryRunEntry{Notification: "fleet-health-change", AlertLevel: fleet.AlertLevel, Previous: &previous, Counts: fleet.Counts}.log()
+	return nil
+}
+
+// implements LifecycleSender interface
+func (DryRunNotificationService) SendLifecycleNotification(config *HalfLifeConfig, lifecycle *LifecycleNotification) error {
+	dryRunEntry{Notification: lifecycle.Event, Message: lifecycle.Message, Validators: lifecycle.validatorLines()}.log()
+	return nil
+}
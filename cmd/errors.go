@@ -1,8 +1,10 @@
 package cmd
 
 import (
+	"errors"
 	"fmt"
 	"math"
+	"strings"
 	"time"
 )
 
@@ -24,10 +26,13 @@ func (e *ignoreableError) Active(config AlertConfig) bool {
 	return true
 }
 func newIgnorableError(err error) *ignoreableError {
-	return &ignoreableError{err}
+	return &ignoreableError{errors.New(redactError(err))}
 }
 
-type JailedError struct{ until time.Time }
+type JailedError struct {
+	until    time.Time
+	verified bool // also reported by the confirm rpc
+}
 
 func (e *JailedError) Error() string {
 	return fmt.Sprintf("validator is jailed until %s", e.until.String())
@@ -35,18 +40,70 @@ func (e *JailedError) Error() string {
 func (e *JailedError) Active(config AlertConfig) bool {
 	return config.AlertActive(alertTypeJailed)
 }
-func newJailedError(until time.Time) *JailedError {
-	return &JailedError{until}
+func newJailedError(until time.Time, verified bool) *JailedError {
+	return &JailedError{until: until, verified: verified}
 }
 
-type TombstonedError struct{}
+type TombstonedError struct {
+	verified bool // also reported by the confirm rpc
+}
 
 func (e *TombstonedError) Error() string { return "validator is tombstoned" }
 func (e *TombstonedError) Active(config AlertConfig) bool {
 	return config.AlertActive(alertTypeTombstoned)
 }
-func newTombstonedError() *TombstonedError {
-	return &TombstonedError{}
+func newTombstonedError(verified bool) *TombstonedError {
+	return &TombstonedError{verified: verified}
+}
+
+type RPCUnreachableError struct {
+	failures int64
+	since    time.Time
+}
+
+func (e *RPCUnreachableError) Error() string {
+	return fmt.Sprintf("rpc server has failed %d consecutive checks since %s, validator is not being monitored", e.failures, e.since.Format(time.RFC3339))
+}
+func (e *RPCUnreachableError) Active(config AlertConfig) bool {
+	return config.AlertActive(alertTypeRPCUnreachable)
+}
+func newRPCUnreachableError(failures int64, since time.Time) *RPCUnreachableError {
+	return &RPCUnreachableError{failures, since}
+}
+
+type SentryDivergenceError struct {
+	fastest       string
+	fastestHeight int64
+	slowest       string
+	slowestHeight int64
+	threshold     int64
+}
+
+func (e *SentryDivergenceError) Error() string {
+	return fmt.Sprintf("sentry heights diverged by %d blocks (threshold %d) - fastest %s at height %d, slowest %s at height %d",
+		e.fastestHeight-e.slowestHeight, e.threshold, e.fastest, e.fastestHeight, e.slowest, e.slowestHeight)
+}
+func (e *SentryDivergenceError) Active(config AlertConfig) bool {
+	return config.AlertActive(alertTypeSentryDivergence)
+}
+func newSentryDivergenceError(fastest string, fastestHeight int64, slowest string, slowestHeight int64, threshold int64) *SentryDivergenceError {
+	return &SentryDivergenceError{fastest, fastestHeight, slowest, slowestHeight, threshold}
+}
+
+type DoubleSignError struct {
+	evidenceType string
+	height       int64 // infraction height
+	blockHeight  int64 // height of the block including the evidence
+}
+
+func (e *DoubleSignError) Error() string {
+	return fmt.Sprintf("double sign evidence (%s) for height %d included in block %d", e.evidenceType, e.height, e.blockHeight)
+}
+func (e *DoubleSignError) Active(config AlertConfig) bool {
+	return config.AlertActive(alertTypeDoubleSign)
+}
+func newDoubleSignError(evidenceType string, height int64, blockHeight int64) *DoubleSignError {
+	return &DoubleSignError{evidenceType, height, blockHeight}
 }
 
 type OutOfSyncError struct{ msg string }
@@ -56,15 +113,19 @@ func (e *OutOfSyncError) Active(config AlertConfig) bool {
 	return config.AlertActive(alertTypeOutOfSync)
 }
 func newOutOfSyncError(address string) *OutOfSyncError {
-	return &OutOfSyncError{fmt.Sprintf("rpc server %s out of sync, cannot get up to date information", address)}
+	return &OutOfSyncError{fmt.Sprintf("rpc server %s out of sync, cannot get up to date information", redactSecrets(address))}
 }
 
 type ChainHaltError struct {
-	durationNano int64
+	durationNano    int64
+	referenceHeight int64 // latest height of the reference rpc when it is still producing blocks, 0 when unknown
 }
 
 func (e *ChainHaltError) Error() string {
 	minutesHalted := int64(math.Round(float64(e.durationNano) / 6e10))
+	if e.referenceHeight > 0 {
+		return fmt.Sprintf("rpc node has been halted for %dmin while the reference rpc is producing blocks at height %d, the halt is local to the node", minutesHalted, e.referenceHeight)
+	}
 	return fmt.Sprintf("rpc node has been halted for %dmin", minutesHalted)
 }
 func (e *ChainHaltError) Active(config AlertConfig) bool {
@@ -73,6 +134,41 @@ func (e *ChainHaltError) Active(config AlertConfig) bool {
 func newChainHaltError(durationNano int64) *ChainHaltError {
 	return &ChainHaltError{durationNano: durationNano}
 }
+func newLocalHaltError(durationNano int64, referenceHeight int64) *ChainHaltError {
+	return &ChainHaltError{durationNano: durationNano, referenceHeight: referenceHeight}
+}
+
+type NetworkHaltError struct {
+	chainID         string
+	durationNano    int64
+	referenceHeight int64
+}
+
+func (e *NetworkHaltError) Error() string {
+	minutesHalted := int64(math.Round(float64(e.durationNano) / 6e10))
+	return fmt.Sprintf("chain %s has been halted for %dmin, the reference rpc is also stalled at height %d, block production has stopped network-wide", e.chainID, minutesHalted, e.referenceHeight)
+}
+func (e *NetworkHaltError) Active(config AlertConfig) bool {
+	return config.AlertActive(alertTypeNetworkHalt)
+}
+func newNetworkHaltError(chainID string, durationNano int64, referenceHeight int64) *NetworkHaltError {
+	return &NetworkHaltError{chainID: chainID, durationNano: durationNano, referenceHeight: referenceHeight}
+}
+
+type ChainIDMismatchError struct {
+	expected string
+	observed string
+}
+
+func (e *ChainIDMismatchError) Error() string {
+	return fmt.Sprintf("rpc node is on chain-id %s, expected %s, the rpc may be the wrong node or on a forked network", e.observed, e.expected)
+}
+func (e *ChainIDMismatchError) Active(config AlertConfig) bool {
+	return config.AlertActive(alertTypeChainIDMismatch)
+}
+func newChainIDMismatchError(expected string, observed string) *ChainIDMismatchError {
+	return &ChainIDMismatchError{expected: expected, observed: observed}
+}
 
 type BlockFetchError struct {
 	height  int64
@@ -86,37 +182,92 @@ func (e *BlockFetchError) Active(config AlertConfig) bool {
 	return config.AlertActive(alertTypeBlockFetch)
 }
 func newBlockFetchError(height int64, address string) *BlockFetchError {
-	return &BlockFetchError{height, address}
+	return &BlockFetchError{height, redactSecrets(address)}
 }
 
 type MissedRecentBlocksError struct {
 	missed  int64
+	streak  int64 // longest run of consecutive missed blocks
 	toCheck int64
 }
 
 func (e *MissedRecentBlocksError) Error() string {
-	return fmt.Sprintf("missed %d/%d most recent blocks", e.missed, e.toCheck)
+	return fmt.Sprintf("missed %d/%d most recent blocks, longest streak %d blocks in a row", e.missed, e.toCheck, e.streak)
 }
 func (e *MissedRecentBlocksError) Active(config AlertConfig) bool {
 	return config.AlertActive(alertTypeMissedRecentBlocks)
 }
-func newMissedRecentBlocksError(missed, toCheck int64) *MissedRecentBlocksError {
-	return &MissedRecentBlocksError{missed, toCheck}
+func newMissedRecentBlocksError(missed, streak, toCheck int64) *MissedRecentBlocksError {
+	return &MissedRecentBlocksError{missed, streak, toCheck}
+}
+
+type MissedBlockStreakError struct {
+	streak  int64
+	missed  int64
+	toCheck int64
+}
+
+func (e *MissedBlockStreakError) Error() string {
+	return fmt.Sprintf("missed %d blocks in a row, signer may be down (missed %d/%d most recent blocks)", e.streak, e.missed, e.toCheck)
+}
+func (e *MissedBlockStreakError) Active(config AlertConfig) bool {
+	return config.AlertActive(alertTypeMissedBlockStreak)
+}
+func newMissedBlockStreakError(streak, missed, toCheck int64) *MissedBlockStreakError {
+	return &MissedBlockStreakError{streak, missed, toCheck}
+}
+
+type UptimeDeclineError struct {
+	uptime    float64
+	rate      float64 // percentage points per hour
+	threshold float64
+	window    time.Duration
+}
+
+func (e *UptimeDeclineError) Error() string {
+	return fmt.Sprintf("block signing uptime (%.02f%%) declining %.02f%% per hour over the last %dmin, faster than %.02f%% per hour", e.uptime, e.rate, int64(e.window.Minutes()), e.threshold)
+}
+func (e *UptimeDeclineError) Active(config AlertConfig) bool {
+	return config.AlertActive(alertTypeUptimeDecline)
+}
+func newUptimeDeclineError(uptime, rate, threshold float64, window time.Duration) *UptimeDeclineError {
+	return &UptimeDeclineError{uptime, rate, threshold, window}
 }
 
 type SlashingSLAError struct {
-	uptime float64
-	sla    float64
+	uptime            float64
+	sla               float64
+	recovering        bool  // over the sla but not yet over the clear threshold
+	blocksUntilJailed int64 // -1 when unknown
+	jailBuffer        int64 // set when the alert is for the missed blocks left before jailing falling under the buffer
 }
 
 func (e *SlashingSLAError) Error() string {
-	return fmt.Sprintf("block signing uptime (%.02f%%) under SLA (%.02f%%)", e.uptime, e.sla)
+	var msg string
+	switch {
+	case e.jailBuffer > 0:
+		return fmt.Sprintf("%d missed blocks until jailed, under buffer of %d blocks (block signing uptime %.02f%%)", e.blocksUntilJailed, e.jailBuffer, e.uptime)
+	case e.recovering:
+		msg = fmt.Sprintf("block signing uptime (%.02f%%) recovering, under clear threshold (%.02f%%)", e.uptime, e.sla)
+	default:
+		msg = fmt.Sprintf("block signing uptime (%.02f%%) under SLA (%.02f%%)", e.uptime, e.sla)
+	}
+	if e.blocksUntilJailed >= 0 {
+		msg += fmt.Sprintf(", %d missed blocks until jailed", e.blocksUntilJailed)
+	}
+	return msg
 }
 func (e *SlashingSLAError) Active(config AlertConfig) bool {
 	return config.AlertActive(alertTypeSlashingSLA)
 }
-func newSlashingSLAError(uptime, sla float64) *SlashingSLAError {
-	return &SlashingSLAError{uptime, sla}
+func newSlashingSLAError(uptime, sla float64, blocksUntilJailed int64) *SlashingSLAError {
+	return &SlashingSLAError{uptime: uptime, sla: sla, blocksUntilJailed: blocksUntilJailed}
+}
+func newSlashingSLARecoveringError(uptime, clearThreshold float64, blocksUntilJailed int64) *SlashingSLAError {
+	return &SlashingSLAError{uptime: uptime, sla: clearThreshold, recovering: true, blocksUntilJailed: blocksUntilJailed}
+}
+func newSlashingJailBufferError(uptime float64, blocksUntilJailed, jailBuffer int64) *SlashingSLAError {
+	return &SlashingSLAError{uptime: uptime, blocksUntilJailed: blocksUntilJailed, jailBuffer: jailBuffer}
 }
 
 type GenericRPCError struct{ msg string }
@@ -126,17 +277,23 @@ func (e *GenericRPCError) Active(config AlertConfig) bool {
 	return config.AlertActive(alertTypeGenericRPC)
 }
 func newGenericRPCError(msg string) *GenericRPCError {
-	return &GenericRPCError{msg}
+	return &GenericRPCError{redactSecrets(msg)}
 }
 
 type SentryGRPCError struct {
-	sentry string
-	msg    string
+	sentry    string
+	msg       string
+	transport bool // connection failure rather than an error response
 }
 
-func (e *SentryGRPCError) Error() string { return fmt.Sprintf("%s - %s", e.sentry, e.msg) }
-func newSentryGRPCError(sentry string, msg string) *SentryGRPCError {
-	return &SentryGRPCError{sentry, msg}
+func (e *SentryGRPCError) Error() string {
+	if e.transport {
+		return fmt.Sprintf("%s - grpc transport error, connection lost: %s", e.sentry, e.msg)
+	}
+	return fmt.Sprintf("%s - %s", e.sentry, e.msg)
+}
+func newSentryGRPCError(sentry string, msg string, transport bool) *SentryGRPCError {
+	return &SentryGRPCError{sentry, redactSecrets(msg), transport}
 }
 
 type SentryOutOfSyncError struct {
@@ -146,18 +303,117 @@ type SentryOutOfSyncError struct {
 
 func (e *SentryOutOfSyncError) Error() string { return fmt.Sprintf("%s - %s", e.sentry, e.msg) }
 func newSentryOutOfSyncError(sentry string, msg string) *SentryOutOfSyncError {
-	return &SentryOutOfSyncError{sentry, msg}
+	return &SentryOutOfSyncError{sentry, redactSecrets(msg)}
 }
 
 type SentryHaltError struct {
 	sentry       string
+	height       int64
 	durationNano int64
 }
 
 func (e *SentryHaltError) Error() string {
 	minutesHalted := int64(math.Round(float64(e.durationNano) / 6e10))
-	return fmt.Sprintf("%s has been halted for %dmin", e.sentry, minutesHalted)
+	return fmt.Sprintf("%s has been halted at height %d for %dmin", e.sentry, e.height, minutesHalted)
+}
+func newSentryHaltError(sentry string, height int64, durationNano int64) *SentryHaltError {
+	return &SentryHaltError{sentry, height, durationNano}
+}
+
+type VotingPowerError struct {
+	reason string
+	power  int64
+	rank   int
+}
+
+func (e *VotingPowerError) Error() string {
+	rank := "N/A"
+	if e.rank > 0 {
+		rank = fmt.Sprint(e.rank)
+	}
+	return fmt.Sprintf("%s - rank %s, voting power %d", e.reason, rank, e.power)
+}
+func (e *VotingPowerError) Active(config AlertConfig) bool {
+	return config.AlertActive(alertTypeVotingPower)
+}
+func newVotingPowerError(reason string, power int64, rank int) *VotingPowerError {
+	return &VotingPowerError{reason, power, rank}
+}
+
+type BondStatusError struct {
+	status   string
+	previous string
+	tokens   string
+}
+
+// getBondStatusName returns the bond status without the BOND_STATUS_ prefix, e.g. unbonding
+func getBondStatusName(status string) string {
+	return strings.ToLower(strings.TrimPrefix(status, "BOND_STATUS_"))
+}
+
+func (e *BondStatusError) Error() string {
+	tokens := e.tokens
+	if tokens == "" {
+		tokens = "N/A"
+	}
+	if e.statusChanged() {
+		return fmt.Sprintf("validator status changed from %s to %s - bonded tokens %s", getBondStatusName(e.previous), getBondStatusName(e.status), tokens)
+	}
+	return fmt.Sprintf("validator is %s - bonded tokens %s", getBondStatusName(e.status), tokens)
+}
+func (e *BondStatusError) Active(config AlertConfig) bool {
+	return config.AlertActive(alertTypeUnbonding)
+}
+func (e *BondStatusError) statusChanged() bool {
+	return e.previous != "" && e.previous != e.status
+}
+func newBondStatusError(status string, previous string, tokens string) *BondStatusError {
+	return &BondStatusError{status, previous, tokens}
+}
+
+type UpgradeError struct {
+	name      string
+	height    int64
+	remaining int64
+	eta       time.Duration
+	milestone int64
+}
+
+func (e *UpgradeError) Error() string {
+	eta := "N/A"
+	if e.eta > 0 {
+		eta = e.eta.Round(time.Minute).String()
+	}
+	return fmt.Sprintf("chain upgrade %s scheduled at height %d in %d blocks (ETA ~%s)", e.name, e.height, e.remaining, eta)
+}
+func (e *UpgradeError) Active(config AlertConfig) bool {
+	return config.AlertActive(alertTypeUpgrade)
+}
+func newUpgradeError(name string, height, remaining int64, eta time.Duration, milestone int64) *UpgradeError {
+	return &UpgradeError{name, height, remaining, eta, milestone}
+}
+
+type SentryLowPeersError struct {
+	sentry   string
+	peers    int
+	minPeers int
+}
+
+func (e *SentryLowPeersError) Error() string {
+	return fmt.Sprintf("%s - only %d peers connected (minimum %d)", e.sentry, e.peers, e.minPeers)
+}
+func newSentryLowPeersError(sentry string, peers, minPeers int) *SentryLowPeersError {
+	return &SentryLowPeersError{sentry, peers, minPeers}
+}
+
+type SentryHostError struct {
+	sentry   string
+	problems []string
+}
+
+func (e *SentryHostError) Error() string {
+	return fmt.Sprintf("%s - low host resources: %s", e.sentry, strings.Join(e.problems, ", "))
 }
-func newSentryHaltError(sentry string, durationNano int64) *SentryHaltError {
-	return &SentryHaltError{sentry, durationNano}
+func newSentryHostError(sentry string, problems []string) *SentryHostError {
+	return &SentryHostError{sentry, problems}
 }
@@ -0,0 +1,149 @@
+package cmd
+
+import (
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"strconv"
+	"time"
+
+	"github.com/spf13/cobra"
+)
+
+const exportDateFormat = "2006-01-02"
+
+type uptimeExportFilter struct {
+	from      time.Time
+	to        time.Time
+	validator string
+}
+
+// parseExportTime parses an RFC3339 timestamp or a date, a date is the start of that day in UTC unless endOfDay is set
+func parseExportTime(value string, endOfDay bool) (time.Time, error) {
+	if t, err := time.Parse(time.RFC3339, value); err == nil {
+		return t, nil
+	}
+	t, err := time.Parse(exportDateFormat, value)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("invalid time %s, use YYYY-MM-DD or RFC3339", value)
+	}
+	if endOfDay {
+		t = t.Add(24 * time.Hour)
+	}
+	return t, nil
+}
+
+func parseUptimeExportFilter(from, to, validator string) (uptimeExportFilter, error) {
+	filter := uptimeExportFilter{validator: validator}
+	var err error
+	if from != "" {
+		if filter.from, err = parseExportTime(from, false); err != nil {
+			return filter, err
+		}
+	}
+	if to != "" {
+		if filter.to, err = parseExportTime(to, true); err != nil {
+			return filter, err
+		}
+	}
+	if !filter.from.IsZero() && !filter.to.IsZero() && !filter.to.After(filter.from) {
+		return filter, errors.New("to must be after from")
+	}
+	return filter, nil
+}
+
+func (filter uptimeExportFilter) includes(event AlertHistoryEvent) bool {
+	if event.Event != historyEventUptime {
+		return false
+	}
+	if filter.validator != "" && event.Validator != filter.validator {
+		return false
+	}
+	if !filter.from.IsZero() && event.Timestamp.Before(filter.from) {
+		return false
+	}
+	if !filter.to.IsZero() && !event.Timestamp.Before(filter.to) {
+		return false
+	}
+	return true
+}
+
+// writeUptimeCSV writes the uptime samples matching the filter as CSV
+func writeUptimeCSV(w io.Writer, events []AlertHistoryEvent, filter uptimeExportFilter) error {
+	writer := csv.NewWriter(w)
+	if err := writer.Write([]string{"timestamp", "validator", "chain-id", "height", "uptime", "recent-missed-blocks"}); err != nil {
+		return err
+	}
+	for _, event := range events {
+		if !filter.includes(event) {
+			continue
+		}
+		if err := writer.Write([]string{
+			event.Timestamp.UTC().Format(time.RFC3339),
+			event.Validator,
+			event.ChainID,
+			strconv.FormatInt(event.Height, 10),
+			strconv.FormatFloat(event.Uptime, 'f', 2, 64),
+			strconv.FormatInt(event.RecentMissedBlocks, 10),
+		}); err != nil {
+			return err
+		}
+	}
+	writer.Flush()
+	return writer.Error()
+}
+
+var exportCmd = &cobra.Command{
+	Use:   "export",
+	Short: "Export uptime history to CSV",
+	Long:  "Exports the uptime samples saved to the history uptime-file as CSV, for uptime reporting",
+	Run: func(cmd *cobra.Command, args []string) {
+		configFile, err := getConfigFile(cmd)
+		if err != nil {
+			log.Fatalf("Error resolving config file: %v", err)
+		}
+		config, err := loadConfig(configFile)
+		if err != nil {
+			log.Fatalf("Error loading config: %s", redactError(err))
+		}
+		if config.History == nil || config.History.UptimeFile == "" {
+			log.Fatalf("history uptime-file is not configured in config.yaml")
+		}
+
+		from, _ := cmd.Flags().GetString("from")
+		to, _ := cmd.Flags().GetString("to")
+		validator, _ := cmd.Flags().GetString("validator")
+		filter, err := parseUptimeExportFilter(from, to, validator)
+		if err != nil {
+			log.Fatalf("Error parsing export range: %v", err)
+		}
+
+		events, err := readHistoryFile(config.History.UptimeFile)
+		if err != nil {
+			log.Fatalf("Error reading uptime history: %v", err)
+		}
+
+		out := os.Stdout
+		if output, _ := cmd.Flags().GetString("output"); output != "" {
+			out, err = os.Create(output)
+			if err != nil {
+				log.Fatalf("Error creating %s: %v", output, err)
+			}
+			defer out.Close()
+		}
+		if err := writeUptimeCSV(out, events, filter); err != nil {
+			log.Fatalf("Error writing CSV: %v", err)
+		}
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(exportCmd)
+	exportCmd.Flags().String("from", "", "Start of the export range, YYYY-MM-DD or RFC3339")
+	exportCmd.Flags().String("to", "", "End of the export range, YYYY-MM-DD (inclusive) or RFC3339")
+	exportCmd.Flags().String("validator", "", "Only export samples for this validator name")
+	exportCmd.Flags().StringP("output", "o", "", "File to write the CSV to (default stdout)")
+}
@@ -0,0 +1,128 @@
+package cmd
+
+import (
+	"fmt"
+	"net/http"
+	"sort"
+	"sync"
+	"time"
+)
+
+// FleetHealthSender is implemented by notification services that can post the fleet health
+type FleetHealthSender interface {
+	UpdateFleetHealth(configFile string, config *HalfLifeConfig, fleet *FleetHealth, writeConfigMutex *sync.Mutex) error
+	SendFleetHealthTransition(config *HalfLifeConfig, previous AlertLevel, fleet *FleetHealth) error
+}
+
+// FleetHealth is the worst alert level across all enabled validators at their latest checks
+type FleetHealth struct {
+	AlertLevel AlertLevel
+	Validators int
+	Checked    int
+	Counts     map[AlertLevel]int // checked validators at each alert level
+	Unhealthy  []ValidatorStatus  // checked validators above alert level none, worst first
+}
+
+func getFleetHealth(summary *StatusSummary) *FleetHealth {
+	fleet := &FleetHealth{AlertLevel: alertLevelNone, Counts: make(map[AlertLevel]int)}
+	for _, group := range summary.Groups {
+		for _, status := range group.Validators {
+			fleet.Validators++
+			if status.Updated.IsZero() {
+				continue
+			}
+			fleet.Checked++
+			fleet.Counts[status.AlertLevel]++
+			if status.AlertLevel > fleet.AlertLevel {
+				fleet.AlertLevel = status.AlertLevel
+			}
+			if status.AlertLevel > alertLevelNone {
+				fleet.Unhealthy = append(fleet.Unhealthy, status)
+			}
+		}
+	}
+	sort.SliceStable(fleet.Unhealthy, func(i, j int) bool {
+		return fleet.Unhealthy[i].AlertLevel > fleet.Unhealthy[j].AlertLevel
+	})
+	return fleet
+}
+
+// countsString lists the number of checked validators at each alert level, e.g. 4 none, 1 critical
+func (fleet *FleetHealth) countsString() string {
+	counts := ""
+	for _, alertLevel := range []AlertLevel{alertLevelNone, alertLevelWarning, alertLevelHigh, alertLevelCritical} {
+		if fleet.Counts[alertLevel] == 0 {
+			continue
+		}
+		if counts != "" {
+			counts += ", "
+		}
+		counts += fmt.Sprintf("%d %s", fleet.Counts[alertLevel], alertLevel)
+	}
+	if fleet.Checked < fleet.Validators {
+		if counts != "" {
+			counts += ", "
+		}
+		counts += fmt.Sprintf("%d not checked", fleet.Validators-fleet.Checked)
+	}
+	return counts
+}
+
+// serveMetrics serves the fleet health as Prometheus gauges
+func serveMetrics(validators []*ValidatorMonitor) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		fleet := getFleetHealth(validatorStatuses.summary(validators, ""))
+		w.Header().Set("Content-Type", "text/plain; version=0.0.4")
+		fmt.Fprintln(w, "# HELP halflife_fleet_alert_level Worst alert level across all enabled validators: 0 none, 1 warning, 2 high, 3 critical.")
+		fmt.Fprintln(w, "# TYPE halflife_fleet_alert_level gauge")
+		fmt.Fprintf(w, "halflife_fleet_alert_level %d\n", fleet.AlertLevel)
+		fmt.Fprintln(w, "# HELP halflife_fleet_validators Enabled validators by alert level at their latest check.")
+		fmt.Fprintln(w, "# TYPE halflife_fleet_validators gauge")
+		for _, alertLevel := range []AlertLevel{alertLevelNone, alertLevelWarning, alertLevelHigh, alertLevelCritical} {
+			fmt.Fprintf(w, "halflife_fleet_validators{alert_level=%q} %d\n", alertLevel.String(), fleet.Counts[alertLevel])
+		}
+		fmt.Fprintf(w, "halflife_fleet_validators{alert_level=\"unchecked\"} %d\n", fleet.Validators-fleet.Checked)
+	}
+}
+
+// getFleetHealthSender returns the underlying notification service when it can post the fleet health
+func getFleetHealthSender(service NotificationService) (FleetHealthSender, bool) {
+	for service != nil {
+		if sender, ok := service.(FleetHealthSender); ok {
+			return sender, true
+		}
+		service = unwrapNotificationService(service)
+	}
+	return nil, false
+}
+
+// runFleetHealth updates the fleet health message and notifies fleet alert level changes every check interval.
+// Changes are notified once every validator has been checked, so that startup is not reported as a change.
+func runFleetHealth(
+	sender FleetHealthSender,
+	configFile string,
+	config *HalfLifeConfig,
+	validators []*ValidatorMonitor,
+	writeConfigMutex *sync.Mutex,
+) {
+	var previous *AlertLevel
+	for {
+		time.Sleep(checkInterval)
+		fleet := getFleetHealth(validatorStatuses.summary(validators, ""))
+		if config.Notifications.FleetHealthStatus {
+			if err := sender.UpdateFleetHealth(configFile, config, fleet, writeConfigMutex); err != nil {
+				fmt.Printf("Error updating fleet health: %s\n", redactError(err))
+			}
+		}
+		if fleet.Checked < fleet.Validators {
+			continue
+		}
+		if previous != nil && *previous != fleet.AlertLevel && config.Notifications.FleetHealthNotify {
+			if err := sender.SendFleetHealthTransition(config, *previous, fleet); err != nil {
+				fmt.Printf("Error sending fleet health change: %s\n", redactError(err))
+			}
+		}
+		alertLevel := fleet.AlertLevel
+		previous = &alertLevel
+	}
+}
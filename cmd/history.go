@@ -0,0 +1,336 @@
+package cmd
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strconv"
+	"sync"
+	"time"
+)
+
+const (
+	defaultMaxHistory        = 10000
+	defaultHistoryEndpointN  = 100
+	defaultUptimeInterval    = time.Hour
+	defaultUptimeMaxAge      = 90 * 24 * time.Hour
+	historyEventFired        = "fired"
+	historyEventCleared      = "cleared"
+	historyEventUptime       = "uptime"
+	historyScannerBufferSize = 1024 * 1024
+	historyPruneSlackPercent = 10 // of max history, dropped at once when it is exceeded
+)
+
+// AlertHistoryEvent is a single line of the alert history or uptime history JSONL file
+type AlertHistoryEvent struct {
+	Timestamp  time.Time  `json:"timestamp"`
+	Validator  string     `json:"validator"`
+	ChainID    string     `json:"chain-id"`
+	Event      string     `json:"event"`
+	AlertType  AlertType  `json:"alert-type,omitempty"`
+	Sentry     string     `json:"sentry,omitempty"`
+	AlertLevel AlertLevel `json:"alert-level"`
+	Height     int64      `json:"height"`
+	Message    string     `json:"message,omitempty"`
+	DedupKey   string     `json:"dedup-key,omitempty"`
+
+	// uptime samples
+	Uptime             float64 `json:"uptime,omitempty"`
+	RecentMissedBlocks int64   `json:"recent-missed-blocks,omitempty"`
+}
+
+// historyStore appends events to an on-disk JSONL file,
+// keeping at most maxHistory events and dropping events older than maxAge.
+type historyStore struct {
+	file       string
+	maxHistory int
+	maxAge     time.Duration
+	lock       sync.Mutex
+	events     []AlertHistoryEvent
+}
+
+// AlertHistory records alert transitions, and optionally periodic uptime samples for reporting
+type AlertHistory struct {
+	alerts *historyStore
+	uptime *historyStore
+
+	uptimeInterval time.Duration
+	lastSampleLock sync.Mutex
+	lastSample     map[string]time.Time
+}
+
+// newAlertHistory returns nil when neither alert history nor uptime history is configured
+func newAlertHistory(config *HistoryConfig) (*AlertHistory, error) {
+	if config == nil || (config.File == "" && config.UptimeFile == "") {
+		return nil, nil
+	}
+	history := &AlertHistory{
+		uptimeInterval: defaultUptimeInterval,
+		lastSample:     make(map[string]time.Time),
+	}
+	if config.File != "" {
+		alerts := &historyStore{
+			file:       config.File,
+			maxHistory: defaultMaxHistory,
+		}
+		if config.MaxHistory > 0 {
+			alerts.maxHistory = config.MaxHistory
+		}
+		if config.MaxAge != nil {
+			alerts.maxAge = *config.MaxAge
+		}
+		if err := alerts.load(); err != nil {
+			return nil, err
+		}
+		history.alerts = alerts
+	}
+	if config.UptimeFile != "" {
+		uptime := &historyStore{
+			file:   config.UptimeFile,
+			maxAge: defaultUptimeMaxAge,
+		}
+		if config.UptimeMaxAge != nil {
+			uptime.maxAge = *config.UptimeMaxAge
+		}
+		if config.UptimeInterval != nil && *config.UptimeInterval > 0 {
+			history.uptimeInterval = *config.UptimeInterval
+		}
+		if err := uptime.load(); err != nil {
+			return nil, err
+		}
+		history.uptime = uptime
+	}
+	return history, nil
+}
+
+// readHistoryFile reads all events from a history file
+func readHistoryFile(file string) ([]AlertHistoryEvent, error) {
+	store := &historyStore{file: file}
+	if err := store.read(); err != nil {
+		return nil, err
+	}
+	return store.events, nil
+}
+
+func (h *historyStore) read() error {
+	f, err := os.Open(h.file)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil
+		}
+		return err
+	}
+	defer f.Close()
+
+	scanner := bufio.NewScanner(f)
+	scanner.Buffer(make([]byte, 0, 64*1024), historyScannerBufferSize)
+	for scanner.Scan() {
+		var event AlertHistoryEvent
+		if err := json.Unmarshal(scanner.Bytes(), &event); err != nil {
+			fmt.Printf("Skipping malformed history line: %v\n", err)
+			continue
+		}
+		h.events = append(h.events, event)
+	}
+	return scanner.Err()
+}
+
+func (h *historyStore) load() error {
+	if err := h.read(); err != nil {
+		return err
+	}
+	if h.prune() {
+		return h.rewrite()
+	}
+	return nil
+}
+
+// prune drops events beyond the max history count or older than max age, returns true if any were dropped.
+// Past the max history count, events are dropped down to 90% of it, so that the file is rotated in batches and
+// the events in between are only appended.
+func (h *historyStore) prune() bool {
+	start := 0
+	if h.maxHistory > 0 && len(h.events) > h.maxHistory {
+		keep := h.maxHistory * (100 - historyPruneSlackPercent) / 100
+		if keep < 1 {
+			keep = 1
+		}
+		start = len(h.events) - keep
+	}
+	if h.maxAge > 0 {
+		cutoff := time.Now().Add(-h.maxAge)
+		for start < len(h.events) && h.events[start].Timestamp.Before(cutoff) {
+			start++
+		}
+	}
+	if start == 0 {
+		return false
+	}
+	h.events = append([]AlertHistoryEvent(nil), h.events[start:]...)
+	return true
+}
+
+// rewrite atomically replaces the history file with the in-memory events
+func (h *historyStore) rewrite() error {
+	tmp, err := os.CreateTemp(filepath.Dir(h.file), filepath.Base(h.file)+".tmp")
+	if err != nil {
+		return err
+	}
+	writer := bufio.NewWriter(tmp)
+	encoder := json.NewEncoder(writer)
+	for _, event := range h.events {
+		if err := encoder.Encode(event); err != nil {
+			tmp.Close()
+			os.Remove(tmp.Name())
+			return err
+		}
+	}
+	if err := writer.Flush(); err != nil {
+		tmp.Close()
+		os.Remove(tmp.Name())
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		os.Remove(tmp.Name())
+		return err
+	}
+	return os.Rename(tmp.Name(), h.file)
+}
+
+func (h *historyStore) append(events []AlertHistoryEvent) error {
+	f, err := os.OpenFile(h.file, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0600)
+	if err != nil {
+		return err
+	}
+	encoder := json.NewEncoder(f)
+	for _, event := range events {
+		if err := encoder.Encode(event); err != nil {
+			f.Close()
+			return err
+		}
+	}
+	return f.Close()
+}
+
+// add saves the events, rotating the file when events are pruned
+func (h *historyStore) add(events []AlertHistoryEvent) {
+	h.lock.Lock()
+	defer h.lock.Unlock()
+	h.events = append(h.events, events...)
+	if h.prune() {
+		if err := h.rewrite(); err != nil {
+			fmt.Printf("Error rotating history %s: %v\n", h.file, err)
+		}
+		return
+	}
+	if err := h.append(events); err != nil {
+		fmt.Printf("Error saving history %s: %v\n", h.file, err)
+	}
+}
+
+// recent returns up to the last n events
+func (h *historyStore) recent(n int) []AlertHistoryEvent {
+	h.lock.Lock()
+	defer h.lock.Unlock()
+	start := 0
+	if n < len(h.events) {
+		start = len(h.events) - n
+	}
+	return append([]AlertHistoryEvent(nil), h.events[start:]...)
+}
+
+// all returns a copy of all events
+func (h *historyStore) all() []AlertHistoryEvent {
+	h.lock.Lock()
+	defer h.lock.Unlock()
+	return append([]AlertHistoryEvent(nil), h.events...)
+}
+
+// record saves the transitions from an alert notification, safe to call on a nil history
+func (h *AlertHistory) record(vm *ValidatorMonitor, stats ValidatorStats, alertNotification *ValidatorAlertNotification) {
+	if h == nil || h.alerts == nil || alertNotification == nil || len(alertNotification.Transitions) == 0 {
+		return
+	}
+	now := time.Now()
+	events := make([]AlertHistoryEvent, len(alertNotification.Transitions))
+	for i, transition := range alertNotification.Transitions {
+		event := historyEventFired
+		if transition.Cleared {
+			event = historyEventCleared
+		}
+		events[i] = AlertHistoryEvent{
+			Timestamp:  now,
+			Validator:  vm.Name,
+			ChainID:    vm.ChainID,
+			Event:      event,
+			AlertType:  transition.AlertType,
+			Sentry:     transition.Sentry,
+			AlertLevel: transition.AlertLevel,
+			Height:     stats.Height,
+			Message:    transition.Message,
+			DedupKey:   transition.DedupKey,
+		}
+	}
+	h.alerts.add(events)
+}
+
+// sampleUptime saves the validator's uptime at most once per uptime interval, safe to call on a nil history
+func (h *AlertHistory) sampleUptime(vm *ValidatorMonitor, stats ValidatorStats) {
+	if h == nil || h.uptime == nil || vm.FullNode || stats.Height == 0 || stats.RPCError {
+		return
+	}
+	now := time.Now()
+	h.lastSampleLock.Lock()
+	if now.Sub(h.lastSample[vm.Name]) < h.uptimeInterval {
+		h.lastSampleLock.Unlock()
+		return
+	}
+	h.lastSample[vm.Name] = now
+	h.lastSampleLock.Unlock()
+
+	h.uptime.add([]AlertHistoryEvent{{
+		Timestamp:          now,
+		Validator:          vm.Name,
+		ChainID:            vm.ChainID,
+		Event:              historyEventUptime,
+		AlertLevel:         stats.AlertLevel,
+		Height:             stats.Height,
+		Uptime:             stats.SlashingPeriodUptime,
+		RecentMissedBlocks: stats.RecentMissedBlocks,
+	}})
+}
+
+// serveHTTP serves the last N events as JSON, N is set with the n query parameter
+func (h *AlertHistory) serveHTTP(w http.ResponseWriter, r *http.Request) {
+	n := defaultHistoryEndpointN
+	if nParam := r.URL.Query().Get("n"); nParam != "" {
+		parsed, err := strconv.Atoi(nParam)
+		if err != nil || parsed < 0 {
+			http.Error(w, "invalid n", http.StatusBadRequest)
+			return
+		}
+		n = parsed
+	}
+	if h.alerts == nil {
+		writeJSON(w, []AlertHistoryEvent{})
+		return
+	}
+	writeJSON(w, h.alerts.recent(n))
+}
+
+// serveUptimeCSV serves the uptime samples as CSV, filtered by the from, to and validator query parameters
+func (h *AlertHistory) serveUptimeCSV(w http.ResponseWriter, r *http.Request) {
+	filter, err := parseUptimeExportFilter(r.URL.Query().Get("from"), r.URL.Query().Get("to"), r.URL.Query().Get("validator"))
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	w.Header().Set("Content-Type", "text/csv")
+	w.Header().Set("Content-Disposition", "attachment; filename=uptime.csv")
+	if err := writeUptimeCSV(w, h.uptime.all(), filter); err != nil {
+		fmt.Printf("Error writing uptime export: %v\n", err)
+	}
+}
@@ -0,0 +1,40 @@
+package cmd
+
+import (
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestHistoryStorePrunesInBatches(t *testing.T) {
+	const maxHistory = 20
+	store := &historyStore{file: filepath.Join(t.TempDir(), "history.jsonl"), maxHistory: maxHistory}
+
+	rotations := 0
+	for i := 0; i < 3*maxHistory; i++ {
+		before := len(store.events)
+		store.add([]AlertHistoryEvent{{Timestamp: time.Now(), Validator: "validator", Height: int64(i)}})
+		if len(store.events) <= before {
+			rotations++
+		}
+		if len(store.events) > maxHistory {
+			t.Fatalf("%d events kept, want at most %d", len(store.events), maxHistory)
+		}
+	}
+	// past the first 20 events, each rotation drops down to 18 events, so the next two are appended: 14 rotations
+	// for the last 40 events in place of one for each
+	if rotations != 14 {
+		t.Errorf("rotated %d times, want 14", rotations)
+	}
+
+	saved, err := readHistoryFile(store.file)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(saved) != len(store.events) {
+		t.Fatalf("saved %d events, want %d", len(saved), len(store.events))
+	}
+	if last := saved[len(saved)-1].Height; last != 3*maxHistory-1 {
+		t.Errorf("last saved event at height %d, want %d", last, 3*maxHistory-1)
+	}
+}
@@ -0,0 +1,116 @@
+package cmd
+
+import (
+	"errors"
+	"fmt"
+)
+
+// InhibitRule suppresses the notifications of the target alert types of a validator while the source alert type is
+// active for it, as the targets are symptoms of the source, e.g. missed blocks counted from blocks that failed to fetch
+type InhibitRule struct {
+	Source  AlertType   `yaml:"source"`
+	Targets []AlertType `yaml:"targets"`
+}
+
+// defaultInhibitRules are used when inhibit-rules is not set, so that an RPC failure pages once, without the block
+// fetch errors and the stale signing alerts that follow from it
+var defaultInhibitRules = []InhibitRule{
+	{
+		Source: alertTypeRPCUnreachable,
+		Targets: []AlertType{
+			alertTypeGenericRPC, alertTypeOutOfSync, alertTypeBlockFetch, alertTypeHalt,
+			alertTypeMissedRecentBlocks, alertTypeMissedBlockStreak, alertTypeUptimeDecline,
+		},
+	},
+	{
+		Source:  alertTypeGenericRPC,
+		Targets: []AlertType{alertTypeBlockFetch, alertTypeMissedRecentBlocks, alertTypeMissedBlockStreak, alertTypeUptimeDecline},
+	},
+	{
+		Source:  alertTypeOutOfSync,
+		Targets: []AlertType{alertTypeBlockFetch, alertTypeMissedRecentBlocks, alertTypeMissedBlockStreak},
+	},
+	{
+		Source:  alertTypeBlockFetch,
+		Targets: []AlertType{alertTypeMissedRecentBlocks, alertTypeMissedBlockStreak},
+	},
+}
+
+// getInhibitRules returns the configured inhibit rules, the default rules when none are configured. An empty list
+// disables inhibition.
+func (at *AlertConfig) getInhibitRules() []InhibitRule {
+	if at.InhibitRules == nil {
+		return defaultInhibitRules
+	}
+	return *at.InhibitRules
+}
+
+func (rule InhibitRule) validate() error {
+	if rule.Source == "" {
+		return errors.New("inhibit rule source is required")
+	}
+	if len(rule.Targets) == 0 {
+		return fmt.Errorf("inhibit rule of %s has no targets", rule.Source)
+	}
+	for _, target := range rule.Targets {
+		if target == rule.Source {
+			return fmt.Errorf("inhibit rule of %s can't inhibit itself", rule.Source)
+		}
+	}
+	return nil
+}
+
+// getInhibitedAlertTypes returns each alert type inhibited by an active source alert type, with its source.
+// requires locked alertState
+func (alertState *ValidatorAlertState) getInhibitedAlertTypes(rules []InhibitRule) map[AlertType]AlertType {
+	inhibited := make(map[AlertType]AlertType)
+	for _, rule := range rules {
+		if alertState.AlertTypeCounts[rule.Source] == 0 {
+			continue
+		}
+		for _, target := range rule.Targets {
+			if _, ok := inhibited[target]; !ok {
+				inhibited[target] = rule.Source
+			}
+		}
+	}
+	return inhibited
+}
+
+// filterInhibited drops the alerts and cleared alerts of the inhibited alert types. The inhibited alerts are still
+// counted in the alert state and recorded in the history, they are only not notified.
+// Returns nil if nothing is left to notify.
+func (n *ValidatorAlertNotification) filterInhibited(vm *ValidatorMonitor, inhibited map[AlertType]AlertType) *ValidatorAlertNotification {
+	if n == nil || len(inhibited) == 0 {
+		return n
+	}
+	filtered := *n
+	filtered.Alerts, filtered.AlertKeys, filtered.AlertLevels = nil, nil, nil
+	filtered.AlertLevel = alertLevelNone
+	for i, alert := range n.Alerts {
+		key := n.AlertKeys[i]
+		if source, ok := inhibited[key.AlertType]; ok {
+			fmt.Printf("Not notifying %s alert of %s, inhibited by active %s\n", key.AlertType, vm.Name, source)
+			continue
+		}
+		filtered.Alerts = append(filtered.Alerts, alert)
+		filtered.AlertKeys = append(filtered.AlertKeys, key)
+		filtered.AlertLevels = append(filtered.AlertLevels, n.AlertLevels[i])
+		if n.AlertLevels[i] > filtered.AlertLevel {
+			filtered.AlertLevel = n.AlertLevels[i]
+		}
+	}
+	filtered.ClearedAlerts, filtered.ClearedAlertKeys = nil, nil
+	for i, clearedAlert := range n.ClearedAlerts {
+		key := n.ClearedAlertKeys[i]
+		if _, ok := inhibited[key.AlertType]; ok {
+			continue
+		}
+		filtered.ClearedAlerts = append(filtered.ClearedAlerts, clearedAlert)
+		filtered.ClearedAlertKeys = append(filtered.ClearedAlertKeys, key)
+	}
+	if len(filtered.Alerts) == 0 && len(filtered.ClearedAlerts) == 0 {
+		return nil
+	}
+	return &filtered
+}
@@ -0,0 +1,86 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"os/signal"
+	"syscall"
+)
+
+const (
+	lifecycleStarted  = "started"
+	lifecycleStopping = "stopping"
+)
+
+// LifecycleSender is implemented by notification services that can notify the monitor starting and shutting down.
+// Pagers don't implement it, so lifecycle notifications never page.
+type LifecycleSender interface {
+	SendLifecycleNotification(config *HalfLifeConfig, lifecycle *LifecycleNotification) error
+}
+
+// LifecycleNotification is a low priority notification of the monitor starting or shutting down
+type LifecycleNotification struct {
+	Event      string // started or stopping
+	Message    string
+	Validators []*ValidatorMonitor // the monitored validators, only listed when started
+}
+
+// validatorLines returns a line with the name and chain-id of each validator
+func (lifecycle *LifecycleNotification) validatorLines() []string {
+	var lines []string
+	for _, vm := range lifecycle.Validators {
+		lines = append(lines, fmt.Sprintf("%s (%s)", vm.Name, vm.ChainID))
+	}
+	return lines
+}
+
+func getLifecycleSender(service NotificationService) (LifecycleSender, bool) {
+	for service != nil {
+		if sender, ok := service.(LifecycleSender); ok {
+			return sender, true
+		}
+		service = unwrapNotificationService(service)
+	}
+	return nil, false
+}
+
+func newStartedNotification(validators []*ValidatorMonitor) *LifecycleNotification {
+	return &LifecycleNotification{
+		Event:      lifecycleStarted,
+		Message:    fmt.Sprintf("half-life started monitoring %d validators", len(validators)),
+		Validators: validators,
+	}
+}
+
+func newStoppingNotification(validators []*ValidatorMonitor, reason string) *LifecycleNotification {
+	return &LifecycleNotification{
+		Event:   lifecycleStopping,
+		Message: fmt.Sprintf("half-life shutting down (%s), no longer monitoring %d validators", reason, len(validators)),
+	}
+}
+
+// notifyShutdown shuts down on SIGINT or SIGTERM
+func notifyShutdown(notificationService NotificationService, config *HalfLifeConfig, validators []*ValidatorMonitor) {
+	signals := make(chan os.Signal, 1)
+	signal.Notify(signals, syscall.SIGINT, syscall.SIGTERM)
+	go func() {
+		sig := <-signals
+		fmt.Printf("Received %s, shutting down\n", sig)
+		shutdown(notificationService, config, validators, sig.String(), 0)
+	}()
+}
+
+// shutdown flushes pending digests and waits for queued retries, sends the shutdown notification when lifecycle
+// notifications are enabled, then exits with the code
+func shutdown(notificationService NotificationService, config *HalfLifeConfig, validators []*ValidatorMonitor, reason string, code int) {
+	flushNotifications(notificationService)
+	drainNotifications(notificationService, notificationDrainTimeout)
+	if config.Notifications.LifecycleNotify {
+		if sender, ok := getLifecycleSender(notificationService); ok {
+			if err := sender.SendLifecycleNotification(config, newStoppingNotification(validators, reason)); err != nil {
+				fmt.Printf("Error sending shutdown notification: %s\n", redactError(err))
+			}
+		}
+	}
+	os.Exit(code)
+}
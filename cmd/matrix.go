@@ -0,0 +1,342 @@
+package cmd
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"html"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+const (
+	matrixHTTPTimeout  = 20 * time.Second
+	matrixHTMLFormat   = "org.matrix.custom.html"
+	matrixUnknownToken = "M_UNKNOWN_TOKEN"
+)
+
+type MatrixNotificationService struct {
+	config     MatrixConfig
+	httpClient *http.Client
+	txnCounter uint64
+}
+
+func NewMatrixNotificationService(config MatrixConfig, httpClient *http.Client) *MatrixNotificationService {
+	if httpClient == nil {
+		httpClient = &http.Client{Timeout: matrixHTTPTimeout}
+	}
+	return &MatrixNotificationService{
+		config:     config,
+		httpClient: httpClient,
+	}
+}
+
+type matrixMessage struct {
+	MsgType       string           `json:"msgtype"`
+	Body          string           `json:"body"`
+	Format        string           `json:"format,omitempty"`
+	FormattedBody string           `json:"formatted_body,omitempty"`
+	NewContent    *matrixMessage   `json:"m.new_content,omitempty"`
+	RelatesTo     *matrixRelatesTo `json:"m.relates_to,omitempty"`
+}
+
+type matrixRelatesTo struct {
+	RelType string `json:"rel_type"`
+	EventID string `json:"event_id"`
+}
+
+type matrixError struct {
+	ErrCode string `json:"errcode"`
+	Error   string `json:"error"`
+}
+
+func newMatrixMessage(body string, formattedBody string) *matrixMessage {
+	return &matrixMessage{
+		MsgType:       "m.text",
+		Body:          body,
+		Format:        matrixHTMLFormat,
+		FormattedBody: formattedBody,
+	}
+}
+
+// request sends a request to the homeserver client API, decoding the JSON response into out when not nil
+func (service *MatrixNotificationService) request(method string, path string, body interface{}, out interface{}) error {
+	var reqBody bytes.Buffer
+	if body != nil {
+		if err := json.NewEncoder(&reqBody).Encode(body); err != nil {
+			return err
+		}
+	}
+	req, err := http.NewRequest(method, strings.TrimSuffix(service.config.HomeserverURL, "/")+path, &reqBody)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Authorization", "Bearer "+service.config.AccessToken)
+	req.Header.Set("Content-Type", "application/json")
+	res, err := service.httpClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer res.Body.Close()
+	if res.StatusCode != http.StatusOK {
+		var matrixErr matrixError
+		_ = json.NewDecoder(res.Body).Decode(&matrixErr)
+		if res.StatusCode == http.StatusUnauthorized || matrixErr.ErrCode == matrixUnknownToken {
+			return fmt.Errorf("matrix access token was rejected (%s), it may have expired or been revoked, update access-token in config.yaml", matrixErr.ErrCode)
+		}
+		return fmt.Errorf("matrix request failed: %s %s %s", res.Status, matrixErr.ErrCode, matrixErr.Error)
+	}
+	if out != nil {
+		return json.NewDecoder(res.Body).Decode(out)
+	}
+	return nil
+}
+
+// send posts a message to the room, returning its event ID
+func (service *MatrixNotificationService) send(roomID string, message *matrixMessage) (string, error) {
+	txnID := fmt.Sprintf("halflife-%d-%d", time.Now().UnixNano(), atomic.AddUint64(&service.txnCounter, 1))
+	path := fmt.Sprintf("/_matrix/client/v3/rooms/%s/send/m.room.message/%s", url.PathEscape(roomID), txnID)
+	var res struct {
+		EventID string `json:"event_id"`
+	}
+	if err := service.request(http.MethodPut, path, message, &res); err != nil {
+		return "", err
+	}
+	return res.EventID, nil
+}
+
+// edit replaces the content of a prior message
+func (service *MatrixNotificationService) edit(roomID string, eventID string, message *matrixMessage) error {
+	_, err := service.send(roomID, &matrixMessage{
+		MsgType:    message.MsgType,
+		Body:       "* " + message.Body,
+		NewContent: message,
+		RelatesTo:  &matrixRelatesTo{RelType: "m.replace", EventID: eventID},
+	})
+	return err
+}
+
+func matrixColor(alertLevel AlertLevel) string {
+	return fmt.Sprintf("#%06X", getColorForAlertLevel(defaultAlertLevelColors, alertLevel))
+}
+
+// getMentions returns the plain and HTML mentions of the alert user IDs
+func (service *MatrixNotificationService) getMentions() (string, string) {
+	var plain, formatted []string
+	for _, userID := range service.config.AlertUserIDs {
+		plain = append(plain, userID)
+		formatted = append(formatted, fmt.Sprintf(`<a href="https://matrix.to/#/%s">%s</a>`, html.EscapeString(userID), html.EscapeString(userID)))
+	}
+	return strings.Join(plain, " "), strings.Join(formatted, " ")
+}
+
+// getAlertMessage formats a list of alerts under a heading, colored for the alert level
+func (service *MatrixNotificationService) getAlertMessage(title string, heading string, alerts []string, alertLevel AlertLevel, mention bool) *matrixMessage {
+	body := fmt.Sprintf("%s\n%s", title, heading)
+	formattedBody := fmt.Sprintf(`<font color="%s"><b>%s</b></font><br><b>%s</b><ul>`, matrixColor(alertLevel), html.EscapeString(title), html.EscapeString(heading))
+	for _, alert := range alerts {
+		body += fmt.Sprintf("\n• %s", alert)
+		formattedBody += fmt.Sprintf("<li>%s</li>", html.EscapeString(alert))
+	}
+	formattedBody += "</ul>"
+	if mention {
+		plainMentions, formattedMentions := service.getMentions()
+		if plainMentions != "" {
+			body = plainMentions + "\n" + body
+			formattedBody = formattedMentions + "<br>" + formattedBody
+		}
+	}
+	return newMatrixMessage(body, formattedBody)
+}
+
+// addMatrixDetails appends the validator stats to the message, for detailed verbosity
+func addMatrixDetails(message *matrixMessage, details []string) *matrixMessage {
+	message.Body += "\nDetails:"
+	message.FormattedBody += "<b>Details:</b><ul>"
+	for _, line := range details {
+		message.Body += fmt.Sprintf("\n• %s", line)
+		message.FormattedBody += fmt.Sprintf("<li>%s</li>", html.EscapeString(line))
+	}
+	message.FormattedBody += "</ul>"
+	return message
+}
+
+// getMatrixStatusMessage renders the status of the validator, without the sentries for minimal verbosity
+func getMatrixStatusMessage(stats ValidatorStats, vm *ValidatorMonitor, verbosity Verbosity) *matrixMessage {
+	title := getValidatorTitle(vm, stats)
+
+	var lines []string
+	if stats.RPCError || stats.Height == 0 {
+		lines = append(lines, fmt.Sprintf("%s Height N/A", iconError))
+	} else {
+		lines = append(lines, fmt.Sprintf("%s Height %d - %s", iconGood, stats.Height, stats.Timestamp.UTC().Format(time.RFC3339)))
+		if !vm.FullNode {
+			signedIcon := iconGood
+			switch level := stats.RecentMissedBlockAlertLevel; {
+			case level >= alertLevelHigh:
+				signedIcon = iconError
+			case level == alertLevelWarning:
+				signedIcon = iconWarning
+			}
+			lines = append(lines, fmt.Sprintf("%s Latest Blocks Signed: %d/%d", signedIcon, vm.RecentBlocksToCheck-stats.RecentMissedBlocks, vm.RecentBlocksToCheck))
+			if stats.UptimeTrendKnown {
+				trendIcon := iconGood
+				if getUptimeTrendDirection(stats.UptimeTrend) == uptimeTrendFalling {
+					trendIcon = iconWarning
+				}
+				lines = append(lines, fmt.Sprintf("%s Uptime Trend: %s", trendIcon, formatUptimeTrend(stats.UptimeTrend)))
+			}
+		}
+	}
+	if stats.RPCError {
+		lines = append(lines, fmt.Sprintf("%s %s", iconError, getRPCHealth(stats)))
+	}
+	if verbosity == verbosityDetailed && stats.BondStatus != "" {
+		lines = append(lines, fmt.Sprintf("%s Bond Status %s - Voting Power %d (rank %d)", iconGood, stats.BondStatus, stats.VotingPower, stats.VotingPowerRank))
+	}
+	for _, sentryStats := range stats.SentryStats {
+		if verbosity == verbosityMinimal {
+			break
+		}
+		statusIcon := iconGood
+		if sentryStats.SentryAlertType != sentryAlertTypeNone {
+			statusIcon = iconError
+		}
+		height := "N/A"
+		if sentryStats.Height != 0 {
+			height = fmt.Sprint(sentryStats.Height)
+		}
+		line := fmt.Sprintf("%s %s - Height %s", statusIcon, sentryStats.Name, height)
+		if !sentryStats.grpcReachable() {
+			line += " - gRPC unreachable"
+		}
+		lines = append(lines, line)
+	}
+
+	body := title + "\n" + strings.Join(lines, "\n")
+	formattedBody := fmt.Sprintf(`<font color="%s"><b>%s</b></font>`, matrixColor(stats.AlertLevel), html.EscapeString(title))
+	for _, line := range lines {
+		formattedBody += "<br>" + html.EscapeString(line)
+	}
+	return newMatrixMessage(body, formattedBody)
+}
+
+// implements NotificationService interface
+func (service *MatrixNotificationService) CheckReachability() error {
+	var res struct {
+		UserID string `json:"user_id"`
+	}
+	if err := service.request(http.MethodGet, "/_matrix/client/v3/account/whoami", nil, &res); err != nil {
+		return fmt.Errorf("matrix homeserver %s could not be reached or token was rejected: %w", service.config.HomeserverURL, err)
+	}
+	return nil
+}
+
+// implements NotificationService interface
+func (service *MatrixNotificationService) UpdateValidatorRealtimeStatus(
+	configFile string,
+	config *HalfLifeConfig,
+	vm *ValidatorMonitor,
+	stats ValidatorStats,
+	writeConfigMutex *sync.Mutex,
+) error {
+	message := getMatrixStatusMessage(stats, vm, getVerbosity(service.config.Verbosity))
+	roomID := service.config.getStatusRoomID()
+	if vm.MatrixStatusEventID != nil {
+		if err := service.edit(roomID, *vm.MatrixStatusEventID, message); err != nil {
+			return fmt.Errorf("error updating matrix status message: %w", err)
+		}
+		return nil
+	}
+	eventID, err := service.send(roomID, message)
+	if err != nil {
+		return fmt.Errorf("error sending matrix status message: %w", err)
+	}
+	vm.MatrixStatusEventID = &eventID
+	fmt.Printf("Saved matrix status event ID: %s\n", eventID)
+	saveConfig(configFile, config, writeConfigMutex)
+	return nil
+}
+
+// implements NotificationService interface
+func (service *MatrixNotificationService) SendValidatorAlertNotification(
+	config *HalfLifeConfig,
+	vm *ValidatorMonitor,
+	stats ValidatorStats,
+	alertNotification *ValidatorAlertNotification,
+) error {
+	var errs []string
+	title := getValidatorTitle(vm, stats)
+	verbosity := getVerbosity(service.config.Verbosity)
+	alerts, clearedAlerts := alertNotification.Alerts, alertNotification.ClearedAlerts
+	if verbosity == verbosityMinimal {
+		alerts, clearedAlerts = []string{getAlertsSummary(alerts)}, []string{getAlertsSummary(clearedAlerts)}
+	}
+	if len(alertNotification.Alerts) > 0 {
+		message := service.getAlertMessage(title, "Errors:", alerts, alertNotification.AlertLevel, alertNotification.AlertLevel > alertLevelWarning)
+		if verbosity == verbosityDetailed {
+			message = addMatrixDetails(message, getStatsDetails(vm, stats))
+		}
+		if _, err := service.send(service.config.RoomID, message); err != nil {
+			errs = append(errs, fmt.Sprintf("error sending matrix alert message: %v", err))
+		}
+	}
+	if len(alertNotification.ClearedAlerts) > 0 {
+		message := service.getAlertMessage(title, "Errors cleared:", clearedAlerts, alertLevelNone, alertNotification.NotifyForClear)
+		if _, err := service.send(service.config.RoomID, message); err != nil {
+			errs = append(errs, fmt.Sprintf("error sending matrix cleared alerts message: %v", err))
+		}
+	}
+	if len(errs) > 0 {
+		return errors.New(strings.Join(errs, ", "))
+	}
+	return nil
+}
+
+// implements AlertDigestSender interface
+func (service *MatrixNotificationService) SendAlertDigest(config *HalfLifeConfig, digest *AlertDigest) error {
+	title := fmt.Sprintf("Alert digest (%d validators)", digest.Validators())
+	var errs []string
+	send := func(heading string, groups []AlertDigestGroup, alertLevel AlertLevel, mention bool) {
+		var alerts []string
+		for _, group := range groups {
+			alertType := string(group.AlertType)
+			if alertType == "" {
+				alertType = "other"
+			}
+			for _, entry := range group.Entries {
+				alerts = append(alerts, fmt.Sprintf("%s - %s - %s: %s", group.AlertLevel, alertType, entry.Validator, entry.Message))
+			}
+		}
+		if _, err := service.send(service.config.RoomID, service.getAlertMessage(title, heading, alerts, alertLevel, mention)); err != nil {
+			errs = append(errs, fmt.Sprintf("error sending matrix alert digest message: %v", err))
+		}
+	}
+	if len(digest.Alerts) > 0 {
+		send("Errors:", digest.AlertGroups(), digest.AlertLevel, digest.AlertLevel > alertLevelWarning)
+	}
+	if len(digest.ClearedAlerts) > 0 {
+		send("Errors cleared:", digest.ClearedAlertGroups(), alertLevelNone, digest.NotifyForClear)
+	}
+	if len(errs) > 0 {
+		return errors.New(strings.Join(errs, ", "))
+	}
+	return nil
+}
+
+// implements LifecycleSender interface
+func (service *MatrixNotificationService) SendLifecycleNotification(config *HalfLifeConfig, lifecycle *LifecycleNotification) error {
+	message := newMatrixMessage(lifecycle.Message, html.EscapeString(lifecycle.Message))
+	if lines := lifecycle.validatorLines(); len(lines) > 0 {
+		message = service.getAlertMessage(lifecycle.Message, "Validators:", lines, alertLevelNone, false)
+	}
+	if _, err := service.send(service.config.RoomID, message); err != nil {
+		return fmt.Errorf("error sending matrix %s message: %w", lifecycle.Event, err)
+	}
+	return nil
+}
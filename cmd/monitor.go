@@ -3,11 +3,12 @@ package cmd
 import (
 	"fmt"
 	"log"
+	"net/http"
 	"os"
 	"sync"
+	"time"
 
 	"github.com/spf13/cobra"
-	"gopkg.in/yaml.v2"
 )
 
 var monitorCmd = &cobra.Command{
@@ -15,58 +16,188 @@ var monitorCmd = &cobra.Command{
 	Short: "Daemon to monitor validators",
 	Long:  "Monitors validators and pushes alerts to Discord using the configuration in config.yaml",
 	Run: func(cmd *cobra.Command, args []string) {
-		configFile, _ := cmd.Flags().GetString("file")
-		dat, err := os.ReadFile(configFile)
+		configFile, err := getConfigFile(cmd)
 		if err != nil {
-			log.Fatalf("Error reading config.yaml: %v", err)
+			log.Fatalf("Error resolving config file: %v", err)
 		}
-		config := HalfLifeConfig{}
-		err = yaml.Unmarshal(dat, &config)
+		config, err := loadConfig(configFile)
 		if err != nil {
-			log.Fatalf("Error parsing config.yaml: %v", err)
+			log.Fatalf("Error loading config: %s", redactError(err))
 		}
-		config.getUnsetDefaults()
 
-		if config.Notifications == nil {
-			panic("Notifications configuration is not present in config.yaml")
+		if dryRun, _ := cmd.Flags().GetBool("dry-run"); dryRun {
+			config.DryRun = true
+		}
+
+		notificationService, err := getNotificationService(config)
+		if err != nil {
+			log.Fatalf("Error configuring notifications: %s", redactError(err))
+		}
+		if err := notificationService.CheckReachability(); err != nil {
+			fmt.Printf("!!! NOTIFICATIONS WILL NOT BE DELIVERED: %s\n", redactError(err))
 		}
 
 		writeConfigMutex := sync.Mutex{}
-		// TODO implement more notification services e.g. slack, email
-		var notificationService NotificationService
-		switch config.Notifications.Service {
-		case "discord":
-			if config.Notifications.Discord == nil {
-				panic("Discord configuration not present in config.yaml")
+
+		validators := config.enabledValidators()
+		if len(validators) == 0 {
+			log.Fatalf("No enabled validators to monitor")
+		}
+
+		for _, vm := range validators {
+			if err := resolveValidatorAddress(config, vm); err != nil {
+				log.Fatalf("Error resolving validator %s: %s", vm.Name, redactError(err))
 			}
-			notificationService = NewDiscordNotificationService(config.Notifications.Discord.Webhook.ID, config.Notifications.Discord.Webhook.Token)
-		default:
-			if config.Notifications.Service == "" {
-				panic("Notification service not configured in config.yaml")
+		}
+		sharedRPCs.register(validators)
+		checkSchedule.register(config, validators)
+		tracing.register(config)
+
+		history, err := newAlertHistory(config.History)
+		if err != nil {
+			log.Fatalf("Error loading alert history: %v", err)
+		}
+
+		mux := http.NewServeMux()
+		mux.HandleFunc("/status", serveStatus(validators))
+		mux.HandleFunc("/metrics", serveMetrics(validators))
+		if history != nil {
+			mux.HandleFunc("/history", history.serveHTTP)
+			if history.uptime != nil {
+				mux.HandleFunc("/uptime.csv", history.serveUptimeCSV)
 			}
-			panic(fmt.Sprintf("Notification service not supported: %s", config.Notifications.Service))
 		}
+		startHTTPServer(config, mux)
 
 		alertState := make(map[string]*ValidatorAlertState)
-		for i, vm := range config.Validators {
-			alertState[vm.Name] = &ValidatorAlertState{
-				AlertTypeCounts:            make(map[AlertType]int64),
-				SentryGRPCErrorCounts:      make(map[string]int64),
-				SentryOutOfSyncErrorCounts: make(map[string]int64),
-				SentryHaltErrorCounts:      make(map[string]int64),
-				SentryLatestHeight:         make(map[string]int64),
+		alertStateLocks := make(map[string]*sync.Mutex)
+		for _, vm := range validators {
+			alertState[vm.Name] = newValidatorAlertState()
+			alertStateLocks[vm.Name] = &sync.Mutex{}
+		}
+
+		once, _ := cmd.Flags().GetBool("once")
+		if once {
+			// a single cycle is never re-notified, so acks are not accepted
+			startAPIServer(config, validators, nil, nil)
+			alertLevel := runMonitorOnce(notificationService, alertState, configFile, config, validators, &writeConfigMutex, history)
+			flushNotifications(notificationService)
+			drainNotifications(notificationService, notificationDrainTimeout)
+			tracing.flush()
+			os.Exit(int(alertLevel))
+		}
+		startAPIServer(config, validators, alertState, alertStateLocks)
+
+		if grace := config.AlertConfig.StartupGracePeriod; grace != nil && *grace > 0 {
+			graceUntil := time.Now().Add(*grace)
+			for _, state := range alertState {
+				state.StartupGraceUntil = graceUntil
 			}
-			alertStateLock := sync.Mutex{}
-			if i == len(config.Validators)-1 {
-				runMonitor(notificationService, alertState[vm.Name], &alertStateLock, configFile, &config, vm, &writeConfigMutex)
+			fmt.Printf("Out-of-sync and halt alerts will not be notified until %s\n", graceUntil.Format(time.RFC3339))
+		}
+
+		if config.Notifications.StatusSummary {
+			sender, ok := getStatusSummarySender(notificationService)
+			if !ok {
+				log.Fatalf("Status summary is not supported by the configured notification service")
+			}
+			go runStatusSummary(sender, configFile, config, validators, &writeConfigMutex)
+		}
+		if config.Notifications.FleetHealthStatus || config.Notifications.FleetHealthNotify {
+			sender, ok := getFleetHealthSender(notificationService)
+			if !ok {
+				log.Fatalf("Fleet health is not supported by the configured notification service")
+			}
+			go runFleetHealth(sender, configFile, config, validators, &writeConfigMutex)
+		}
+		if config.Notifications.LifecycleNotify {
+			sender, ok := getLifecycleSender(notificationService)
+			if !ok {
+				log.Fatalf("Lifecycle notifications are not supported by the configured notification service")
+			}
+			if err := sender.SendLifecycleNotification(config, newStartedNotification(validators)); err != nil {
+				fmt.Printf("Error sending startup notification: %s\n", redactError(err))
+			}
+			notifyShutdown(notificationService, config, validators)
+		}
+		if configPollInterval, _ := cmd.Flags().GetDuration("config-poll-interval"); configPollInterval > 0 {
+			go watchConfigSource(notificationService, configFile, config, validators, configPollInterval)
+		}
+
+		for i, vm := range validators {
+			if i == len(validators)-1 {
+				runMonitor(notificationService, alertState[vm.Name], alertStateLocks[vm.Name], configFile, config, vm, &writeConfigMutex, history)
 			} else {
-				go runMonitor(notificationService, alertState[vm.Name], &alertStateLock, configFile, &config, vm, &writeConfigMutex)
+				go runMonitor(notificationService, alertState[vm.Name], alertStateLocks[vm.Name], configFile, config, vm, &writeConfigMutex, history)
 			}
 		}
 	},
 }
 
+func newValidatorAlertState() *ValidatorAlertState {
+	return &ValidatorAlertState{
+		AlertTypeCounts:            make(map[AlertType]int64),
+		SentryGRPCErrorCounts:      make(map[string]int64),
+		SentryOutOfSyncErrorCounts: make(map[string]int64),
+		SentryHaltErrorCounts:      make(map[string]int64),
+		SentryLowPeersErrorCounts:  make(map[string]int64),
+		SentryHostErrorCounts:      make(map[string]int64),
+		SentryLatestHeight:         make(map[string]int64),
+		SentryLastNotified:         make(map[AlertKey]SentryNotifyState),
+		AlertLastSent:              make(map[AlertType]time.Time),
+		PendingConfirmCounts:       make(map[AlertType]int64),
+		AckedAlerts:                make(map[AlertKey]time.Time),
+		StartupSuppressed:          make(map[AlertKey]bool),
+	}
+}
+
+// runMonitorOnce runs a single monitoring cycle for all validators concurrently
+// and returns the worst alert level encountered across all of them, at least warning when a check was abandoned
+func runMonitorOnce(
+	notificationService NotificationService,
+	alertState map[string]*ValidatorAlertState,
+	configFile string,
+	config *HalfLifeConfig,
+	validators []*ValidatorMonitor,
+	writeConfigMutex *sync.Mutex,
+	history *AlertHistory,
+) AlertLevel {
+	worstAlertLevel := alertLevelNone
+	worstAlertLevelLock := sync.Mutex{}
+	cycle := tracing.startTrace("monitor cycle", intAttribute("halflife.validators", int64(len(validators))))
+	cycleCtx, cancel := newCycleContext(config)
+	defer cancel()
+	wg := sync.WaitGroup{}
+	wg.Add(len(validators))
+	for _, vm := range validators {
+		go func(vm *ValidatorMonitor) {
+			defer wg.Done()
+			// a single cycle checks every validator, so lower priority checks wait rather than skip
+			checkSchedule.acquire(vm, false)
+			defer checkSchedule.release()
+			alertStateLock := sync.Mutex{}
+			alertLevel, checked := runMonitorCycle(cycleCtx, notificationService, alertState[vm.Name], &alertStateLock, configFile, config, vm, writeConfigMutex, history, cycle)
+			if !checked && alertLevel < alertLevelWarning {
+				// a validator that was not checked can't be reported as healthy
+				alertLevel = alertLevelWarning
+			}
+			worstAlertLevelLock.Lock()
+			if alertLevel > worstAlertLevel {
+				worstAlertLevel = alertLevel
+			}
+			worstAlertLevelLock.Unlock()
+		}(vm)
+	}
+	wg.Wait()
+	cycle.set(stringAttribute("halflife.alert_level", worstAlertLevel.String()))
+	cycle.end(nil)
+	return worstAlertLevel
+}
+
 func init() {
 	rootCmd.AddCommand(monitorCmd)
-	monitorCmd.Flags().StringP("file", "f", configFilePath, "File path to config yaml")
+	monitorCmd.Flags().StringP("file", "f", "", "File path to config yaml (deprecated, use --config)")
+	monitorCmd.Flags().Duration("config-poll-interval", 0, "Interval to poll the config source for changes, exiting when it changes so the new config is loaded on restart (requires a read-only config source or save-file)")
+	monitorCmd.Flags().Bool("dry-run", false, "Log the notifications that would be sent instead of sending them (same as dry-run: true in the config)")
+	monitorCmd.Flags().Bool("once", false, "Run a single monitoring cycle and exit with the worst alert level (0 none, 1 warning, 2 high, 3 critical)")
 }
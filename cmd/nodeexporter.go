@@ -0,0 +1,129 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"time"
+
+	dto "github.com/prometheus/client_model/go"
+	"github.com/prometheus/common/expfmt"
+)
+
+const (
+	sentryHostErrorNotifyThreshold                 = 1 // will notify with error for any more than this number of consecutive low host resources errors for a given sentry
+	defaultSentryDiskMountpoint                    = "/"
+	defaultSentryMinDiskFreePercent        float64 = 10
+	defaultSentryMinMemoryAvailablePercent float64 = 10
+)
+
+// sentryHostMetrics are the host resources of a sentry scraped from its node-exporter metrics,
+// a percentage is -1 when its metrics are not exposed
+type sentryHostMetrics struct {
+	diskFreePercent        float64
+	memoryAvailablePercent float64
+}
+
+// getGaugeValue returns the value of the gauge, or of the gauge with the label value when the label is not empty
+func getGaugeValue(families map[string]*dto.MetricFamily, name string, label string, labelValue string) (float64, bool) {
+	family, ok := families[name]
+	if !ok {
+		return 0, false
+	}
+	for _, metric := range family.GetMetric() {
+		if label != "" {
+			matched := false
+			for _, pair := range metric.GetLabel() {
+				if pair.GetName() == label && pair.GetValue() == labelValue {
+					matched = true
+					break
+				}
+			}
+			if !matched {
+				continue
+			}
+		}
+		if metric.GetGauge() != nil {
+			return metric.GetGauge().GetValue(), true
+		}
+		if metric.GetUntyped() != nil {
+			return metric.GetUntyped().GetValue(), true
+		}
+	}
+	return 0, false
+}
+
+// getSentryHostMetrics scrapes the disk free on the mountpoint and the memory available from the node-exporter
+// metrics of the sentry
+func getSentryHostMetrics(ctx context.Context, metricsURL string, mountpoint string, proxyConfig string) (sentryHostMetrics, error) {
+	metrics := sentryHostMetrics{diskFreePercent: -1, memoryAvailablePercent: -1}
+	client := newProxyHTTPClient(proxyConfig, time.Duration(time.Second*RPCTimeoutSeconds))
+	if client == nil {
+		client = &http.Client{Timeout: time.Duration(time.Second * RPCTimeoutSeconds)}
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, metricsURL, nil)
+	if err != nil {
+		return metrics, err
+	}
+	res, err := client.Do(req)
+	if err != nil {
+		return metrics, err
+	}
+	defer res.Body.Close()
+	if res.StatusCode != http.StatusOK {
+		return metrics, fmt.Errorf("unexpected response: %s", res.Status)
+	}
+	var parser expfmt.TextParser
+	families, err := parser.TextToMetricFamilies(res.Body)
+	if err != nil {
+		return metrics, fmt.Errorf("error parsing metrics: %w", err)
+	}
+	diskAvailable, okAvailable := getGaugeValue(families, "node_filesystem_avail_bytes", "mountpoint", mountpoint)
+	diskSize, okSize := getGaugeValue(families, "node_filesystem_size_bytes", "mountpoint", mountpoint)
+	if okAvailable && okSize && diskSize > 0 {
+		metrics.diskFreePercent = diskAvailable / diskSize * 100
+	}
+	memoryAvailable, okAvailable := getGaugeValue(families, "node_memory_MemAvailable_bytes", "", "")
+	memoryTotal, okTotal := getGaugeValue(families, "node_memory_MemTotal_bytes", "", "")
+	if okAvailable && okTotal && memoryTotal > 0 {
+		metrics.memoryAvailablePercent = memoryAvailable / memoryTotal * 100
+	}
+	return metrics, nil
+}
+
+// checkSentryHost returns an error when the sentry's disk free or memory available is below its minimum.
+// Metrics that can't be scraped are logged rather than alerted, so they don't mask the consensus alerts.
+func checkSentryHost(ctx context.Context, config *HalfLifeConfig, vm *ValidatorMonitor, sentry Sentry) error {
+	mountpoint := defaultSentryDiskMountpoint
+	if sentry.DiskMountpoint != "" {
+		mountpoint = sentry.DiskMountpoint
+	}
+	metrics, err := getSentryHostMetrics(ctx, sentry.NodeExporterURL, mountpoint, getProxy(config, vm))
+	if err != nil {
+		fmt.Printf("Error fetching host metrics for sentry %s: %s\n", sentry.Name, redactError(err))
+		return nil
+	}
+	minDiskFree := defaultSentryMinDiskFreePercent
+	if sentry.MinDiskFreePercent != nil {
+		minDiskFree = *sentry.MinDiskFreePercent
+	}
+	minMemoryAvailable := defaultSentryMinMemoryAvailablePercent
+	if sentry.MinMemoryAvailablePercent != nil {
+		minMemoryAvailable = *sentry.MinMemoryAvailablePercent
+	}
+	var problems []string
+	if metrics.diskFreePercent < 0 {
+		fmt.Printf("No disk metrics for mountpoint %s of sentry %s\n", mountpoint, sentry.Name)
+	} else if metrics.diskFreePercent < minDiskFree {
+		problems = append(problems, fmt.Sprintf("disk free %.01f%% on %s (minimum %.01f%%)", metrics.diskFreePercent, mountpoint, minDiskFree))
+	}
+	if metrics.memoryAvailablePercent < 0 {
+		fmt.Printf("No memory metrics for sentry %s\n", sentry.Name)
+	} else if metrics.memoryAvailablePercent < minMemoryAvailable {
+		problems = append(problems, fmt.Sprintf("memory available %.01f%% (minimum %.01f%%)", metrics.memoryAvailablePercent, minMemoryAvailable))
+	}
+	if len(problems) == 0 {
+		return nil
+	}
+	return newSentryHostError(sentry.Name, problems)
+}
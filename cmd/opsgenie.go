@@ -0,0 +1,221 @@
+package cmd
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	opsgenieHTTPTimeout   = 20 * time.Second
+	opsgenieSource        = "halflife"
+	opsgenieMessageLimit  = 130
+	opsgenieAliasLimit    = 512
+	opsgenieRegionEU      = "eu"
+	opsgenieAPIURL        = "https://api.opsgenie.com"
+	opsgenieEUAPIURL      = "https://api.eu.opsgenie.com"
+	opsgenieOtherAlertKey = "other"
+)
+
+var opsgeniePriorities = map[AlertLevel]string{
+	alertLevelNone:     "P5",
+	alertLevelWarning:  "P3",
+	alertLevelHigh:     "P2",
+	alertLevelCritical: "P1",
+}
+
+// OpsgenieNotificationService creates an Opsgenie alert for each validator alert, and closes it when the alert clears.
+// Opsgenie has no status message, so status updates are ignored.
+type OpsgenieNotificationService struct {
+	apiKey     string
+	apiURL     string
+	verbosity  Verbosity
+	httpClient *http.Client
+}
+
+func NewOpsgenieNotificationService(config OpsgenieConfig, httpClient *http.Client) *OpsgenieNotificationService {
+	if httpClient == nil {
+		httpClient = &http.Client{Timeout: opsgenieHTTPTimeout}
+	}
+	apiURL := opsgenieAPIURL
+	if strings.EqualFold(config.Region, opsgenieRegionEU) {
+		apiURL = opsgenieEUAPIURL
+	}
+	return &OpsgenieNotificationService{
+		apiKey:     config.APIKey,
+		apiURL:     apiURL,
+		verbosity:  getVerbosity(config.Verbosity),
+		httpClient: httpClient,
+	}
+}
+
+type opsgenieAlert struct {
+	Message     string            `json:"message"`
+	Alias       string            `json:"alias"`
+	Description string            `json:"description,omitempty"`
+	Priority    string            `json:"priority"`
+	Source      string            `json:"source"`
+	Tags        []string          `json:"tags,omitempty"`
+	Details     map[string]string `json:"details,omitempty"`
+}
+
+type opsgenieClose struct {
+	Source string `json:"source"`
+	Note   string `json:"note,omitempty"`
+}
+
+// getOpsgenieAlias identifies the alert in Opsgenie by validator and alert type, and sentry for sentry alerts,
+// so that repeats of an alert are de-duplicated and the alert can be closed when it clears
+func getOpsgenieAlias(vm *ValidatorMonitor, key AlertKey) string {
+	alertType := string(key.AlertType)
+	if alertType == "" {
+		alertType = opsgenieOtherAlertKey
+	}
+	alias := fmt.Sprintf("halflife-%s-%s", vm.Name, alertType)
+	if key.Sentry != "" {
+		alias += "-" + key.Sentry
+	}
+	if len(alias) > opsgenieAliasLimit {
+		alias = alias[:opsgenieAliasLimit]
+	}
+	return alias
+}
+
+func truncateOpsgenieMessage(message string) string {
+	if len(message) <= opsgenieMessageLimit {
+		return message
+	}
+	return message[:opsgenieMessageLimit-3] + "..."
+}
+
+func (service *OpsgenieNotificationService) request(method string, path string, body interface{}) error {
+	var reqBody io.Reader
+	if body != nil {
+		buf := &bytes.Buffer{}
+		if err := json.NewEncoder(buf).Encode(body); err != nil {
+			return err
+		}
+		reqBody = buf
+	}
+	req, err := http.NewRequest(method, service.apiURL+path, reqBody)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Authorization", "GenieKey "+service.apiKey)
+	req.Header.Set("Content-Type", "application/json")
+	res, err := service.httpClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer res.Body.Close()
+	if res.StatusCode < 200 || res.StatusCode >= 300 {
+		var opsgenieErr struct {
+			Message string `json:"message"`
+		}
+		_ = json.NewDecoder(res.Body).Decode(&opsgenieErr)
+		return &opsgenieStatusError{status: res.StatusCode, msg: fmt.Sprintf("opsgenie request failed: %s %s", res.Status, opsgenieErr.Message)}
+	}
+	return nil
+}
+
+type opsgenieStatusError struct {
+	status int
+	msg    string
+}
+
+func (e *opsgenieStatusError) Error() string { return e.msg }
+
+// implements NotificationService interface
+func (service *OpsgenieNotificationService) CheckReachability() error {
+	err := service.request(http.MethodGet, "/v2/alerts?limit=1", nil)
+	var statusErr *opsgenieStatusError
+	if errors.As(err, &statusErr) && statusErr.status == http.StatusForbidden {
+		// the key was accepted but is not allowed to read alerts, which is not needed to create them
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("opsgenie could not be reached or api key was rejected: %w", err)
+	}
+	return nil
+}
+
+// implements NotificationService interface
+func (service *OpsgenieNotificationService) UpdateValidatorRealtimeStatus(
+	configFile string,
+	config *HalfLifeConfig,
+	vm *ValidatorMonitor,
+	stats ValidatorStats,
+	writeConfigMutex *sync.Mutex,
+) error {
+	return nil
+}
+
+// implements NotificationService interface
+func (service *OpsgenieNotificationService) SendValidatorAlertNotification(
+	config *HalfLifeConfig,
+	vm *ValidatorMonitor,
+	stats ValidatorStats,
+	alertNotification *ValidatorAlertNotification,
+) error {
+	var errs []string
+	for i, alert := range alertNotification.Alerts {
+		var key AlertKey
+		if i < len(alertNotification.AlertKeys) {
+			key = alertNotification.AlertKeys[i]
+		}
+		details := map[string]string{
+			"validator": vm.Name,
+			"chain-id":  vm.ChainID,
+			"height":    fmt.Sprint(stats.Height),
+			"dedup-key": key.dedupKey(vm),
+		}
+		alertLevel := alertNotification.AlertLevel
+		if i < len(alertNotification.AlertLevels) {
+			alertLevel = alertNotification.AlertLevels[i]
+		}
+		// minimal alerts are only the one line message, detailed alerts add the validator stats to the description
+		description := alert
+		switch service.verbosity {
+		case verbosityMinimal:
+			description = ""
+		case verbosityDetailed:
+			description += "\n\n" + strings.Join(getStatsDetails(vm, stats), "\n")
+		}
+		err := service.request(http.MethodPost, "/v2/alerts", opsgenieAlert{
+			Message:     truncateOpsgenieMessage(fmt.Sprintf("%s: %s", vm.Name, alert)),
+			Alias:       getOpsgenieAlias(vm, key),
+			Description: description,
+			Priority:    opsgeniePriorities[alertLevel],
+			Source:      opsgenieSource,
+			Tags:        []string{vm.ChainID},
+			Details:     details,
+		})
+		if err != nil {
+			errs = append(errs, fmt.Sprintf("error creating opsgenie alert: %v", err))
+		}
+	}
+	for i, clearedAlert := range alertNotification.ClearedAlerts {
+		if i >= len(alertNotification.ClearedAlertKeys) {
+			break
+		}
+		alias := getOpsgenieAlias(vm, alertNotification.ClearedAlertKeys[i])
+		err := service.request(http.MethodPost, fmt.Sprintf("/v2/alerts/%s/close?identifierType=alias", url.PathEscape(alias)), opsgenieClose{
+			Source: opsgenieSource,
+			Note:   fmt.Sprintf("cleared: %s", clearedAlert),
+		})
+		if err != nil {
+			errs = append(errs, fmt.Sprintf("error closing opsgenie alert: %v", err))
+		}
+	}
+	if len(errs) > 0 {
+		return errors.New(strings.Join(errs, ", "))
+	}
+	return nil
+}
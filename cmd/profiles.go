@@ -0,0 +1,140 @@
+package cmd
+
+import (
+	"bytes"
+	"fmt"
+
+	"gopkg.in/yaml.v2"
+)
+
+// hasProfileReferences returns whether any validator references a profile
+func (c *HalfLifeConfig) hasProfileReferences() bool {
+	for _, vm := range c.Validators {
+		if vm.Profile != "" {
+			return true
+		}
+	}
+	return false
+}
+
+// applyProfiles merges the profile of each validator in the config yaml under the validator's own settings. A setting
+// on the validator replaces the profile's value for that setting as a whole, e.g. a validator's quiet-hours replaces
+// the profile's. Profiles are applied before the defaults, so the defaults only fill settings neither sets.
+func (c *HalfLifeConfig) applyProfiles(dat []byte) error {
+	var raw struct {
+		Validators []yaml.MapSlice `yaml:"validators"`
+	}
+	if err := yaml.Unmarshal(dat, &raw); err != nil {
+		return err
+	}
+	if len(raw.Validators) != len(c.Validators) {
+		return nil
+	}
+	for idx, vm := range c.Validators {
+		if vm.Profile == "" {
+			continue
+		}
+		profile, ok := c.Profiles[vm.Profile]
+		if !ok {
+			return fmt.Errorf("validator %s: unknown profile %s", vm.Name, vm.Profile)
+		}
+		// settings saved as null, e.g. by an earlier save of the config, are unset and so come from the profile
+		merged := yaml.MapSlice{}
+		for _, item := range raw.Validators[idx] {
+			if item.Value != nil {
+				merged = append(merged, item)
+			}
+		}
+		var profileKeys []string
+		for _, item := range profile {
+			key := fmt.Sprint(item.Key)
+			if _, ok := getMapSliceValue(merged, item.Key); ok || key == "name" || key == "profile" {
+				continue
+			}
+			merged = append(merged, item)
+			profileKeys = append(profileKeys, key)
+		}
+		mergedDat, err := yaml.Marshal(merged)
+		if err != nil {
+			return err
+		}
+		resolved := ValidatorMonitor{}
+		if err := yaml.Unmarshal(mergedDat, &resolved); err != nil {
+			return fmt.Errorf("validator %s: error applying profile %s: %w", vm.Name, vm.Profile, err)
+		}
+		resolved.profileKeys = profileKeys
+		*vm = resolved
+	}
+	return nil
+}
+
+// marshalMapSlice marshals the value to yaml and back, in the form settings are saved in
+func marshalMapSlice(value interface{}) (yaml.MapSlice, error) {
+	dat, err := yaml.Marshal(value)
+	if err != nil {
+		return nil, err
+	}
+	var m yaml.MapSlice
+	return m, yaml.Unmarshal(dat, &m)
+}
+
+// getSavedProfile returns the profile's settings in the form they are saved in, e.g. a notify_every of 10m as checks
+func (c *HalfLifeConfig) getSavedProfile(name string) (yaml.MapSlice, error) {
+	dat, err := yaml.Marshal(c.Profiles[name])
+	if err != nil {
+		return nil, err
+	}
+	profile := ValidatorMonitor{}
+	if err := yaml.Unmarshal(dat, &profile); err != nil {
+		return nil, err
+	}
+	return marshalMapSlice(&profile)
+}
+
+// stripProfiles removes the settings provided by each validator's profile from the saved config yaml, so that
+// saving the config keeps it as short as it was written. Settings that no longer match the profile are kept.
+func (c *HalfLifeConfig) stripProfiles(saved yaml.MapSlice) yaml.MapSlice {
+	i, ok := getMapSliceValue(saved, "validators")
+	if !ok {
+		return saved
+	}
+	validators, ok := saved[i].Value.([]interface{})
+	if !ok || len(validators) != len(c.Validators) {
+		return saved
+	}
+	for idx, vm := range c.Validators {
+		entry, ok := validators[idx].(yaml.MapSlice)
+		if !ok || len(vm.profileKeys) == 0 {
+			continue
+		}
+		profile, err := c.getSavedProfile(vm.Profile)
+		if err != nil {
+			continue
+		}
+		stripped := yaml.MapSlice{}
+		for _, item := range entry {
+			if j, ok := getMapSliceValue(profile, item.Key); ok && isProfileKey(vm, item.Key) && sameYAML(item.Value, profile[j].Value) {
+				continue
+			}
+			stripped = append(stripped, item)
+		}
+		validators[idx] = stripped
+	}
+	return saved
+}
+
+func isProfileKey(vm *ValidatorMonitor, key interface{}) bool {
+	for _, profileKey := range vm.profileKeys {
+		if profileKey == fmt.Sprint(key) {
+			return true
+		}
+	}
+	return false
+}
+
+// sameYAML compares settings by their yaml, since a profile's value is saved in the form it was parsed into
+func sameYAML(a interface{}, b interface{}) bool {
+	aDat, aErr := yaml.Marshal(a)
+	bDat, bErr := yaml.Marshal(b)
+	return aErr == nil && bErr == nil && bytes.Equal(aDat, bDat)
+}
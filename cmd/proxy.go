@@ -0,0 +1,188 @@
+package cmd
+
+import (
+	"bufio"
+	"context"
+	"crypto/tls"
+	"encoding/base64"
+	"fmt"
+	"net"
+	"net/http"
+	"net/url"
+	"time"
+
+	"golang.org/x/net/proxy"
+	"google.golang.org/grpc"
+)
+
+const (
+	proxyFromEnvironment = "env"  // use HTTP_PROXY, HTTPS_PROXY and NO_PROXY for all connections
+	proxyNone            = "none" // connect directly
+
+	discordHTTPTimeout = 20 * time.Second
+)
+
+// validateProxy checks that the proxy is env, none, or an http, https or socks5 URL
+func validateProxy(proxyConfig string) error {
+	switch proxyConfig {
+	case "", proxyFromEnvironment, proxyNone:
+		return nil
+	}
+	proxyURL, err := url.Parse(proxyConfig)
+	if err != nil {
+		return fmt.Errorf("invalid proxy %s: %w", proxyConfig, err)
+	}
+	switch proxyURL.Scheme {
+	case "http", "https", "socks5", "socks5h":
+	default:
+		return fmt.Errorf("unsupported proxy scheme %q, use http, https, or socks5", proxyURL.Scheme)
+	}
+	if proxyURL.Host == "" {
+		return fmt.Errorf("invalid proxy %s: missing host", proxyConfig)
+	}
+	return nil
+}
+
+// getRPCTLS returns the TLS settings for the validator's https RPC endpoints, falling back to the global settings.
+// nil uses the system roots.
+func getRPCTLS(config *HalfLifeConfig, vm *ValidatorMonitor) *RPCTLSConfig {
+	if vm.RPCTLS != nil {
+		return vm.RPCTLS
+	}
+	return config.RPCTLS
+}
+
+// getProxy returns the proxy for the validator, falling back to the global proxy
+func getProxy(config *HalfLifeConfig, vm *ValidatorMonitor) string {
+	if vm.Proxy != nil {
+		return *vm.Proxy
+	}
+	return config.Proxy
+}
+
+// httpProxyFunc returns the proxy selection for an http.Transport
+func httpProxyFunc(proxyConfig string) func(*http.Request) (*url.URL, error) {
+	switch proxyConfig {
+	case "", proxyNone:
+		return nil
+	case proxyFromEnvironment:
+		return http.ProxyFromEnvironment
+	}
+	proxyURL, _ := url.Parse(proxyConfig)
+	return http.ProxyURL(proxyURL)
+}
+
+// newProxyHTTPClient returns an http client using the proxy, or nil to use the default client when no proxy is configured
+func newProxyHTTPClient(proxyConfig string, timeout time.Duration) *http.Client {
+	if proxyConfig == "" {
+		return nil
+	}
+	return &http.Client{
+		Timeout: timeout,
+		Transport: &http.Transport{
+			// Set to true to prevent GZIP-bomb DoS attacks
+			DisableCompression: true,
+			Proxy:              httpProxyFunc(proxyConfig),
+		},
+	}
+}
+
+// grpcProxyDialOption returns the dial option to connect through the proxy, or nil for grpc's default
+// behavior, which uses the proxy environment variables
+func grpcProxyDialOption(proxyConfig string) grpc.DialOption {
+	switch proxyConfig {
+	case "", proxyFromEnvironment:
+		return nil
+	case proxyNone:
+		return grpc.WithContextDialer(func(ctx context.Context, addr string) (net.Conn, error) {
+			var dialer net.Dialer
+			return dialer.DialContext(ctx, "tcp", addr)
+		})
+	}
+	proxyURL, _ := url.Parse(proxyConfig)
+	return grpc.WithContextDialer(func(ctx context.Context, addr string) (net.Conn, error) {
+		return dialProxy(ctx, proxyURL, addr)
+	})
+}
+
+func proxyHostPort(proxyURL *url.URL) string {
+	if proxyURL.Port() != "" {
+		return proxyURL.Host
+	}
+	switch proxyURL.Scheme {
+	case "https":
+		return net.JoinHostPort(proxyURL.Hostname(), "443")
+	case "socks5", "socks5h":
+		return net.JoinHostPort(proxyURL.Hostname(), "1080")
+	default:
+		return net.JoinHostPort(proxyURL.Hostname(), "80")
+	}
+}
+
+// dialProxy opens a tcp connection to addr through a socks5 proxy, or an http proxy with CONNECT
+func dialProxy(ctx context.Context, proxyURL *url.URL, addr string) (net.Conn, error) {
+	if proxyURL.Scheme == "socks5" || proxyURL.Scheme == "socks5h" {
+		var auth *proxy.Auth
+		if proxyURL.User != nil {
+			password, _ := proxyURL.User.Password()
+			auth = &proxy.Auth{User: proxyURL.User.Username(), Password: password}
+		}
+		dialer, err := proxy.SOCKS5("tcp", proxyHostPort(proxyURL), auth, &net.Dialer{})
+		if err != nil {
+			return nil, err
+		}
+		return dialer.(proxy.ContextDialer).DialContext(ctx, "tcp", addr)
+	}
+
+	var dialer net.Dialer
+	conn, err := dialer.DialContext(ctx, "tcp", proxyHostPort(proxyURL))
+	if err != nil {
+		return nil, err
+	}
+	if proxyURL.Scheme == "https" {
+		conn = tls.Client(conn, &tls.Config{ServerName: proxyURL.Hostname()})
+	}
+	if deadline, ok := ctx.Deadline(); ok {
+		_ = conn.SetDeadline(deadline)
+		defer conn.SetDeadline(time.Time{})
+	}
+
+	req := &http.Request{
+		Method: http.MethodConnect,
+		URL:    &url.URL{Opaque: addr},
+		Host:   addr,
+		Header: make(http.Header),
+	}
+	if proxyURL.User != nil {
+		password, _ := proxyURL.User.Password()
+		credentials := base64.StdEncoding.EncodeToString([]byte(proxyURL.User.Username() + ":" + password))
+		req.Header.Set("Proxy-Authorization", "Basic "+credentials)
+	}
+	if err := req.Write(conn); err != nil {
+		conn.Close()
+		return nil, err
+	}
+	reader := bufio.NewReader(conn)
+	resp, err := http.ReadResponse(reader, req)
+	if err != nil {
+		conn.Close()
+		return nil, err
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		conn.Close()
+		return nil, fmt.Errorf("proxy CONNECT to %s failed: %s", addr, resp.Status)
+	}
+	return &bufferedConn{Conn: conn, reader: reader}, nil
+}
+
+// bufferedConn reads through the buffer used to read the proxy response,
+// in case the server already sent data after the CONNECT response
+type bufferedConn struct {
+	net.Conn
+	reader *bufio.Reader
+}
+
+func (c *bufferedConn) Read(b []byte) (int, error) {
+	return c.reader.Read(b)
+}
@@ -0,0 +1,183 @@
+package cmd
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	pushoverHTTPTimeout          = 20 * time.Second
+	pushoverAPIURL               = "https://api.pushover.net/1"
+	pushoverTitleLimit           = 250
+	pushoverMessageLimit         = 1024
+	pushoverDefaultCriticalSound = "siren"
+	pushoverOtherAlertType       = "alert"
+
+	// emergency priority notifications repeat every retry until acknowledged, for at most expire
+	pushoverEmergencyRetry  = 60 * time.Second
+	pushoverEmergencyExpire = 3 * time.Hour
+)
+
+const (
+	pushoverPriorityLow       = -1
+	pushoverPriorityNormal    = 0
+	pushoverPriorityHigh      = 1
+	pushoverPriorityEmergency = 2
+)
+
+var pushoverPriorities = map[AlertLevel]int{
+	alertLevelNone:     pushoverPriorityLow,
+	alertLevelWarning:  pushoverPriorityNormal,
+	alertLevelHigh:     pushoverPriorityHigh,
+	alertLevelCritical: pushoverPriorityEmergency,
+}
+
+// PushoverNotificationService sends a push notification for each validator alert.
+// Pushover has no status message, so status updates are ignored.
+type PushoverNotificationService struct {
+	token         string
+	userKey       string
+	criticalSound string
+	apiURL        string
+	verbosity     Verbosity
+	httpClient    *http.Client
+}
+
+func NewPushoverNotificationService(config PushoverConfig, httpClient *http.Client) *PushoverNotificationService {
+	if httpClient == nil {
+		httpClient = &http.Client{Timeout: pushoverHTTPTimeout}
+	}
+	criticalSound := config.CriticalSound
+	if criticalSound == "" {
+		criticalSound = pushoverDefaultCriticalSound
+	}
+	return &PushoverNotificationService{
+		token:         config.Token,
+		userKey:       config.UserKey,
+		criticalSound: criticalSound,
+		apiURL:        pushoverAPIURL,
+		verbosity:     getVerbosity(config.Verbosity),
+		httpClient:    httpClient,
+	}
+}
+
+func truncatePushoverText(text string, limit int) string {
+	if len(text) <= limit {
+		return text
+	}
+	return text[:limit-3] + "..."
+}
+
+func (service *PushoverNotificationService) post(path string, values url.Values) error {
+	values.Set("token", service.token)
+	values.Set("user", service.userKey)
+	res, err := service.httpClient.PostForm(service.apiURL+path, values)
+	if err != nil {
+		return err
+	}
+	defer res.Body.Close()
+	var pushoverRes struct {
+		Status int      `json:"status"`
+		Errors []string `json:"errors"`
+	}
+	_ = json.NewDecoder(res.Body).Decode(&pushoverRes)
+	if res.StatusCode != http.StatusOK || pushoverRes.Status != 1 {
+		return fmt.Errorf("pushover request failed: %s %s", res.Status, strings.Join(pushoverRes.Errors, ", "))
+	}
+	return nil
+}
+
+// send sends a push notification with the priority and sound for the alert level
+func (service *PushoverNotificationService) send(title string, message string, alertLevel AlertLevel) error {
+	priority := pushoverPriorities[alertLevel]
+	values := url.Values{
+		"title":    {truncatePushoverText(title, pushoverTitleLimit)},
+		"message":  {truncatePushoverText(message, pushoverMessageLimit)},
+		"priority": {strconv.Itoa(priority)},
+	}
+	if priority == pushoverPriorityEmergency {
+		values.Set("retry", strconv.Itoa(int(pushoverEmergencyRetry.Seconds())))
+		values.Set("expire", strconv.Itoa(int(pushoverEmergencyExpire.Seconds())))
+		values.Set("sound", service.criticalSound)
+	}
+	return service.post("/messages.json", values)
+}
+
+// getPushoverTitle returns the validator name and alert type, and sentry for sentry alerts
+func getPushoverTitle(vm *ValidatorMonitor, key AlertKey) string {
+	alertType := string(key.AlertType)
+	if alertType == "" {
+		alertType = pushoverOtherAlertType
+	}
+	if key.Sentry != "" {
+		return fmt.Sprintf("%s: %s (%s)", vm.Name, alertType, key.Sentry)
+	}
+	return fmt.Sprintf("%s: %s", vm.Name, alertType)
+}
+
+// implements NotificationService interface
+func (service *PushoverNotificationService) CheckReachability() error {
+	if err := service.post("/users/validate.json", url.Values{}); err != nil {
+		return fmt.Errorf("pushover could not be reached or token or user key was rejected: %w", err)
+	}
+	return nil
+}
+
+// implements NotificationService interface
+func (service *PushoverNotificationService) UpdateValidatorRealtimeStatus(
+	configFile string,
+	config *HalfLifeConfig,
+	vm *ValidatorMonitor,
+	stats ValidatorStats,
+	writeConfigMutex *sync.Mutex,
+) error {
+	return nil
+}
+
+// implements NotificationService interface
+func (service *PushoverNotificationService) SendValidatorAlertNotification(
+	config *HalfLifeConfig,
+	vm *ValidatorMonitor,
+	stats ValidatorStats,
+	alertNotification *ValidatorAlertNotification,
+) error {
+	var errs []string
+	for i, alert := range alertNotification.Alerts {
+		var key AlertKey
+		if i < len(alertNotification.AlertKeys) {
+			key = alertNotification.AlertKeys[i]
+		}
+		alertLevel := alertNotification.AlertLevel
+		if i < len(alertNotification.AlertLevels) {
+			alertLevel = alertNotification.AlertLevels[i]
+		}
+		message := alert
+		if service.verbosity == verbosityDetailed {
+			message += "\n\n" + strings.Join(getStatsDetails(vm, stats), "\n")
+		}
+		if err := service.send(getPushoverTitle(vm, key), message, alertLevel); err != nil {
+			errs = append(errs, fmt.Sprintf("error sending pushover alert: %v", err))
+		}
+	}
+	// cleared alerts are only pushed when they would mention, at low priority so they are silent
+	if alertNotification.NotifyForClear && len(alertNotification.ClearedAlerts) > 0 {
+		message := "Errors cleared:\n• " + strings.Join(alertNotification.ClearedAlerts, "\n• ")
+		if service.verbosity == verbosityMinimal {
+			message = "Cleared: " + getAlertsSummary(alertNotification.ClearedAlerts)
+		}
+		if err := service.send(vm.Name, message, alertLevelNone); err != nil {
+			errs = append(errs, fmt.Sprintf("error sending pushover cleared alerts: %v", err))
+		}
+	}
+	if len(errs) > 0 {
+		return errors.New(strings.Join(errs, ", "))
+	}
+	return nil
+}
@@ -0,0 +1,265 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	cosmosClient "github.com/cosmos/cosmos-sdk/client"
+	querytypes "github.com/cosmos/cosmos-sdk/types/query"
+	slashingtypes "github.com/cosmos/cosmos-sdk/x/slashing/types"
+	stakingtypes "github.com/cosmos/cosmos-sdk/x/staking/types"
+	upgradetypes "github.com/cosmos/cosmos-sdk/x/upgrade/types"
+	rpcclient "github.com/tendermint/tendermint/rpc/client"
+	tmtypes "github.com/tendermint/tendermint/types"
+)
+
+// queryCacheTTL is shorter than the check interval, so that shared data is fetched once per cycle
+const queryCacheTTL = 15 * time.Second
+
+// blockCacheTTL keeps blocks for two check intervals, since validators sharing an RPC check at different times
+// within the interval with start-jitter, and blocks never change once they are committed
+const blockCacheTTL = 2 * checkInterval
+
+const (
+	querySlashingParams    = "slashing-params"
+	queryStakingValidators = "staking-validators"
+	queryUpgradePlan       = "upgrade-plan"
+	querySigningInfos      = "signing-infos"
+)
+
+// queryCacheKey identifies a query that returns the same result for every validator on the chain
+type queryCacheKey struct {
+	chainID string
+	rpc     string
+	query   string
+}
+
+type queryCacheEntry struct {
+	done             chan struct{} // closed once the fetch completes
+	value            interface{}
+	err              error
+	fetched          time.Time
+	validUntilHeight int64 // the value is refetched once the chain reaches this height, 0 when it does not depend on height
+
+	ttl time.Duration
+}
+
+// queryCache shares the results of chain-wide queries, e.g. slashing params, across validators on the same chain
+// and RPC. Concurrent requests for the same query wait for a single fetch, and failed fetches are not cached.
+type queryCache struct {
+	ttl     time.Duration
+	lock    sync.Mutex
+	entries map[queryCacheKey]*queryCacheEntry
+}
+
+var chainQueries = queryCache{ttl: queryCacheTTL, entries: make(map[queryCacheKey]*queryCacheEntry)}
+
+// chainBlocks shares the blocks fetched by the recent blocks scan across validators on the same chain and RPC
+var chainBlocks = queryCache{ttl: blockCacheTTL, entries: make(map[queryCacheKey]*queryCacheEntry)}
+
+// get returns the cached result of the query at the height, fetching it when it is missing, expired, or no longer
+// valid at the height. The fetch returns the height the value is valid until, or 0. Waiting for the fetch of another
+// validator ends when the context is done.
+func (cache *queryCache) get(ctx context.Context, key queryCacheKey, height int64, fetch func() (interface{}, int64, error)) (interface{}, error) {
+	return cache.getWithTTL(ctx, key, height, cache.ttl, fetch)
+}
+
+// getWithTTL is get for a query that is kept for the ttl in place of the cache TTL
+func (cache *queryCache) getWithTTL(ctx context.Context, key queryCacheKey, height int64, ttl time.Duration, fetch func() (interface{}, int64, error)) (interface{}, error) {
+	cache.lock.Lock()
+	entry, ok := cache.entries[key]
+	if ok {
+		select {
+		case <-entry.done:
+			if entry.err != nil || time.Since(entry.fetched) > entry.ttl || (entry.validUntilHeight > 0 && height >= entry.validUntilHeight) {
+				ok = false
+			}
+		default:
+			// another validator is fetching it
+		}
+	}
+	if !ok {
+		cache.prune()
+		entry = &queryCacheEntry{done: make(chan struct{}), ttl: ttl}
+		cache.entries[key] = entry
+		cache.lock.Unlock()
+		entry.value, entry.validUntilHeight, entry.err = fetch()
+		entry.fetched = time.Now()
+		close(entry.done)
+		return entry.value, entry.err
+	}
+	cache.lock.Unlock()
+	select {
+	case <-entry.done:
+		return entry.value, entry.err
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+}
+
+// prune removes the expired entries, so that queries that are not repeated, e.g. blocks, don't accumulate.
+// requires locked cache
+func (cache *queryCache) prune() {
+	for key, entry := range cache.entries {
+		select {
+		case <-entry.done:
+			if time.Since(entry.fetched) > entry.ttl {
+				delete(cache.entries, key)
+			}
+		default:
+		}
+	}
+}
+
+// sharedRPCs holds the chain and RPC of validators that are monitored through the same RPC as another validator.
+// Their signing infos and recent blocks are fetched once and shared, instead of once for each validator.
+var sharedRPCs = sharedRPCRegistry{shared: make(map[queryCacheKey]bool)}
+
+type sharedRPCRegistry struct {
+	lock   sync.Mutex
+	shared map[queryCacheKey]bool
+}
+
+func (registry *sharedRPCRegistry) register(validators []*ValidatorMonitor) {
+	counts := make(map[queryCacheKey]int)
+	for _, vm := range validators {
+		if !vm.FullNode {
+			counts[queryCacheKey{chainID: vm.ChainID, rpc: vm.RPC}]++
+		}
+	}
+	registry.lock.Lock()
+	defer registry.lock.Unlock()
+	for key, count := range counts {
+		if count > 1 {
+			registry.shared[key] = true
+			fmt.Printf("Sharing blocks and signing infos of %d validators on %s through %s\n", count, key.chainID, key.rpc)
+		}
+	}
+}
+
+func (registry *sharedRPCRegistry) has(vm *ValidatorMonitor) bool {
+	registry.lock.Lock()
+	defer registry.lock.Unlock()
+	return registry.shared[queryCacheKey{chainID: vm.ChainID, rpc: vm.RPC}]
+}
+
+// getCachedSigningInfo returns the signing info of the validator. When other validators share the RPC, the signing
+// infos of all validators are fetched once and shared, falling back to querying the validator when it is not listed.
+func getCachedSigningInfo(ctx context.Context, client *cosmosClient.Context, vm *ValidatorMonitor) (*slashingtypes.QuerySigningInfoResponse, error) {
+	if !sharedRPCs.has(vm) {
+		return getSigningInfo(ctx, client, vm.Address)
+	}
+	value, err := chainQueries.get(ctx, queryCacheKey{vm.ChainID, vm.RPC, querySigningInfos}, 0, func() (interface{}, int64, error) {
+		signingInfos, err := getSigningInfos(ctx, client)
+		return signingInfos, 0, err
+	})
+	if err != nil {
+		return nil, err
+	}
+	if signingInfo, ok := value.(map[string]slashingtypes.ValidatorSigningInfo)[vm.Address]; ok {
+		return &slashingtypes.QuerySigningInfoResponse{ValSigningInfo: signingInfo}, nil
+	}
+	return getSigningInfo(ctx, client, vm.Address)
+}
+
+// getSigningInfos returns the signing infos of all validators by consensus address
+func getSigningInfos(ctx context.Context, client *cosmosClient.Context) (map[string]slashingtypes.ValidatorSigningInfo, error) {
+	signingInfos := make(map[string]slashingtypes.ValidatorSigningInfo)
+	var nextKey []byte
+	for {
+		pageCtx, cancel := context.WithTimeout(ctx, time.Duration(time.Second*RPCTimeoutSeconds))
+		res, err := slashingtypes.NewQueryClient(client).SigningInfos(pageCtx, &slashingtypes.QuerySigningInfosRequest{
+			Pagination: &querytypes.PageRequest{Key: nextKey, Limit: validatorsPageLimit},
+		})
+		cancel()
+		if err != nil {
+			return nil, err
+		}
+		for _, info := range res.Info {
+			signingInfos[info.Address] = info
+		}
+		if res.Pagination == nil || len(res.Pagination.NextKey) == 0 {
+			return signingInfos, nil
+		}
+		nextKey = res.Pagination.NextKey
+	}
+}
+
+// getCachedBlock returns the block at the height. When other validators share the RPC, each block is fetched once
+// and shared for the signing check of every validator.
+func getCachedBlock(ctx context.Context, node rpcclient.Client, vm *ValidatorMonitor, height int64) (*tmtypes.Block, error) {
+	if !sharedRPCs.has(vm) {
+		block, err := node.Block(ctx, &height)
+		if err != nil {
+			return nil, err
+		}
+		return block.Block, nil
+	}
+	value, err := chainBlocks.get(ctx, queryCacheKey{vm.ChainID, vm.RPC, fmt.Sprintf("block-%d", height)}, 0, func() (interface{}, int64, error) {
+		block, err := node.Block(ctx, &height)
+		if err != nil {
+			return nil, 0, err
+		}
+		// only the header, last commit, and evidence are checked, so the transactions are not kept
+		block.Block.Data.Txs = nil
+		return block.Block, 0, nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return value.(*tmtypes.Block), nil
+}
+
+// getMetadataRefreshInterval returns how long the results of the slower moving module queries, the slashing params,
+// the staking validators, and the upgrade plan, are kept between refreshes
+func getMetadataRefreshInterval(config *HalfLifeConfig) time.Duration {
+	if config.MetadataRefreshInterval != nil && *config.MetadataRefreshInterval > queryCacheTTL {
+		return *config.MetadataRefreshInterval
+	}
+	return queryCacheTTL
+}
+
+func getCachedSlashingParams(ctx context.Context, config *HalfLifeConfig, client *cosmosClient.Context, vm *ValidatorMonitor) (*slashingtypes.QueryParamsResponse, error) {
+	key := queryCacheKey{vm.ChainID, vm.RPC, querySlashingParams}
+	value, err := chainQueries.getWithTTL(ctx, key, 0, getMetadataRefreshInterval(config), func() (interface{}, int64, error) {
+		res, err := getSlashingInfo(ctx, client)
+		return res, 0, err
+	})
+	if err != nil {
+		return nil, err
+	}
+	return value.(*slashingtypes.QueryParamsResponse), nil
+}
+
+// getCachedStakingValidators returns the staking validators. Tokens and bond statuses may be up to the metadata
+// refresh interval old, which jailing does not depend on since it is checked from the signing info every check.
+func getCachedStakingValidators(ctx context.Context, config *HalfLifeConfig, client *cosmosClient.Context, vm *ValidatorMonitor) (stakingtypes.Validators, error) {
+	key := queryCacheKey{vm.ChainID, vm.RPC, queryStakingValidators}
+	value, err := chainQueries.getWithTTL(ctx, key, 0, getMetadataRefreshInterval(config), func() (interface{}, int64, error) {
+		validators, err := getStakingValidators(ctx, client)
+		return validators, 0, err
+	})
+	if err != nil {
+		return nil, err
+	}
+	return value.(stakingtypes.Validators), nil
+}
+
+// getCachedUpgradePlan returns the upgrade plan at the height. A cached plan is refetched once its upgrade height is
+// reached, since the plan is cleared by the upgrade.
+func getCachedUpgradePlan(ctx context.Context, config *HalfLifeConfig, client *cosmosClient.Context, vm *ValidatorMonitor, height int64) (*upgradetypes.Plan, error) {
+	key := queryCacheKey{vm.ChainID, vm.RPC, queryUpgradePlan}
+	value, err := chainQueries.getWithTTL(ctx, key, height, getMetadataRefreshInterval(config), func() (interface{}, int64, error) {
+		plan, err := getUpgradePlan(ctx, client)
+		if err != nil || plan == nil {
+			return plan, 0, err
+		}
+		return plan, plan.Height, nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return value.(*upgradetypes.Plan), nil
+}
@@ -0,0 +1,142 @@
+package cmd
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+var weekdayNames = map[string]time.Weekday{
+	"sun": time.Sunday,
+	"mon": time.Monday,
+	"tue": time.Tuesday,
+	"wed": time.Wednesday,
+	"thu": time.Thursday,
+	"fri": time.Friday,
+	"sat": time.Saturday,
+}
+
+// parseTimeOfDay parses HH:MM to the duration since midnight
+func parseTimeOfDay(value string) (time.Duration, error) {
+	t, err := time.Parse("15:04", value)
+	if err != nil {
+		return 0, fmt.Errorf("invalid time of day %q, expected HH:MM", value)
+	}
+	return time.Duration(t.Hour())*time.Hour + time.Duration(t.Minute())*time.Minute, nil
+}
+
+func (q *QuietHoursConfig) location() (*time.Location, error) {
+	if q.Timezone == "" {
+		return time.Local, nil
+	}
+	return time.LoadLocation(q.Timezone)
+}
+
+func (q *QuietHoursConfig) validate() error {
+	start, err := parseTimeOfDay(q.Start)
+	if err != nil {
+		return fmt.Errorf("quiet-hours start: %w", err)
+	}
+	end, err := parseTimeOfDay(q.End)
+	if err != nil {
+		return fmt.Errorf("quiet-hours end: %w", err)
+	}
+	if start == end {
+		return fmt.Errorf("quiet-hours start and end are both %s", q.Start)
+	}
+	if _, err := q.location(); err != nil {
+		return fmt.Errorf("quiet-hours timezone: %w", err)
+	}
+	for _, weekday := range q.Weekdays {
+		if _, ok := weekdayNames[strings.ToLower(weekday)]; !ok {
+			return fmt.Errorf("quiet-hours weekday %q, expected one of sun, mon, tue, wed, thu, fri, sat", weekday)
+		}
+	}
+	return nil
+}
+
+// onWeekday returns true if quiet hours start on the weekday, every day when no weekdays are set
+func (q *QuietHoursConfig) onWeekday(weekday time.Weekday) bool {
+	if len(q.Weekdays) == 0 {
+		return true
+	}
+	for _, name := range q.Weekdays {
+		if weekdayNames[strings.ToLower(name)] == weekday {
+			return true
+		}
+	}
+	return false
+}
+
+// active returns true if the time is within quiet hours. Quiet hours that end before they start run over midnight,
+// and belong to the weekday they start on.
+func (q *QuietHoursConfig) active(now time.Time) bool {
+	start, err := parseTimeOfDay(q.Start)
+	if err != nil {
+		return false
+	}
+	end, err := parseTimeOfDay(q.End)
+	if err != nil {
+		return false
+	}
+	location, err := q.location()
+	if err != nil {
+		return false
+	}
+	now = now.In(location)
+	sinceMidnight := time.Duration(now.Hour())*time.Hour + time.Duration(now.Minute())*time.Minute
+	if start < end {
+		return sinceMidnight >= start && sinceMidnight < end && q.onWeekday(now.Weekday())
+	}
+	if sinceMidnight >= start {
+		return q.onWeekday(now.Weekday())
+	}
+	return sinceMidnight < end && q.onWeekday(now.AddDate(0, 0, -1).Weekday())
+}
+
+// getQuietHours returns the quiet hours of the validator, preferring the validator setting over the global
+// notifications setting, or nil when there are none
+func getQuietHours(config *HalfLifeConfig, vm *ValidatorMonitor) *QuietHoursConfig {
+	if vm.QuietHours != nil {
+		return vm.QuietHours
+	}
+	if config.Notifications != nil {
+		return config.Notifications.QuietHours
+	}
+	return nil
+}
+
+// filterQuietHours drops the notification during quiet hours, keeping only critical alerts and clears when they are
+// allowed. Returns nil if nothing is left to notify.
+func (n *ValidatorAlertNotification) filterQuietHours(quietHours *QuietHoursConfig, now time.Time) *ValidatorAlertNotification {
+	if n == nil || quietHours == nil || !quietHours.active(now) {
+		return n
+	}
+	if !quietHours.AllowCritical {
+		return nil
+	}
+	filtered := *n
+	filtered.Alerts, filtered.AlertKeys, filtered.AlertLevels = nil, nil, nil
+	for i, alert := range n.Alerts {
+		alertLevel := n.AlertLevel
+		if i < len(n.AlertLevels) {
+			alertLevel = n.AlertLevels[i]
+		}
+		if alertLevel < alertLevelCritical {
+			continue
+		}
+		filtered.Alerts = append(filtered.Alerts, alert)
+		if i < len(n.AlertKeys) {
+			filtered.AlertKeys = append(filtered.AlertKeys, n.AlertKeys[i])
+		}
+		filtered.AlertLevels = append(filtered.AlertLevels, alertLevel)
+	}
+	if filtered.ClearedAlertLevel < alertLevelCritical {
+		filtered.ClearedAlerts = nil
+		filtered.ClearedAlertKeys = nil
+	}
+	if len(filtered.Alerts) == 0 && len(filtered.ClearedAlerts) == 0 {
+		return nil
+	}
+	return &filtered
+}
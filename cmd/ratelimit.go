@@ -0,0 +1,182 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	discordRateLimitMaxRetries = 3
+	discordRequestTimeout      = 4 * time.Second // from when the call gets its turn, not while it is queued
+)
+
+type discordPriority int
+
+const (
+	discordPriorityStatus discordPriority = iota
+	discordPriorityAlert
+)
+
+// discordRateLimiter paces all Discord webhook calls, honoring the X-RateLimit-* and Retry-After headers of
+// prior responses. Calls are made one at a time, and alert sends go ahead of queued status edits.
+type discordRateLimiter struct {
+	transport http.RoundTripper
+
+	lock          sync.Mutex
+	cond          *sync.Cond
+	busy          bool
+	alertsWaiting int
+	global        time.Time            // no requests until this time
+	buckets       map[string]time.Time // no requests for the bucket until this time
+}
+
+func newDiscordRateLimiter(transport http.RoundTripper) *discordRateLimiter {
+	if transport == nil {
+		transport = http.DefaultTransport
+	}
+	limiter := &discordRateLimiter{
+		transport: transport,
+		buckets:   make(map[string]time.Time),
+	}
+	limiter.cond = sync.NewCond(&limiter.lock)
+	return limiter
+}
+
+// acquire waits for prior calls to finish, and for all waiting alert sends when the priority is status. It returns
+// the context of the call, with the request timeout starting once the call gets its turn, and the release of the turn.
+func (limiter *discordRateLimiter) acquire(priority discordPriority) (context.Context, func()) {
+	limiter.wait(priority)
+	ctx, cancel := context.WithTimeout(context.Background(), discordRequestTimeout)
+	return ctx, func() {
+		cancel()
+		limiter.release()
+	}
+}
+
+func (limiter *discordRateLimiter) wait(priority discordPriority) {
+	limiter.lock.Lock()
+	defer limiter.lock.Unlock()
+	if priority == discordPriorityAlert {
+		limiter.alertsWaiting++
+		defer func() { limiter.alertsWaiting-- }()
+	}
+	for limiter.busy || (priority == discordPriorityStatus && limiter.alertsWaiting > 0) {
+		limiter.cond.Wait()
+	}
+	limiter.busy = true
+}
+
+func (limiter *discordRateLimiter) release() {
+	limiter.lock.Lock()
+	limiter.busy = false
+	limiter.cond.Broadcast()
+	limiter.lock.Unlock()
+}
+
+// getDiscordRateLimitBucket returns the method and webhook ID of the request. Discord limits each webhook separately.
+func getDiscordRateLimitBucket(req *http.Request) string {
+	path := req.URL.Path
+	if i := strings.Index(path, "/webhooks/"); i != -1 {
+		path = path[i+len("/webhooks/"):]
+		if j := strings.Index(path, "/"); j != -1 {
+			path = path[:j]
+		}
+	}
+	return req.Method + " " + path
+}
+
+// parseDiscordSeconds parses a number of seconds header, which Discord may send with a fraction
+func parseDiscordSeconds(value string) (time.Duration, bool) {
+	seconds, err := strconv.ParseFloat(value, 64)
+	if err != nil || seconds < 0 {
+		return 0, false
+	}
+	return time.Duration(seconds * float64(time.Second)), true
+}
+
+// update records when requests may resume, from the rate limit headers of the response
+func (limiter *discordRateLimiter) update(bucket string, res *http.Response) time.Duration {
+	limiter.lock.Lock()
+	defer limiter.lock.Unlock()
+	now := time.Now()
+	if res.StatusCode == http.StatusTooManyRequests {
+		retryAfter, ok := parseDiscordSeconds(res.Header.Get("Retry-After"))
+		if !ok {
+			retryAfter = time.Second
+		}
+		if res.Header.Get("X-RateLimit-Global") != "" || res.Header.Get("X-RateLimit-Scope") == "global" {
+			limiter.global = now.Add(retryAfter)
+		} else {
+			limiter.buckets[bucket] = now.Add(retryAfter)
+		}
+		return retryAfter
+	}
+	if res.Header.Get("X-RateLimit-Remaining") == "0" {
+		if resetAfter, ok := parseDiscordSeconds(res.Header.Get("X-RateLimit-Reset-After")); ok {
+			limiter.buckets[bucket] = now.Add(resetAfter)
+		}
+	} else {
+		delete(limiter.buckets, bucket)
+	}
+	return 0
+}
+
+func (limiter *discordRateLimiter) getWait(bucket string) time.Duration {
+	limiter.lock.Lock()
+	defer limiter.lock.Unlock()
+	until := limiter.global
+	if bucketUntil := limiter.buckets[bucket]; bucketUntil.After(until) {
+		until = bucketUntil
+	}
+	return time.Until(until)
+}
+
+// RoundTrip implements http.RoundTripper, waiting out rate limits before the request and retrying it when Discord returns 429
+func (limiter *discordRateLimiter) RoundTrip(req *http.Request) (*http.Response, error) {
+	bucket := getDiscordRateLimitBucket(req)
+	for attempt := 0; ; attempt++ {
+		if wait := limiter.getWait(bucket); wait > 0 {
+			if attempt == 0 {
+				fmt.Printf("Discord rate limit reached, waiting %s before %s\n", wait.Round(time.Millisecond), req.Method)
+			}
+			select {
+			case <-req.Context().Done():
+				return nil, req.Context().Err()
+			case <-time.After(wait):
+			}
+		}
+		if attempt > 0 && req.GetBody != nil {
+			body, err := req.GetBody()
+			if err != nil {
+				return nil, err
+			}
+			req = req.Clone(req.Context())
+			req.Body = body
+		}
+		res, err := limiter.transport.RoundTrip(req)
+		if err != nil {
+			return nil, err
+		}
+		retryAfter := limiter.update(bucket, res)
+		if res.StatusCode != http.StatusTooManyRequests {
+			return res, nil
+		}
+		if attempt >= discordRateLimitMaxRetries || (req.Body != nil && req.GetBody == nil) {
+			fmt.Printf("Discord rate limited %s, giving up after %d retries\n", req.Method, attempt)
+			return res, nil
+		}
+		if deadline, ok := req.Context().Deadline(); ok && time.Now().Add(retryAfter).After(deadline) {
+			fmt.Printf("Discord rate limited %s, retry after %s is past the request timeout\n", req.Method, retryAfter.Round(time.Millisecond))
+			return res, nil
+		}
+		fmt.Printf("Discord rate limited %s, retrying after %s\n", req.Method, retryAfter.Round(time.Millisecond))
+		_, _ = io.Copy(io.Discard, res.Body)
+		res.Body.Close()
+	}
+}
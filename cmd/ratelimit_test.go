@@ -0,0 +1,28 @@
+package cmd
+
+import (
+	"testing"
+	"time"
+)
+
+func TestDiscordRateLimiterTimeoutStartsAtTurn(t *testing.T) {
+	limiter := newDiscordRateLimiter(nil)
+	_, release := limiter.acquire(discordPriorityAlert)
+
+	const queued = 50 * time.Millisecond
+	acquired := make(chan time.Time)
+	go func() {
+		ctx, release := limiter.acquire(discordPriorityStatus)
+		defer release()
+		deadline, _ := ctx.Deadline()
+		acquired <- deadline
+	}()
+	time.Sleep(queued)
+	turn := time.Now()
+	release()
+
+	// the time spent queued behind the alert send is not taken from the status edit's request timeout
+	if deadline := <-acquired; deadline.Before(turn.Add(discordRequestTimeout)) {
+		t.Errorf("request deadline %s is within %s of its turn", deadline.Sub(turn), discordRequestTimeout)
+	}
+}
@@ -0,0 +1,325 @@
+package cmd
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"text/tabwriter"
+	"time"
+
+	cosmosClient "github.com/cosmos/cosmos-sdk/client"
+	slashingtypes "github.com/cosmos/cosmos-sdk/x/slashing/types"
+	"github.com/spf13/cobra"
+	rpcclient "github.com/tendermint/tendermint/rpc/client"
+)
+
+const replayFetchChunk = 1000 // blocks fetched between progress messages
+
+const (
+	replayEventFired    = "fired"
+	replayEventRepeated = "repeated"
+	replayEventCleared  = "cleared"
+)
+
+// ReplayEvent is an alert that fired, was repeated, or cleared at a replayed check
+type ReplayEvent struct {
+	Time       time.Time  `json:"time"`
+	Height     int64      `json:"height"`
+	Event      string     `json:"event"` // fired, repeated, or cleared
+	AlertType  AlertType  `json:"alert-type"`
+	AlertLevel AlertLevel `json:"alert-level"`
+	Message    string     `json:"message"`
+	Notified   bool       `json:"notified"` // false when inhibited or filtered by min-notify-level or quiet-hours
+}
+
+// replay runs the alert evaluation of a validator over historical blocks, with checks every check interval of
+// block time, so that notify_every, cooldowns, and quiet hours behave as they would have live
+type replay struct {
+	config     *HalfLifeConfig
+	vm         *ValidatorMonitor
+	client     *cosmosClient.Context
+	alertState *ValidatorAlertState
+	blocks     map[int64]recentBlock
+
+	slashingParams *slashingtypes.Params // nil when the signing info is not replayed
+}
+
+// fetchBlocks fetches the signing info of the blocks from the height down, count blocks
+func (r *replay) fetchBlocks(node rpcclient.Client, hexAddress []byte, height int64, count int64) error {
+	concurrency := defaultRecentBlocksConcurrency
+	if r.vm.RecentBlocksConcurrency != nil {
+		concurrency = *r.vm.RecentBlocksConcurrency
+	}
+	for top := height; top > height-count && top > 0; top -= replayFetchChunk {
+		chunk := int64(replayFetchChunk)
+		if top-chunk < height-count {
+			chunk = top - (height - count)
+		}
+		blocks := scanRecentBlocks(context.Background(), top, chunk, concurrency, func(ctx context.Context, height int64) (blockSigningInfo, error) {
+			block, err := node.Block(ctx, &height)
+			if err != nil {
+				return blockSigningInfo{}, err
+			}
+			return getBlockSigningInfo(block.Block, hexAddress), nil
+		})
+		for _, block := range blocks {
+			if block.err != nil {
+				// a missing block would be replayed as an rpc error that never happened, so the replay is stopped
+				return fmt.Errorf("error fetching block %d: %w", block.height, block.err)
+			}
+			r.blocks[block.height] = block
+		}
+		fmt.Printf("Fetched blocks %d to %d\n", top-chunk+1, top)
+	}
+	return nil
+}
+
+// determineSigningInfo adds the slashing uptime, jailed, and tombstoned errors from the signing info at the height.
+// The signing info is only replayed while the rpc serves historical queries.
+func (r *replay) determineSigningInfo(stats *ValidatorStats, height int64, now time.Time) (errs []IgnorableError) {
+	if r.slashingParams == nil {
+		return
+	}
+	heightClient := r.client.WithHeight(height)
+	valInfo, err := getSigningInfo(context.Background(), &heightClient, r.vm.Address)
+	if err != nil {
+		fmt.Printf("Signing info is not available at height %d, the slashing checks are not replayed from here: %s\n", height, redactError(err))
+		r.slashingParams = nil
+		return
+	}
+	signingInfo := valInfo.ValSigningInfo
+	if signingInfo.Tombstoned {
+		errs = append(errs, newTombstonedError(false))
+	}
+	if signingInfo.JailedUntil.After(now) {
+		errs = append(errs, newJailedError(signingInfo.JailedUntil, false))
+	}
+	return append(errs, stats.determineSlashingUptime(r.vm, signingInfo, *r.slashingParams)...)
+}
+
+// check evaluates the alerts as a live check at the time would have, with the height as the latest block.
+// The bond status, voting power, upgrade, and sentry checks depend on live queries, so are not replayed.
+func (r *replay) check(height int64, now time.Time) []ReplayEvent {
+	alertClock = func() time.Time { return now }
+	stats := ValidatorStats{
+		Height:                height,
+		Timestamp:             r.blocks[height].info.time,
+		LastSignedBlockHeight: -1,
+		BlocksUntilJailed:     -1,
+	}
+	var valErrs []IgnorableError
+	if timeSinceLastBlock := now.Sub(stats.Timestamp); timeSinceLastBlock > haltThresholdNanoseconds {
+		valErrs = append(valErrs, newChainHaltError(int64(timeSinceLastBlock)))
+	}
+	valErrs = append(valErrs, r.determineSigningInfo(&stats, height, now)...)
+	var recentBlocks []recentBlock
+	for i := height; i > height-r.vm.RecentBlocksToCheck && i > 0; i-- {
+		recentBlocks = append(recentBlocks, r.blocks[i])
+	}
+	valErrs = append(valErrs, stats.determineRecentBlocks(r.vm, recentBlocks)...)
+
+	errs := []error{}
+	for _, e := range valErrs {
+		if e.Active(r.config.AlertConfig) {
+			errs = append(errs, e)
+		}
+	}
+	stats.determineBlockTime(r.vm, r.alertState)
+	errs = append(errs, stats.determineAggregatedErrorsAndAlertLevel(r.vm)...)
+	errs = stats.determineConfirmedErrors(r.vm, r.alertState, errs)
+	errs = append(errs, stats.determineSlashingSLAErrors(r.config, r.vm, r.alertState, errs)...)
+	notification := getAlertNotification(r.config, r.vm, &stats, r.alertState, errs)
+	if notification == nil {
+		return nil
+	}
+	inhibited := r.alertState.getInhibitedAlertTypes(r.config.AlertConfig.getInhibitRules())
+	sent := notification.filterInhibited(r.vm, inhibited).filterByMinNotifyLevel(getMinNotifyLevel(r.config, r.vm)).filterQuietHours(getQuietHours(r.config, r.vm), now)
+	r.alertState.recordAlertsSent(r.config, sent)
+	notified := func(key AlertKey, cleared bool) bool {
+		if sent == nil {
+			return false
+		}
+		keys := sent.AlertKeys
+		if cleared {
+			keys = sent.ClearedAlertKeys
+		}
+		for _, k := range keys {
+			if k == key {
+				return true
+			}
+		}
+		return false
+	}
+
+	var events []ReplayEvent
+	transitioned := make(map[AlertKey]bool)
+	for _, transition := range notification.Transitions {
+		key := AlertKey{AlertType: transition.AlertType, Sentry: transition.Sentry}
+		event := replayEventFired
+		if transition.Cleared {
+			event = replayEventCleared
+		} else {
+			transitioned[key] = true
+		}
+		events = append(events, ReplayEvent{
+			Time:       now,
+			Height:     height,
+			Event:      event,
+			AlertType:  transition.AlertType,
+			AlertLevel: transition.AlertLevel,
+			Message:    transition.Message,
+			Notified:   notified(key, transition.Cleared),
+		})
+	}
+	for i, alert := range notification.Alerts {
+		key := notification.AlertKeys[i]
+		if transitioned[key] {
+			continue
+		}
+		events = append(events, ReplayEvent{
+			Time:       now,
+			Height:     height,
+			Event:      replayEventRepeated,
+			AlertType:  key.AlertType,
+			AlertLevel: notification.AlertLevels[i],
+			Message:    alert,
+			Notified:   notified(key, false),
+		})
+	}
+	return events
+}
+
+// run replays the checks from the from height until the to height is the latest block
+func (r *replay) run(from int64, to int64) (events []ReplayEvent, checks int) {
+	latest := from
+	now := r.blocks[from].info.time
+	for {
+		for latest < to && !r.blocks[latest+1].info.time.After(now) {
+			latest++
+		}
+		events = append(events, r.check(latest, now)...)
+		checks++
+		if latest == to {
+			return
+		}
+		now = now.Add(checkInterval)
+	}
+}
+
+func printReplayEvents(out io.Writer, events []ReplayEvent) {
+	w := tabwriter.NewWriter(out, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "TIME\tHEIGHT\tEVENT\tALERT LEVEL\tALERT TYPE\tMESSAGE")
+	for _, event := range events {
+		name := event.Event
+		if !event.Notified {
+			name += " (not notified)"
+		}
+		fmt.Fprintf(w, "%s\t%d\t%s\t%s\t%s\t%s\n", event.Time.UTC().Format(time.RFC3339), event.Height, name, event.AlertLevel, event.AlertType, event.Message)
+	}
+	w.Flush()
+}
+
+var replayCmd = &cobra.Command{
+	Use:   "replay",
+	Short: "Replay historical blocks through the alert logic",
+	Long: "Fetches the blocks of a validator's chain in a height range and runs the alert evaluation over them, " +
+		"with a check every 30 seconds of block time, printing a timeline of the alerts that would have fired and cleared. " +
+		"No notifications are sent.",
+	Run: func(cmd *cobra.Command, args []string) {
+		// config loading and the checks log to stdout, so it is redirected to stderr to keep the timeline parseable
+		stdout := os.Stdout
+		os.Stdout = os.Stderr
+
+		configFile, err := getConfigFile(cmd)
+		if err != nil {
+			log.Fatalf("Error resolving config file: %v", err)
+		}
+		config, err := loadConfig(configFile)
+		if err != nil {
+			log.Fatalf("Error loading config: %s", redactError(err))
+		}
+		name, _ := cmd.Flags().GetString("validator")
+		from, _ := cmd.Flags().GetInt64("from")
+		to, _ := cmd.Flags().GetInt64("to")
+		rpc, _ := cmd.Flags().GetString("rpc")
+		asJSON, _ := cmd.Flags().GetBool("json")
+		if from < 1 || to < from {
+			log.Fatalf("--from and --to must be a range of heights, e.g. --from 1000 --to 2000")
+		}
+
+		var vm *ValidatorMonitor
+		for _, v := range config.Validators {
+			if v.Name == name {
+				vm = v
+			}
+		}
+		if vm == nil {
+			log.Fatalf("Validator %s is not in the config", name)
+		}
+		if vm.FullNode {
+			log.Fatalf("Validator %s is a full node, which does not sign blocks", name)
+		}
+		if rpc != "" {
+			vm.RPC = rpc
+		}
+		if err := resolveValidatorAddress(config, vm); err != nil {
+			log.Fatalf("Error resolving validator %s: %s", vm.Name, redactError(err))
+		}
+		hexAddress, err := vm.getChainType().consensusAddress(vm.Address)
+		if err != nil {
+			log.Fatalf("Error decoding address of %s: %v", vm.Name, err)
+		}
+		client, err := getCosmosClient(vm.getRPCAddress(), vm.ChainID, getProxy(config, vm), getRPCTLS(config, vm))
+		if err != nil {
+			log.Fatalf("Error connecting to %s: %s", redactSecrets(vm.RPC), redactError(err))
+		}
+		node, err := client.GetNode()
+		if err != nil {
+			log.Fatalf("Error connecting to %s: %s", redactSecrets(vm.RPC), redactError(err))
+		}
+
+		r := &replay{
+			config:     config,
+			vm:         vm,
+			client:     client,
+			alertState: newValidatorAlertState(),
+			blocks:     make(map[int64]recentBlock),
+		}
+		if err := r.fetchBlocks(node, hexAddress, to, to-from+vm.RecentBlocksToCheck); err != nil {
+			log.Fatalf("Error fetching blocks: %s", redactError(err))
+		}
+		if vm.getChainType().hasSDKModules() {
+			heightClient := client.WithHeight(from)
+			if params, err := getSlashingInfo(context.Background(), &heightClient); err == nil {
+				r.slashingParams = &params.Params
+			} else {
+				fmt.Printf("Slashing params are not available at height %d, the slashing checks are not replayed: %s\n", from, redactError(err))
+			}
+		}
+
+		events, checks := r.run(from, to)
+		fmt.Printf("Replayed %d checks of %s from height %d to %d, %d events\n", checks, vm.Name, from, to, len(events))
+		if asJSON {
+			encoder := json.NewEncoder(stdout)
+			for _, event := range events {
+				if err := encoder.Encode(event); err != nil {
+					log.Fatalf("Error writing timeline: %v", err)
+				}
+			}
+			return
+		}
+		printReplayEvents(stdout, events)
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(replayCmd)
+	replayCmd.Flags().String("validator", "", "Name of the validator in the config to replay")
+	replayCmd.Flags().Int64("from", 0, "First height of the replay")
+	replayCmd.Flags().Int64("to", 0, "Last height of the replay")
+	replayCmd.Flags().String("rpc", "", "RPC to fetch the blocks from in place of the validator rpc, e.g. an archive node")
+	replayCmd.Flags().Bool("json", false, "Print the timeline as JSON lines")
+}
@@ -0,0 +1,370 @@
+package cmd
+
+import (
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+)
+
+const (
+	defaultNotificationRetryWindow = 10 * time.Minute
+	notificationRetryBaseDelay     = 5 * time.Second
+	notificationRetryMaxDelay      = 2 * time.Minute
+	notificationRetryPollInterval  = time.Second
+	statusUpdateRetryAttempts      = 2
+	notificationDrainTimeout       = time.Minute // longest the queued retries are waited for before exiting
+)
+
+// number of retries for failed alert deliveries, by alert level
+var notificationRetryAttempts = map[AlertLevel]int{
+	alertLevelNone:     3,
+	alertLevelWarning:  3,
+	alertLevelHigh:     5,
+	alertLevelCritical: 10,
+}
+
+type pendingDelivery struct {
+	// alert deliveries
+	alertNotification *ValidatorAlertNotification
+
+	// alert digest deliveries
+	digest *AlertDigest
+
+	// status deliveries
+	status           bool
+	configFile       string
+	writeConfigMutex *sync.Mutex
+
+	config      *HalfLifeConfig
+	vm          *ValidatorMonitor
+	stats       ValidatorStats
+	attempts    int
+	maxAttempts int
+	nextAttempt time.Time
+	deadline    time.Time
+}
+
+// RetryingNotificationService wraps a NotificationService, retrying failed deliveries
+// with backoff for a bounded window. Queued alerts are dropped if they clear or are
+// re-sent before redelivery, and queued status updates are replaced by newer ones.
+type RetryingNotificationService struct {
+	NotificationService
+	window        time.Duration
+	lock          sync.Mutex
+	pending       []*pendingDelivery
+	delivering    int // deliveries taken from pending that are being retried
+	statusLocks   map[string]*sync.Mutex
+	statusLocksMu sync.Mutex
+}
+
+func NewRetryingNotificationService(service NotificationService, window time.Duration) *RetryingNotificationService {
+	retryService := &RetryingNotificationService{
+		NotificationService: service,
+		window:              window,
+		statusLocks:         make(map[string]*sync.Mutex),
+	}
+	go retryService.run()
+	return retryService
+}
+
+// name identifies the delivery in logs
+func (delivery *pendingDelivery) name() string {
+	if delivery.digest != nil {
+		return "alert digest"
+	}
+	return delivery.vm.Name
+}
+
+func notificationRetryDelay(attempt int) time.Duration {
+	delay := notificationRetryBaseDelay << attempt
+	if attempt >= 16 || delay > notificationRetryMaxDelay {
+		return notificationRetryMaxDelay
+	}
+	return delay
+}
+
+// statusLock serializes status updates per validator so a retry can't race a new status update
+func (service *RetryingNotificationService) statusLock(validator string) *sync.Mutex {
+	service.statusLocksMu.Lock()
+	defer service.statusLocksMu.Unlock()
+	lock, ok := service.statusLocks[validator]
+	if !ok {
+		lock = &sync.Mutex{}
+		service.statusLocks[validator] = lock
+	}
+	return lock
+}
+
+func (service *RetryingNotificationService) enqueue(delivery *pendingDelivery) {
+	now := time.Now()
+	delivery.nextAttempt = now.Add(notificationRetryDelay(delivery.attempts))
+	if delivery.deadline.IsZero() {
+		delivery.deadline = now.Add(service.window)
+	}
+	service.lock.Lock()
+	service.pending = append(service.pending, delivery)
+	service.lock.Unlock()
+}
+
+// dropStaleAlerts removes queued alerts for the validator that are cleared or re-sent by the new notification
+func (service *RetryingNotificationService) dropStaleAlerts(vm *ValidatorMonitor, alertNotification *ValidatorAlertNotification) {
+	stale := make(map[AlertKey]bool)
+	for _, key := range alertNotification.ClearedAlertKeys {
+		stale[key] = true
+	}
+	for _, key := range alertNotification.AlertKeys {
+		stale[key] = true
+	}
+
+	service.lock.Lock()
+	defer service.lock.Unlock()
+	pending := service.pending[:0]
+	for _, delivery := range service.pending {
+		if delivery.status || delivery.digest != nil || delivery.vm != vm || len(delivery.alertNotification.Alerts) == 0 {
+			pending = append(pending, delivery)
+			continue
+		}
+		queued := delivery.alertNotification
+		var alerts []string
+		var alertKeys []AlertKey
+		var alertLevels []AlertLevel
+		for i, alert := range queued.Alerts {
+			if i < len(queued.AlertKeys) && stale[queued.AlertKeys[i]] && queued.AlertKeys[i].AlertType != "" {
+				continue
+			}
+			alerts = append(alerts, alert)
+			if i < len(queued.AlertKeys) {
+				alertKeys = append(alertKeys, queued.AlertKeys[i])
+			}
+			if i < len(queued.AlertLevels) {
+				alertLevels = append(alertLevels, queued.AlertLevels[i])
+			}
+		}
+		if len(alerts) == 0 {
+			fmt.Printf("Dropping stale queued alert notification for %s\n", vm.Name)
+			continue
+		}
+		queued.Alerts = alerts
+		queued.AlertKeys = alertKeys
+		queued.AlertLevels = alertLevels
+		pending = append(pending, delivery)
+	}
+	service.pending = pending
+}
+
+// dropQueuedStatus removes queued status updates for the validator
+func (service *RetryingNotificationService) dropQueuedStatus(vm *ValidatorMonitor) {
+	service.lock.Lock()
+	defer service.lock.Unlock()
+	pending := service.pending[:0]
+	for _, delivery := range service.pending {
+		if delivery.status && delivery.vm == vm {
+			continue
+		}
+		pending = append(pending, delivery)
+	}
+	service.pending = pending
+}
+
+// implements NotificationService interface
+func (service *RetryingNotificationService) SendValidatorAlertNotification(
+	config *HalfLifeConfig,
+	vm *ValidatorMonitor,
+	stats ValidatorStats,
+	alertNotification *ValidatorAlertNotification,
+) error {
+	service.dropStaleAlerts(vm, alertNotification)
+
+	// alerts and cleared alerts are delivered separately so one can be retried without repeating the other
+	alerts := *alertNotification
+	alerts.ClearedAlerts = nil
+	alerts.ClearedAlertKeys = nil
+	alerts.NotifyForClear = false
+	clearedAlerts := *alertNotification
+	clearedAlerts.Alerts = nil
+	clearedAlerts.AlertKeys = nil
+	clearedAlerts.AlertLevels = nil
+	clearedAlerts.AlertLevel = alertLevelNone
+
+	var firstErr error
+	for _, part := range []struct {
+		notification *ValidatorAlertNotification
+		alertLevel   AlertLevel
+		count        int
+	}{
+		{&alerts, alerts.AlertLevel, len(alerts.Alerts)},
+		{&clearedAlerts, clearedAlerts.ClearedAlertLevel, len(clearedAlerts.ClearedAlerts)},
+	} {
+		if part.count == 0 {
+			continue
+		}
+		err := service.NotificationService.SendValidatorAlertNotification(config, vm, stats, part.notification)
+		if err == nil {
+			continue
+		}
+		if firstErr == nil {
+			firstErr = err
+		}
+		fmt.Printf("Queueing alert notification for %s for retry\n", vm.Name)
+		service.enqueue(&pendingDelivery{
+			alertNotification: part.notification,
+			config:            config,
+			vm:                vm,
+			stats:             stats,
+			maxAttempts:       notificationRetryAttempts[part.alertLevel],
+		})
+	}
+	return firstErr
+}
+
+// implements NotificationService interface
+func (service *RetryingNotificationService) UpdateValidatorRealtimeStatus(
+	configFile string,
+	config *HalfLifeConfig,
+	vm *ValidatorMonitor,
+	stats ValidatorStats,
+	writeConfigMutex *sync.Mutex,
+) error {
+	statusLock := service.statusLock(vm.Name)
+	statusLock.Lock()
+	defer statusLock.Unlock()
+	service.dropQueuedStatus(vm)
+	err := service.NotificationService.UpdateValidatorRealtimeStatus(configFile, config, vm, stats, writeConfigMutex)
+	if err != nil {
+		service.enqueue(&pendingDelivery{
+			status:           true,
+			configFile:       configFile,
+			writeConfigMutex: writeConfigMutex,
+			config:           config,
+			vm:               vm,
+			stats:            stats,
+			maxAttempts:      statusUpdateRetryAttempts,
+		})
+	}
+	return err
+}
+
+// implements AlertDigestSender interface, when supported by the wrapped notification service
+func (service *RetryingNotificationService) SendAlertDigest(config *HalfLifeConfig, digest *AlertDigest) error {
+	sender, ok := service.NotificationService.(AlertDigestSender)
+	if !ok {
+		return errors.New("alert digests are not supported by the notification service")
+	}
+	err := sender.SendAlertDigest(config, digest)
+	if err != nil {
+		fmt.Println("Queueing alert digest for retry")
+		service.enqueue(&pendingDelivery{
+			digest:      digest,
+			config:      config,
+			maxAttempts: notificationRetryAttempts[digest.AlertLevel],
+		})
+	}
+	return err
+}
+
+func (service *RetryingNotificationService) deliver(delivery *pendingDelivery) error {
+	if delivery.digest != nil {
+		return service.NotificationService.(AlertDigestSender).SendAlertDigest(delivery.config, delivery.digest)
+	}
+	if delivery.status {
+		statusLock := service.statusLock(delivery.vm.Name)
+		statusLock.Lock()
+		defer statusLock.Unlock()
+		return service.NotificationService.UpdateValidatorRealtimeStatus(delivery.configFile, delivery.config, delivery.vm, delivery.stats, delivery.writeConfigMutex)
+	}
+	return service.NotificationService.SendValidatorAlertNotification(delivery.config, delivery.vm, delivery.stats, delivery.alertNotification)
+}
+
+func (service *RetryingNotificationService) run() {
+	for {
+		time.Sleep(notificationRetryPollInterval)
+
+		now := time.Now()
+		var due []*pendingDelivery
+		service.lock.Lock()
+		pending := service.pending[:0]
+		for _, delivery := range service.pending {
+			if now.Before(delivery.nextAttempt) {
+				pending = append(pending, delivery)
+			} else {
+				due = append(due, delivery)
+			}
+		}
+		service.pending = pending
+		service.delivering = len(due)
+		service.lock.Unlock()
+
+		for _, delivery := range due {
+			service.retry(delivery)
+			service.lock.Lock()
+			service.delivering--
+			service.lock.Unlock()
+		}
+	}
+}
+
+// retry delivers a due delivery, queueing it again when it fails and has attempts left
+func (service *RetryingNotificationService) retry(delivery *pendingDelivery) {
+	delivery.attempts++
+	err := service.deliver(delivery)
+	if err == nil {
+		fmt.Printf("Delivered queued notification for %s after %d retries\n", delivery.name(), delivery.attempts)
+		return
+	}
+	if delivery.attempts >= delivery.maxAttempts || time.Now().After(delivery.deadline) {
+		fmt.Printf("Giving up on queued notification for %s after %d retries: %s\n", delivery.name(), delivery.attempts, redactError(err))
+		return
+	}
+	fmt.Printf("Retry %d/%d of queued notification for %s failed: %s\n", delivery.attempts, delivery.maxAttempts, delivery.name(), redactError(err))
+	service.enqueue(delivery)
+}
+
+// queued returns the number of deliveries that are queued or being retried
+func (service *RetryingNotificationService) queued() int {
+	service.lock.Lock()
+	defer service.lock.Unlock()
+	return len(service.pending) + service.delivering
+}
+
+// drain waits until the queued deliveries are delivered or given up, or the deadline passes.
+// Returns the number of deliveries still queued.
+func (service *RetryingNotificationService) drain(deadline time.Time) int {
+	for {
+		queued := service.queued()
+		if queued == 0 || !time.Now().Before(deadline) {
+			return queued
+		}
+		time.Sleep(notificationRetryPollInterval)
+	}
+}
+
+// drainNotifications waits for the queued retries of the notification service and its pagers, for at most the
+// timeout, used before exiting after the batched notifications are flushed
+func drainNotifications(service NotificationService, timeout time.Duration) {
+	deadline := time.Now().Add(timeout)
+	queued := 0
+	for _, retryService := range getRetryingNotificationServices(service) {
+		queued += retryService.drain(deadline)
+	}
+	if queued > 0 {
+		fmt.Printf("Exiting with %d queued notifications not delivered within %s\n", queued, timeout)
+	}
+}
+
+// getRetryingNotificationServices returns the retrying services wrapped by the notification service and its pagers
+func getRetryingNotificationServices(service NotificationService) []*RetryingNotificationService {
+	var retryServices []*RetryingNotificationService
+	for service != nil {
+		switch wrapped := service.(type) {
+		case *RetryingNotificationService:
+			retryServices = append(retryServices, wrapped)
+		case *PagingNotificationService:
+			for _, pager := range wrapped.pagers {
+				retryServices = append(retryServices, getRetryingNotificationServices(pager)...)
+			}
+		}
+		service = unwrapNotificationService(service)
+	}
+	return retryServices
+}
@@ -1,11 +1,15 @@
 package cmd
 
 import (
+	"fmt"
 	"os"
+	"path/filepath"
 
 	"github.com/spf13/cobra"
 )
 
+const configFileEnvVar = "HALFLIFE_CONFIG"
+
 var rootCmd = &cobra.Command{
 	Use:   "halflife",
 	Short: "Validator monitoring and alerting",
@@ -30,4 +34,35 @@ func Execute() {
 	}
 }
 
-func init() {}
+// getConfigFile resolves the config file path from the --config flag, the legacy --file flag,
+// the HALFLIFE_CONFIG environment variable, or the default, in that order of precedence.
+// The config file can also be an http(s) URL.
+func getConfigFile(cmd *cobra.Command) (string, error) {
+	configFile, _ := cmd.Flags().GetString("config")
+	if configFile == "" && cmd.Flags().Lookup("file") != nil {
+		configFile, _ = cmd.Flags().GetString("file")
+	}
+	if configFile == "" {
+		configFile = os.Getenv(configFileEnvVar)
+	}
+	if configFile == "" {
+		configFile = configFilePath
+	}
+	if isRemoteConfig(configFile) {
+		return configFile, nil
+	}
+
+	absConfigFile, err := filepath.Abs(configFile)
+	if err != nil {
+		return "", fmt.Errorf("invalid config file path %s: %w", configFile, err)
+	}
+	configDir := filepath.Dir(absConfigFile)
+	if info, err := os.Stat(configDir); err != nil || !info.IsDir() {
+		return "", fmt.Errorf("config file directory does not exist: %s", configDir)
+	}
+	return absConfigFile, nil
+}
+
+func init() {
+	rootCmd.PersistentFlags().String("config", "", fmt.Sprintf("File path or http(s) URL of config yaml (overrides $%s, default %s)", configFileEnvVar, configFilePath))
+}
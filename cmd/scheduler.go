@@ -0,0 +1,118 @@
+package cmd
+
+import (
+	"fmt"
+	"sync"
+	"time"
+)
+
+// checkSlotWait is how long a check below the highest priority waits for a slot before it is skipped for the cycle,
+// so that a backlog of low priority checks never delays the next cycle of the higher priority ones
+const checkSlotWait = checkInterval
+
+// checkSchedule is shared by the checks of every validator
+var checkSchedule = checkScheduler{}
+
+// checkScheduler limits how many validator checks run at a time across the fleet when max-concurrent-checks is set.
+// When checks are waiting for a slot, the validator with the highest priority is started first, in the order they
+// started waiting within a priority.
+type checkScheduler struct {
+	lock        sync.Mutex
+	limited     bool
+	slots       int // slots that are free
+	maxPriority int // checks at this priority are never skipped
+	waiting     []*checkWaiter
+}
+
+type checkWaiter struct {
+	priority int
+	granted  chan struct{} // closed once the slot is handed to the waiter
+}
+
+// register limits the checks to max-concurrent-checks at a time, checks are not limited when it is not set
+func (scheduler *checkScheduler) register(config *HalfLifeConfig, validators []*ValidatorMonitor) {
+	if config.MaxConcurrentChecks == nil {
+		return
+	}
+	scheduler.lock.Lock()
+	defer scheduler.lock.Unlock()
+	scheduler.limited = true
+	scheduler.slots = *config.MaxConcurrentChecks
+	for i, vm := range validators {
+		if i == 0 || vm.Priority > scheduler.maxPriority {
+			scheduler.maxPriority = vm.Priority
+		}
+	}
+	fmt.Printf("Running at most %d checks at a time, checks of priority %d first\n", *config.MaxConcurrentChecks, scheduler.maxPriority)
+}
+
+// acquire waits for a slot to check the validator, and returns false when the check should be skipped. Checks below
+// the highest priority give up after checkSlotWait, or wait indefinitely when skip is false.
+func (scheduler *checkScheduler) acquire(vm *ValidatorMonitor, skip bool) bool {
+	scheduler.lock.Lock()
+	if !scheduler.limited {
+		scheduler.lock.Unlock()
+		return true
+	}
+	if scheduler.slots > 0 && len(scheduler.waiting) == 0 {
+		scheduler.slots--
+		scheduler.lock.Unlock()
+		return true
+	}
+	waiter := &checkWaiter{priority: vm.Priority, granted: make(chan struct{})}
+	scheduler.waiting = append(scheduler.waiting, waiter)
+	waitingAhead := 0
+	for _, w := range scheduler.waiting {
+		if w.priority > vm.Priority {
+			waitingAhead++
+		}
+	}
+	canSkip := skip && vm.Priority < scheduler.maxPriority
+	scheduler.lock.Unlock()
+
+	if !canSkip {
+		<-waiter.granted
+		return true
+	}
+	timer := time.NewTimer(checkSlotWait)
+	defer timer.Stop()
+	select {
+	case <-waiter.granted:
+		return true
+	case <-timer.C:
+	}
+	scheduler.lock.Lock()
+	defer scheduler.lock.Unlock()
+	for i, w := range scheduler.waiting {
+		if w == waiter {
+			scheduler.waiting = append(scheduler.waiting[:i], scheduler.waiting[i+1:]...)
+			fmt.Printf("Skipping check of %s (priority %d), no check slot was free within %s with %d higher priority checks waiting\n",
+				vm.Name, vm.Priority, checkSlotWait, waitingAhead)
+			return false
+		}
+	}
+	// the slot was handed over as the wait timed out
+	return true
+}
+
+// release frees the slot of a check, handing it to the highest priority check waiting
+func (scheduler *checkScheduler) release() {
+	scheduler.lock.Lock()
+	defer scheduler.lock.Unlock()
+	if !scheduler.limited {
+		return
+	}
+	if len(scheduler.waiting) == 0 {
+		scheduler.slots++
+		return
+	}
+	next := 0
+	for i, w := range scheduler.waiting {
+		if w.priority > scheduler.waiting[next].priority {
+			next = i
+		}
+	}
+	waiter := scheduler.waiting[next]
+	scheduler.waiting = append(scheduler.waiting[:next], scheduler.waiting[next+1:]...)
+	close(waiter.granted)
+}
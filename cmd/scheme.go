@@ -0,0 +1,93 @@
+package cmd
+
+import (
+	"fmt"
+	"net"
+	"strings"
+)
+
+// ConnectionScheme forces a connection to TLS or plaintext, in place of the scheme of the address
+type ConnectionScheme string
+
+const (
+	schemeTLS       ConnectionScheme = "tls"
+	schemePlaintext ConnectionScheme = "plaintext"
+)
+
+// grpcURLSchemes are the URL schemes a sentry grpc address may have, and whether each connects with TLS
+var grpcURLSchemes = map[string]bool{
+	"grpcs": true,
+	"https": true,
+	"grpc":  false,
+	"http":  false,
+	"h2c":   false,
+}
+
+func (s ConnectionScheme) validate() error {
+	switch s {
+	case "", schemeTLS, schemePlaintext:
+		return nil
+	default:
+		return fmt.Errorf("unsupported scheme %q, expected tls or plaintext", s)
+	}
+}
+
+// splitURLScheme returns the scheme of the address, if any, and the address without it
+func splitURLScheme(addr string) (string, string) {
+	if i := strings.Index(addr, "://"); i >= 0 {
+		return strings.ToLower(addr[:i]), addr[i+len("://"):]
+	}
+	return "", addr
+}
+
+// withRPCScheme returns the rpc address connecting over https for tls, or http for plaintext, replacing the scheme
+// of the address. The address is returned as is when no scheme is forced, or for unix sockets.
+func withRPCScheme(addr string, scheme ConnectionScheme) string {
+	urlScheme, hostPort := splitURLScheme(addr)
+	if scheme == "" || urlScheme == "unix" {
+		return addr
+	}
+	if scheme == schemeTLS {
+		return "https://" + hostPort
+	}
+	return "http://" + hostPort
+}
+
+// getRPCAddress returns the rpc address of the validator to connect to, with the rpc-scheme applied
+func (vm *ValidatorMonitor) getRPCAddress() string {
+	return withRPCScheme(vm.RPC, vm.RPCScheme)
+}
+
+// getRPCAddress returns the rpc address of the sentry to connect to, with the rpc-scheme applied
+func (sentry Sentry) getRPCAddress() string {
+	return withRPCScheme(sentry.RPC, sentry.RPCScheme)
+}
+
+// grpcTarget returns the address to dial the sentry's grpc at, and whether to connect with TLS. The scheme setting
+// takes precedence over the URL scheme of the grpc address, e.g. grpcs:// or h2c://, which takes precedence over tls.
+// A URL scheme is stripped from the address, and https and grpcs addresses without a port default to 443.
+func (sentry Sentry) grpcTarget() (string, bool, error) {
+	urlScheme, target := splitURLScheme(sentry.GRPC)
+	useTLS := sentry.TLS
+	if urlScheme != "" {
+		urlTLS, ok := grpcURLSchemes[urlScheme]
+		if !ok {
+			return "", false, fmt.Errorf("unsupported grpc URL scheme %s://, expected grpcs, https, grpc, http, or h2c", urlScheme)
+		}
+		useTLS = urlTLS
+		target = strings.TrimSuffix(target, "/")
+		if _, _, err := net.SplitHostPort(target); err != nil {
+			if !useTLS {
+				return "", false, fmt.Errorf("grpc address %s has no port", sentry.GRPC)
+			}
+			target = net.JoinHostPort(target, "443")
+		}
+	}
+	switch sentry.Scheme {
+	case schemeTLS:
+		useTLS = true
+	case schemePlaintext:
+		useTLS = false
+	}
+	return target, useTLS, nil
+}
@@ -0,0 +1,166 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+
+	"gopkg.in/yaml.v2"
+)
+
+// loadSecrets reads the secrets file and merges it over the config yaml. The secrets file has the same layout as
+// config.yaml, e.g. notifications.discord.webhook.token, and list entries such as validators and sentries are matched by name.
+func loadSecrets(dat []byte, secretsFile string) ([]byte, yaml.MapSlice, error) {
+	secretsDat, err := os.ReadFile(secretsFile)
+	if err != nil {
+		return nil, nil, fmt.Errorf("error reading secrets file %s: %w", secretsFile, err)
+	}
+	var secrets yaml.MapSlice
+	if err := yaml.Unmarshal(secretsDat, &secrets); err != nil {
+		return nil, nil, fmt.Errorf("error parsing secrets file %s: %w", secretsFile, err)
+	}
+	var merged yaml.MapSlice
+	if err := yaml.Unmarshal(dat, &merged); err != nil {
+		return nil, nil, err
+	}
+	mergedDat, err := yaml.Marshal(mergeSecrets(merged, secrets, ""))
+	if err != nil {
+		return nil, nil, err
+	}
+	return mergedDat, secrets, nil
+}
+
+func getMapSliceValue(m yaml.MapSlice, key interface{}) (int, bool) {
+	for i, item := range m {
+		if item.Key == key {
+			return i, true
+		}
+	}
+	return -1, false
+}
+
+// getNamedEntry returns the index of the list entry with the name
+func getNamedEntry(list []interface{}, name interface{}) (int, bool) {
+	for i, entry := range list {
+		if m, ok := entry.(yaml.MapSlice); ok {
+			if entryName, ok := getMapSliceValue(m, "name"); ok && m[entryName].Value == name {
+				return i, true
+			}
+		}
+	}
+	return -1, false
+}
+
+// mergeSecrets merges the secret values over the config values, the path names the value for logs
+func mergeSecrets(value interface{}, secret interface{}, path string) interface{} {
+	switch secret := secret.(type) {
+	case yaml.MapSlice:
+		m, ok := value.(yaml.MapSlice)
+		if !ok {
+			return secret
+		}
+		for _, item := range secret {
+			i, ok := getMapSliceValue(m, item.Key)
+			if !ok {
+				m = append(m, item)
+				continue
+			}
+			itemPath := fmt.Sprint(item.Key)
+			if path != "" {
+				itemPath = path + "." + itemPath
+			}
+			m[i].Value = mergeSecrets(m[i].Value, item.Value, itemPath)
+		}
+		return m
+	case []interface{}:
+		list, ok := value.([]interface{})
+		if !ok {
+			return secret
+		}
+		for _, entry := range secret {
+			m, ok := entry.(yaml.MapSlice)
+			if !ok {
+				// secrets without names replace the whole list
+				return secret
+			}
+			name, ok := getMapSliceValue(m, "name")
+			if !ok {
+				return secret
+			}
+			i, ok := getNamedEntry(list, m[name].Value)
+			if !ok {
+				fmt.Printf("Secrets for %s %v don't match a name in the config, ignoring\n", path, m[name].Value)
+				continue
+			}
+			list[i] = mergeSecrets(list[i], m, path)
+		}
+		return list
+	default:
+		return secret
+	}
+}
+
+// stripSecrets removes the values provided by the secrets file from the config yaml, so that they are never saved to it.
+// The names that list entries are matched by are kept.
+func stripSecrets(value interface{}, secret interface{}) interface{} {
+	switch secret := secret.(type) {
+	case yaml.MapSlice:
+		m, ok := value.(yaml.MapSlice)
+		if !ok {
+			return value
+		}
+		stripped := yaml.MapSlice{}
+		for _, item := range m {
+			i, ok := getMapSliceValue(secret, item.Key)
+			if !ok {
+				stripped = append(stripped, item)
+				continue
+			}
+			switch secret[i].Value.(type) {
+			case yaml.MapSlice, []interface{}:
+				if item.Value = stripSecrets(item.Value, secret[i].Value); item.Value != nil {
+					stripped = append(stripped, item)
+				}
+			}
+		}
+		return stripped
+	case []interface{}:
+		list, ok := value.([]interface{})
+		if !ok {
+			return value
+		}
+		for _, entry := range secret {
+			m, ok := entry.(yaml.MapSlice)
+			if !ok {
+				return nil
+			}
+			name, ok := getMapSliceValue(m, "name")
+			if !ok {
+				return nil
+			}
+			if i, ok := getNamedEntry(list, m[name].Value); ok {
+				withoutName := append(yaml.MapSlice{}, m[:name]...)
+				withoutName = append(withoutName, m[name+1:]...)
+				list[i] = stripSecrets(list[i], withoutName)
+			}
+		}
+		return list
+	default:
+		return value
+	}
+}
+
+// marshalWithoutSecrets marshals the config for saving, without the values from the secrets file or the profiles
+func (c *HalfLifeConfig) marshalWithoutSecrets() ([]byte, error) {
+	yamlBytes, err := yaml.Marshal(c)
+	if err != nil || (c.secrets == nil && !c.hasProfileReferences()) {
+		return yamlBytes, err
+	}
+	var saved yaml.MapSlice
+	if err := yaml.Unmarshal(yamlBytes, &saved); err != nil {
+		return nil, err
+	}
+	if c.secrets != nil {
+		saved, _ = stripSecrets(saved, c.secrets).(yaml.MapSlice)
+	}
+	return yaml.Marshal(c.stripProfiles(saved))
+}
@@ -0,0 +1,105 @@
+package cmd
+
+import (
+	"errors"
+	"sync"
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/connectivity"
+	"google.golang.org/grpc/keepalive"
+	"google.golang.org/grpc/status"
+)
+
+// sentryConns caches sentry grpc connections for sentries configured to reuse connections
+var sentryConns = sentryConnCache{conns: make(map[string]*grpc.ClientConn)}
+
+type sentryConnCache struct {
+	lock  sync.Mutex
+	conns map[string]*grpc.ClientConn
+}
+
+// get returns the cached connection for the key, and whether it was cached, dialing a new connection if needed
+func (cache *sentryConnCache) get(key string, target string, dialOptions []grpc.DialOption) (*grpc.ClientConn, bool, error) {
+	cache.lock.Lock()
+	defer cache.lock.Unlock()
+	if conn, ok := cache.conns[key]; ok {
+		switch conn.GetState() {
+		case connectivity.TransientFailure, connectivity.Shutdown:
+			// don't reuse a dead channel
+			conn.Close()
+			delete(cache.conns, key)
+		default:
+			return conn, true, nil
+		}
+	}
+	conn, err := grpc.Dial(target, dialOptions...)
+	if err != nil {
+		return nil, false, err
+	}
+	cache.conns[key] = conn
+	return conn, false, nil
+}
+
+// drop closes and removes the connection from the cache, so the next check reconnects
+func (cache *sentryConnCache) drop(key string, conn *grpc.ClientConn) {
+	cache.lock.Lock()
+	defer cache.lock.Unlock()
+	if cache.conns[key] == conn {
+		delete(cache.conns, key)
+	}
+	conn.Close()
+}
+
+// getSentryGRPCConfig returns the sentry's grpc connection settings, falling back to the global settings
+func (sentry Sentry) getSentryGRPCConfig(global *SentryGRPCConfig) SentryGRPCConfig {
+	settings := sentry.SentryGRPCConfig
+	if global == nil {
+		return settings
+	}
+	if settings.KeepaliveTime == nil {
+		settings.KeepaliveTime = global.KeepaliveTime
+	}
+	if settings.KeepaliveTimeout == nil {
+		settings.KeepaliveTimeout = global.KeepaliveTimeout
+	}
+	if settings.ReuseConnection == nil {
+		settings.ReuseConnection = global.ReuseConnection
+	}
+	return settings
+}
+
+func (settings SentryGRPCConfig) reuseConnection() bool {
+	return settings.ReuseConnection != nil && *settings.ReuseConnection
+}
+
+// keepaliveDialOption returns the keepalive dial option, or nil when keepalive is not configured
+func (settings SentryGRPCConfig) keepaliveDialOption() grpc.DialOption {
+	if settings.KeepaliveTime == nil && settings.KeepaliveTimeout == nil {
+		return nil
+	}
+	params := keepalive.ClientParameters{PermitWithoutStream: true}
+	if settings.KeepaliveTime != nil {
+		params.Time = *settings.KeepaliveTime
+	}
+	if settings.KeepaliveTimeout != nil {
+		params.Timeout = *settings.KeepaliveTimeout
+	}
+	return grpc.WithKeepaliveParams(params)
+}
+
+func (settings SentryGRPCConfig) validate() error {
+	if settings.KeepaliveTime != nil && *settings.KeepaliveTime < 10*time.Second {
+		return errors.New("keepalive-time must be at least 10s")
+	}
+	if settings.KeepaliveTimeout != nil && *settings.KeepaliveTimeout <= 0 {
+		return errors.New("keepalive-timeout must be positive")
+	}
+	return nil
+}
+
+// isGRPCTransportError returns true for errors from the connection rather than from the sentry's response
+func isGRPCTransportError(err error) bool {
+	return status.Code(err) == codes.Unavailable
+}
@@ -0,0 +1,27 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+)
+
+// startHTTPServer serves the registered endpoints in the background when a listen address is configured
+func startHTTPServer(config *HalfLifeConfig, mux *http.ServeMux) {
+	if config.HTTP == nil || config.HTTP.Listen == "" {
+		return
+	}
+	go func() {
+		fmt.Printf("Serving HTTP endpoints on %s\n", config.HTTP.Listen)
+		if err := http.ListenAndServe(config.HTTP.Listen, mux); err != nil {
+			fmt.Printf("Error serving HTTP endpoints: %v\n", err)
+		}
+	}()
+}
+
+func writeJSON(w http.ResponseWriter, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(v); err != nil {
+		fmt.Printf("Error writing HTTP response: %v\n", err)
+	}
+}
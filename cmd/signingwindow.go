@@ -0,0 +1,224 @@
+package cmd
+
+import (
+	"context"
+	"encoding/binary"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	slashingtypes "github.com/cosmos/cosmos-sdk/x/slashing/types"
+	rpcclient "github.com/tendermint/tendermint/rpc/client"
+)
+
+// SDKVersion selects how the recent missed blocks of a validator are determined
+type SDKVersion string
+
+const (
+	// detected from the CometBFT version of the rpc node, the default
+	sdkVersionAuto SDKVersion = "auto"
+	// chains before cosmos-sdk v0.50, the missed blocks are counted from the signatures of the recent blocks
+	sdkVersionLegacy SDKVersion = "legacy"
+	// chains on cosmos-sdk v0.50 or later, the missed blocks are read from the slashing missed blocks bitmap
+	sdkVersionV050 SDKVersion = "v0.50"
+)
+
+var sdkVersions = []SDKVersion{sdkVersionAuto, sdkVersionLegacy, sdkVersionV050}
+
+const (
+	missedBlockBitmapStorePath     = "/store/slashing/key"
+	missedBlockBitmapKeyPrefix     = 0x02 // ValidatorMissedBlockBitmapKeyPrefix of the v0.50 slashing store
+	missedBlockBitmapChunkSize     = 1024 // signing window indexes in each chunk of the bitmap
+	missedBlockBitmapCometBFTMinor = 38   // cosmos-sdk v0.50 is the first release on CometBFT v0.38
+)
+
+func (vm *ValidatorMonitor) getSDKVersion() SDKVersion {
+	if vm.SDKVersion == "" {
+		return sdkVersionAuto
+	}
+	return vm.SDKVersion
+}
+
+func (v SDKVersion) validate() error {
+	for _, sdkVersion := range sdkVersions {
+		if v == sdkVersion {
+			return nil
+		}
+	}
+	return fmt.Errorf("unsupported sdk-version %q, expected auto, legacy, or v0.50", v)
+}
+
+// usesMissedBlockBitmap returns whether the recent missed blocks are read from the missed blocks bitmap. Detected
+// from the CometBFT version of the node, e.g. 0.38.12, as cosmos-sdk v0.50 and later run on CometBFT v0.38 or later.
+func (vm *ValidatorMonitor) usesMissedBlockBitmap(nodeVersion string) bool {
+	if !vm.getChainType().hasSDKModules() {
+		return false
+	}
+	switch vm.getSDKVersion() {
+	case sdkVersionLegacy:
+		return false
+	case sdkVersionV050:
+		return true
+	}
+	parts := strings.SplitN(strings.TrimPrefix(nodeVersion, "v"), ".", 3)
+	if len(parts) < 2 {
+		return false
+	}
+	major, err := strconv.Atoi(parts[0])
+	if err != nil {
+		return false
+	}
+	minor, err := strconv.Atoi(parts[1])
+	if err != nil {
+		return false
+	}
+	return major > 0 || minor >= missedBlockBitmapCometBFTMinor
+}
+
+// missedBlockBitmap reads the missed blocks of a validator in the signing window from the v0.50 slashing store.
+// The bitmap is stored in chunks of 1024 signing window indexes, each a bitset, and a missing chunk has no missed
+// blocks. Chunks are fetched once per check.
+type missedBlockBitmap struct {
+	node        rpcclient.Client
+	consAddress []byte
+	chunks      map[int64][]uint64
+}
+
+func newMissedBlockBitmap(node rpcclient.Client, consAddress []byte) *missedBlockBitmap {
+	return &missedBlockBitmap{node: node, consAddress: consAddress, chunks: make(map[int64][]uint64)}
+}
+
+// getMissedBlockBitmapKey returns the store key of a chunk, the prefix, the length prefixed consensus address, and
+// the big endian chunk index
+func getMissedBlockBitmapKey(consAddress []byte, chunk int64) []byte {
+	key := make([]byte, 2+len(consAddress)+8)
+	key[0], key[1] = missedBlockBitmapKeyPrefix, byte(len(consAddress))
+	copy(key[2:], consAddress)
+	binary.BigEndian.PutUint64(key[2+len(consAddress):], uint64(chunk))
+	return key
+}
+
+// decodeMissedBlockBitmapChunk decodes a bitset chunk: the number of bits, then the 64 bit words, all big endian
+func decodeMissedBlockBitmapChunk(chunk []byte) ([]uint64, error) {
+	if len(chunk) == 0 {
+		return nil, nil
+	}
+	if len(chunk) < 8 || (len(chunk)-8)%8 != 0 {
+		return nil, fmt.Errorf("invalid missed blocks bitmap chunk of %d bytes", len(chunk))
+	}
+	words := make([]uint64, (len(chunk)-8)/8)
+	for i := range words {
+		words[i] = binary.BigEndian.Uint64(chunk[8+i*8:])
+	}
+	return words, nil
+}
+
+// missed returns whether the block at the signing window index was missed
+func (bitmap *missedBlockBitmap) missed(ctx context.Context, index int64) (bool, error) {
+	chunkIndex, bit := index/missedBlockBitmapChunkSize, index%missedBlockBitmapChunkSize
+	words, ok := bitmap.chunks[chunkIndex]
+	if !ok {
+		queryCtx, cancel := context.WithTimeout(ctx, time.Duration(time.Second*RPCTimeoutSeconds))
+		res, err := bitmap.node.ABCIQueryWithOptions(queryCtx, missedBlockBitmapStorePath,
+			getMissedBlockBitmapKey(bitmap.consAddress, chunkIndex), rpcclient.ABCIQueryOptions{})
+		cancel()
+		if err != nil {
+			return false, fmt.Errorf("error querying missed blocks bitmap: %w", err)
+		}
+		if !res.Response.IsOK() {
+			return false, fmt.Errorf("error querying missed blocks bitmap: %s", res.Response.Log)
+		}
+		words, err = decodeMissedBlockBitmapChunk(res.Response.Value)
+		if err != nil {
+			return false, err
+		}
+		bitmap.chunks[chunkIndex] = words
+	}
+	if int(bit/64) >= len(words) {
+		return false, nil
+	}
+	return words[bit/64]&(1<<uint(bit%64)) != 0, nil
+}
+
+// getSigningWindowIndex returns the signing window index of the block that many blocks below the latest block
+// processed by the slashing module, false when the validator was not yet in the signing window at that block
+func getSigningWindowIndex(signingInfo slashingtypes.ValidatorSigningInfo, window int64, blocksBack int64) (int64, bool) {
+	processed := signingInfo.IndexOffset - 1 - blocksBack
+	if processed < 0 || blocksBack >= window {
+		return 0, false
+	}
+	return processed % window, true
+}
+
+// getBitmapRecentBlocks returns the recent blocks from the height down, from the bitmap in place of fetching the
+// blocks. The blocks have no timestamps or evidence, a double sign is alerted from the tombstoned signing info.
+func getBitmapRecentBlocks(
+	ctx context.Context,
+	bitmap *missedBlockBitmap,
+	signingInfo slashingtypes.ValidatorSigningInfo,
+	window int64,
+	height int64,
+	count int64,
+) ([]recentBlock, error) {
+	var blocks []recentBlock
+	for i := int64(0); i < count && height-i > 0; i++ {
+		index, ok := getSigningWindowIndex(signingInfo, window, i)
+		if !ok {
+			break
+		}
+		missed, err := bitmap.missed(ctx, index)
+		if err != nil {
+			return nil, err
+		}
+		blocks = append(blocks, recentBlock{height: height - i, info: blockSigningInfo{height: height - i, signed: !missed}})
+	}
+	return blocks, nil
+}
+
+// determineBitmapRecentBlocks counts the recent missed blocks from the bitmap. When the validator has not signed
+// the recent blocks, the last signed block is looked up in the rest of the signing window. Only the last signed block
+// is fetched, for its timestamp.
+func (stats *ValidatorStats) determineBitmapRecentBlocks(
+	ctx context.Context,
+	vm *ValidatorMonitor,
+	node rpcclient.Client,
+	bitmap *missedBlockBitmap,
+	signingInfo slashingtypes.ValidatorSigningInfo,
+	window int64,
+) ([]IgnorableError, error) {
+	recentBlocks, err := getBitmapRecentBlocks(ctx, bitmap, signingInfo, window, stats.Height, vm.RecentBlocksToCheck)
+	if err != nil {
+		return nil, err
+	}
+	errs := stats.determineRecentBlocks(vm, recentBlocks)
+	if stats.LastSignedBlockHeight == -1 && stats.RecentMissedBlocks > vm.getMissedBlocksThreshold() {
+		for i := vm.RecentBlocksToCheck; stats.Height-i > 0; i++ {
+			index, ok := getSigningWindowIndex(signingInfo, window, i)
+			if !ok {
+				break
+			}
+			missed, err := bitmap.missed(ctx, index)
+			if err != nil {
+				return nil, err
+			}
+			if !missed {
+				stats.LastSignedBlockHeight = stats.Height - i
+				break
+			}
+		}
+	}
+	switch stats.LastSignedBlockHeight {
+	case -1:
+	case stats.Height:
+		stats.LastSignedBlockTimestamp = stats.Timestamp
+	default:
+		blockCtx, blockCtxCancel := context.WithTimeout(ctx, time.Duration(time.Second*RPCTimeoutSeconds))
+		block, err := getCachedBlock(blockCtx, node, vm, stats.LastSignedBlockHeight)
+		blockCtxCancel()
+		if err == nil {
+			stats.LastSignedBlockTimestamp = block.Time
+		}
+	}
+	return errs, nil
+}
@@ -0,0 +1,290 @@
+package cmd
+
+import (
+	"context"
+	"encoding/binary"
+	"encoding/hex"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	slashingtypes "github.com/cosmos/cosmos-sdk/x/slashing/types"
+	abci "github.com/tendermint/tendermint/abci/types"
+	tmbytes "github.com/tendermint/tendermint/libs/bytes"
+	rpcclient "github.com/tendermint/tendermint/rpc/client"
+	coretypes "github.com/tendermint/tendermint/rpc/core/types"
+	tmtypes "github.com/tendermint/tendermint/types"
+)
+
+// recordedBitmapChunk is a v0.50 missed blocks bitmap chunk: a bitset of 1024 bits with indexes 0, 1, 65, and 1023
+// missed, the bit length then the words, big endian
+var recordedBitmapChunk = "0000000000000400" + "0000000000000003" + "0000000000000002" +
+	strings.Repeat("0000000000000000", 13) + "8000000000000000"
+
+var testConsAddress = []byte{
+	0x01, 0x02, 0x03, 0x04, 0x05, 0x06, 0x07, 0x08, 0x09, 0x0a,
+	0x0b, 0x0c, 0x0d, 0x0e, 0x0f, 0x10, 0x11, 0x12, 0x13, 0x14,
+}
+
+// fakeSigningNode serves the missed blocks bitmap chunks by store key, and the recorded blocks by height
+type fakeSigningNode struct {
+	rpcclient.Client
+	chunks  map[string][]byte
+	blocks  map[int64]*tmtypes.Block
+	paths   []string
+	queried []int64
+}
+
+func (node *fakeSigningNode) ABCIQueryWithOptions(
+	ctx context.Context,
+	path string,
+	data tmbytes.HexBytes,
+	opts rpcclient.ABCIQueryOptions,
+) (*coretypes.ResultABCIQuery, error) {
+	node.paths = append(node.paths, path)
+	node.queried = append(node.queried, int64(binary.BigEndian.Uint64(data[len(data)-8:])))
+	return &coretypes.ResultABCIQuery{Response: abci.ResponseQuery{Value: node.chunks[hex.EncodeToString(data)]}}, nil
+}
+
+func (node *fakeSigningNode) Block(ctx context.Context, height *int64) (*coretypes.ResultBlock, error) {
+	block, ok := node.blocks[*height]
+	if !ok {
+		return nil, errors.New("block not found")
+	}
+	return &coretypes.ResultBlock{Block: block}, nil
+}
+
+func mustDecodeHex(t *testing.T, s string) []byte {
+	t.Helper()
+	b, err := hex.DecodeString(s)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return b
+}
+
+func TestDecodeMissedBlockBitmapChunk(t *testing.T) {
+	words, err := decodeMissedBlockBitmapChunk(mustDecodeHex(t, recordedBitmapChunk))
+	if err != nil {
+		t.Fatalf("decoding recorded chunk: %v", err)
+	}
+	if len(words) != 16 {
+		t.Fatalf("decoded %d words, want 16", len(words))
+	}
+	bitmap := &missedBlockBitmap{chunks: map[int64][]uint64{0: words}}
+	for index := int64(0); index < missedBlockBitmapChunkSize; index++ {
+		want := index == 0 || index == 1 || index == 65 || index == 1023
+		missed, err := bitmap.missed(context.Background(), index)
+		if err != nil {
+			t.Fatalf("index %d: %v", index, err)
+		}
+		if missed != want {
+			t.Errorf("index %d missed = %t, want %t", index, missed, want)
+		}
+	}
+
+	// a chunk without missed blocks is not stored
+	words, err = decodeMissedBlockBitmapChunk(nil)
+	if err != nil || words != nil {
+		t.Errorf("decoding missing chunk = %v, %v, want no words", words, err)
+	}
+	for _, invalid := range []string{"00", "0000000000000400" + "000000000003"} {
+		if _, err := decodeMissedBlockBitmapChunk(mustDecodeHex(t, invalid)); err == nil {
+			t.Errorf("decoding %s returned no error", invalid)
+		}
+	}
+}
+
+func TestGetMissedBlockBitmapKey(t *testing.T) {
+	want := "02" + "14" + hex.EncodeToString(testConsAddress) + "0000000000000002"
+	if key := hex.EncodeToString(getMissedBlockBitmapKey(testConsAddress, 2)); key != want {
+		t.Errorf("key = %s, want %s", key, want)
+	}
+}
+
+func TestGetSigningWindowIndex(t *testing.T) {
+	const window = 10000
+	tests := []struct {
+		name        string
+		indexOffset int64
+		blocksBack  int64
+		want        int64
+		wantOK      bool
+	}{
+		{name: "latest", indexOffset: 10005, blocksBack: 0, want: 4, wantOK: true},
+		{name: "start of window", indexOffset: 10005, blocksBack: 4, want: 0, wantOK: true},
+		{name: "wraps to end of window", indexOffset: 10005, blocksBack: 5, want: 9999, wantOK: true},
+		{name: "last block in window", indexOffset: 10005, blocksBack: window - 1, want: 5, wantOK: true},
+		{name: "beyond window", indexOffset: 10005, blocksBack: window},
+		{name: "joined within window", indexOffset: 3, blocksBack: 2, want: 0, wantOK: true},
+		{name: "before joining", indexOffset: 3, blocksBack: 3},
+		{name: "no blocks processed", indexOffset: 0, blocksBack: 0},
+	}
+	for _, test := range tests {
+		signingInfo := slashingtypes.ValidatorSigningInfo{IndexOffset: test.indexOffset}
+		index, ok := getSigningWindowIndex(signingInfo, window, test.blocksBack)
+		if ok != test.wantOK || (ok && index != test.want) {
+			t.Errorf("%s: getSigningWindowIndex = %d, %t, want %d, %t", test.name, index, ok, test.want, test.wantOK)
+		}
+	}
+}
+
+func TestUsesMissedBlockBitmap(t *testing.T) {
+	tests := []struct {
+		sdkVersion  SDKVersion
+		chainType   ChainType
+		nodeVersion string
+		want        bool
+	}{
+		{nodeVersion: "0.34.27"},
+		{nodeVersion: "v0.37.4"},
+		{nodeVersion: "0.38.12", want: true},
+		{nodeVersion: "v0.38.0", want: true},
+		{nodeVersion: "1.0.0", want: true},
+		{nodeVersion: ""},
+		{nodeVersion: "unknown"},
+		{sdkVersion: sdkVersionLegacy, nodeVersion: "0.38.12"},
+		{sdkVersion: sdkVersionV050, nodeVersion: "0.37.4", want: true},
+		{chainType: chainTypeCometBFT, nodeVersion: "0.38.12"},
+	}
+	for _, test := range tests {
+		vm := &ValidatorMonitor{SDKVersion: test.sdkVersion, ChainType: test.chainType}
+		if got := vm.usesMissedBlockBitmap(test.nodeVersion); got != test.want {
+			t.Errorf("usesMissedBlockBitmap(%q) with sdk-version %q and chain-type %q = %t, want %t",
+				test.nodeVersion, test.sdkVersion, test.chainType, got, test.want)
+		}
+	}
+}
+
+// newSigningWindow records the blocks from the height down to the first height, signed unless missed, and the bitmap
+// chunks of the same misses in the signing window of the signing info
+func newSigningWindow(
+	height int64,
+	first int64,
+	missed map[int64]bool,
+	signingInfo slashingtypes.ValidatorSigningInfo,
+	window int64,
+) *fakeSigningNode {
+	node := &fakeSigningNode{blocks: make(map[int64]*tmtypes.Block), chunks: make(map[string][]byte)}
+	words := make(map[int64][]uint64)
+	for h := height; h >= first; h-- {
+		block := &tmtypes.Block{
+			Header:     tmtypes.Header{Height: h, Time: time.Unix(h*6, 0).UTC()},
+			LastCommit: &tmtypes.Commit{},
+		}
+		if !missed[h] {
+			block.LastCommit.Signatures = []tmtypes.CommitSig{{ValidatorAddress: testConsAddress}}
+		}
+		node.blocks[h] = block
+		index, ok := getSigningWindowIndex(signingInfo, window, height-h)
+		if !ok || !missed[h] {
+			continue
+		}
+		chunk, bit := index/missedBlockBitmapChunkSize, index%missedBlockBitmapChunkSize
+		if words[chunk] == nil {
+			words[chunk] = make([]uint64, missedBlockBitmapChunkSize/64)
+		}
+		words[chunk][bit/64] |= 1 << uint(bit%64)
+	}
+	for chunk, chunkWords := range words {
+		value := make([]byte, 8+8*len(chunkWords))
+		binary.BigEndian.PutUint64(value, missedBlockBitmapChunkSize)
+		for i, word := range chunkWords {
+			binary.BigEndian.PutUint64(value[8+8*i:], word)
+		}
+		node.chunks[hex.EncodeToString(getMissedBlockBitmapKey(testConsAddress, chunk))] = value
+	}
+	return node
+}
+
+func TestBitmapRecentBlocksMatchLegacy(t *testing.T) {
+	const (
+		height = int64(5000)
+		window = int64(10000)
+	)
+	tests := []struct {
+		name   string
+		missed []int64
+		// the signing window index of the latest block is 1030, the recent blocks cross into chunk 0 at 1023
+		indexOffset    int64
+		wantLastSigned int64
+	}{
+		{name: "streak across chunks", missed: []int64{4997, 4996, 4995, 4994, 4993, 4992, 4985}, indexOffset: 11031, wantLastSigned: height},
+		{name: "joined recently", missed: []int64{4999, 4998}, indexOffset: 12, wantLastSigned: height},
+		{name: "down", missed: getHeights(height, 4976), indexOffset: 11031, wantLastSigned: 4975},
+	}
+	for _, test := range tests {
+		vm := newTestValidatorMonitor()
+		missed := make(map[int64]bool)
+		for _, h := range test.missed {
+			missed[h] = true
+		}
+		signingInfo := slashingtypes.ValidatorSigningInfo{IndexOffset: test.indexOffset}
+		node := newSigningWindow(height, height-window+1, missed, signingInfo, window)
+
+		// the recent blocks counted from the signatures of the recorded blocks
+		legacy := &ValidatorStats{Height: height, LastSignedBlockHeight: -1}
+		blocks := scanRecentBlocks(context.Background(), height, vm.RecentBlocksToCheck, defaultRecentBlocksConcurrency,
+			func(ctx context.Context, h int64) (blockSigningInfo, error) {
+				block, err := node.Block(ctx, &h)
+				if err != nil {
+					return blockSigningInfo{}, err
+				}
+				return getBlockSigningInfo(block.Block, testConsAddress), nil
+			})
+		// blocks before the validator joined the signing window are not in the bitmap
+		joined := height - test.indexOffset + 1
+		var joinedBlocks []recentBlock
+		for _, block := range blocks {
+			if block.height >= joined {
+				joinedBlocks = append(joinedBlocks, block)
+			}
+		}
+		legacyErrs := legacy.determineRecentBlocks(vm, joinedBlocks)
+
+		bitmapStats := &ValidatorStats{Height: height, Timestamp: node.blocks[height].Time, LastSignedBlockHeight: -1}
+		bitmapErrs, err := bitmapStats.determineBitmapRecentBlocks(context.Background(), vm, node,
+			newMissedBlockBitmap(node, testConsAddress), signingInfo, window)
+		if err != nil {
+			t.Fatalf("%s: %v", test.name, err)
+		}
+
+		wantMissed := int64(len(test.missed))
+		if wantMissed > vm.RecentBlocksToCheck {
+			wantMissed = vm.RecentBlocksToCheck
+		}
+		if bitmapStats.RecentMissedBlocks != wantMissed || legacy.RecentMissedBlocks != wantMissed {
+			t.Errorf("%s: bitmap missed %d, legacy missed %d, want %d", test.name, bitmapStats.RecentMissedBlocks, legacy.RecentMissedBlocks, wantMissed)
+		}
+		if bitmapStats.RecentMissedBlockStreak != legacy.RecentMissedBlockStreak {
+			t.Errorf("%s: bitmap streak %d, legacy streak %d", test.name, bitmapStats.RecentMissedBlockStreak, legacy.RecentMissedBlockStreak)
+		}
+		if len(bitmapErrs) != len(legacyErrs) {
+			t.Errorf("%s: bitmap errors %v, legacy errors %v", test.name, bitmapErrs, legacyErrs)
+		}
+		if bitmapStats.LastSignedBlockHeight != test.wantLastSigned {
+			t.Errorf("%s: last signed %d, want %d", test.name, bitmapStats.LastSignedBlockHeight, test.wantLastSigned)
+		}
+		if want := node.blocks[test.wantLastSigned].Time; !bitmapStats.LastSignedBlockTimestamp.Equal(want) {
+			t.Errorf("%s: last signed timestamp %s, want %s", test.name, bitmapStats.LastSignedBlockTimestamp, want)
+		}
+		for _, path := range node.paths {
+			if path != missedBlockBitmapStorePath {
+				t.Errorf("%s: queried %s, want %s", test.name, path, missedBlockBitmapStorePath)
+			}
+		}
+		if queried := len(node.queried); queried > 3 {
+			t.Errorf("%s: queried %d chunks, each chunk should be queried once", test.name, queried)
+		}
+	}
+}
+
+// getHeights returns the heights from the height down to the last height
+func getHeights(height int64, last int64) []int64 {
+	var heights []int64
+	for h := height; h >= last; h-- {
+		heights = append(heights, h)
+	}
+	return heights
+}
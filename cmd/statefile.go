@@ -0,0 +1,109 @@
+package cmd
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+
+	"gopkg.in/yaml.v2"
+)
+
+// savedState is the state halflife owns, written to the state file so that the config is never rewritten.
+// Validators are keyed by name in a map, which yaml.v2 writes in sorted order, so the file only changes with the state.
+type savedState struct {
+	StatusSummaryMessageID *string                        `yaml:"status-summary-message-id,omitempty"`
+	FleetHealthMessageID   *string                        `yaml:"fleet-health-message-id,omitempty"`
+	Validators             map[string]savedValidatorState `yaml:"validators,omitempty"`
+}
+
+type savedValidatorState struct {
+	DiscordStatusMessageID *string `yaml:"discord-status-message-id,omitempty"`
+	MatrixStatusEventID    *string `yaml:"matrix-status-event-id,omitempty"`
+
+	// the address resolved from the moniker, and the chain-id it was resolved on
+	Address        string `yaml:"address,omitempty"`
+	MonikerChainID string `yaml:"moniker-chain-id,omitempty"`
+
+	UptimeSamples []UptimeSample `yaml:"uptime-samples,omitempty"` // for the uptime trend across restarts
+}
+
+func (c *HalfLifeConfig) getSavedState() savedState {
+	state := savedState{Validators: make(map[string]savedValidatorState)}
+	if c.Notifications != nil && c.Notifications.Discord != nil {
+		state.StatusSummaryMessageID = c.Notifications.Discord.StatusSummaryMessageID
+		state.FleetHealthMessageID = c.Notifications.Discord.FleetHealthMessageID
+	}
+	for _, vm := range c.Validators {
+		vmState := savedValidatorState{
+			DiscordStatusMessageID: vm.DiscordStatusMessageID,
+			MatrixStatusEventID:    vm.MatrixStatusEventID,
+		}
+		if vm.MonikerChainID != "" {
+			vmState.Address = vm.Address
+			vmState.MonikerChainID = vm.MonikerChainID
+		}
+		vmState.UptimeSamples = uptimeTrends.get(vm.Name)
+		if vmState.DiscordStatusMessageID != nil || vmState.MatrixStatusEventID != nil || vmState.Address != "" || len(vmState.UptimeSamples) > 0 {
+			state.Validators[vm.Name] = vmState
+		}
+	}
+	return state
+}
+
+// loadStateFile restores the status message IDs and resolved addresses from the state file, if it exists.
+// Values set in the config take precedence.
+func (c *HalfLifeConfig) loadStateFile() {
+	dat, err := os.ReadFile(c.StateFile)
+	if err != nil {
+		if !os.IsNotExist(err) {
+			fmt.Printf("Error reading state file %s: %v\n", c.StateFile, err)
+		}
+		return
+	}
+	var state savedState
+	if err := yaml.Unmarshal(dat, &state); err != nil {
+		fmt.Printf("Error parsing state file %s: %v\n", c.StateFile, err)
+		return
+	}
+	if c.Notifications != nil && c.Notifications.Discord != nil {
+		if c.Notifications.Discord.StatusSummaryMessageID == nil {
+			c.Notifications.Discord.StatusSummaryMessageID = state.StatusSummaryMessageID
+		}
+		if c.Notifications.Discord.FleetHealthMessageID == nil {
+			c.Notifications.Discord.FleetHealthMessageID = state.FleetHealthMessageID
+		}
+	}
+	for _, vm := range c.Validators {
+		vmState, ok := state.Validators[vm.Name]
+		if !ok {
+			continue
+		}
+		if vm.DiscordStatusMessageID == nil {
+			vm.DiscordStatusMessageID = vmState.DiscordStatusMessageID
+		}
+		if vm.MatrixStatusEventID == nil {
+			vm.MatrixStatusEventID = vmState.MatrixStatusEventID
+		}
+		if vm.Address == "" && vm.Moniker != "" {
+			vm.Address = vmState.Address
+			vm.MonikerChainID = vmState.MonikerChainID
+		}
+		uptimeTrends.restore(vm.Name, vmState.UptimeSamples)
+	}
+}
+
+// saveStateFile writes the state file when the state changed since it was last written
+func (c *HalfLifeConfig) saveStateFile() error {
+	yamlBytes, err := yaml.Marshal(c.getSavedState())
+	if err != nil {
+		return err
+	}
+	if existing, err := os.ReadFile(c.StateFile); err == nil && bytes.Equal(existing, yamlBytes) {
+		return nil
+	}
+	fileMode, err := c.getFileMode()
+	if err != nil {
+		return err
+	}
+	return writeFileAtomic(c.StateFile, yamlBytes, fileMode)
+}
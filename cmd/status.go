@@ -0,0 +1,213 @@
+package cmd
+
+import (
+	"fmt"
+	"net/http"
+	"sync"
+	"time"
+)
+
+const defaultStatusGroup = "default"
+
+// StatusSummarySender is implemented by notification services that can post
+// a single status summary of all validators, grouped by validator group
+type StatusSummarySender interface {
+	UpdateStatusSummary(configFile string, config *HalfLifeConfig, summary *StatusSummary, writeConfigMutex *sync.Mutex) error
+}
+
+// ValidatorStatus is the latest check result for a validator
+type ValidatorStatus struct {
+	Name                string     `json:"name"`
+	ChainID             string     `json:"chain-id"`
+	Group               string     `json:"group"`
+	Tags                []string   `json:"tags,omitempty"`
+	FullNode            bool       `json:"fullnode,omitempty"`
+	AlertLevel          AlertLevel `json:"alert-level"`
+	Height              int64      `json:"height"`
+	Timestamp           time.Time  `json:"timestamp"`
+	Uptime              float64    `json:"uptime"`
+	RecentMissedBlocks  int64      `json:"recent-missed-blocks"`
+	RecentBlocksChecked int64      `json:"recent-blocks-checked"`
+	RPCError            bool       `json:"rpc-error"`
+	Updated             time.Time  `json:"updated"` // zero until the validator has been checked
+
+	RPCLastSuccess         *time.Time     `json:"rpc-last-success,omitempty"`
+	RPCConsecutiveFailures int64          `json:"rpc-consecutive-failures"`
+	Sentries               []SentryStatus `json:"sentries,omitempty"`
+
+	RecentMissedBlockStreak int64 `json:"recent-missed-block-streak"`
+
+	UptimeTrend          *float64 `json:"uptime-trend,omitempty"` // percentage points per hour, when known
+	UptimeTrendDirection string   `json:"uptime-trend-direction,omitempty"`
+}
+
+// SentryStatus is the health of a sentry's gRPC endpoint at the latest check
+type SentryStatus struct {
+	Name          string `json:"name"`
+	GRPCReachable bool   `json:"grpc-reachable"`
+	Status        string `json:"status"` // ok, grpc-error, out-of-sync, halted, or low-peers
+}
+
+// StatusGroup is the status of the validators in a group, with the worst alert level in the group
+type StatusGroup struct {
+	Name       string            `json:"name"`
+	AlertLevel AlertLevel        `json:"alert-level"`
+	Validators []ValidatorStatus `json:"validators"`
+}
+
+// StatusSummary is the status of all validators by group
+type StatusSummary struct {
+	AlertLevel AlertLevel    `json:"alert-level"`
+	Groups     []StatusGroup `json:"groups"`
+}
+
+// getGroup returns the validator's group, or the default group when it is not set
+func (vm *ValidatorMonitor) getGroup() string {
+	if vm.Group == "" {
+		return defaultStatusGroup
+	}
+	return vm.Group
+}
+
+func (vm *ValidatorMonitor) hasTag(tag string) bool {
+	for _, t := range vm.Tags {
+		if t == tag {
+			return true
+		}
+	}
+	return false
+}
+
+// validatorStatuses holds the latest status of each validator by validator name
+var validatorStatuses = validatorStatusRegistry{statuses: make(map[string]ValidatorStatus)}
+
+type validatorStatusRegistry struct {
+	lock     sync.Mutex
+	statuses map[string]ValidatorStatus
+}
+
+func (registry *validatorStatusRegistry) update(vm *ValidatorMonitor, stats ValidatorStats) {
+	status := ValidatorStatus{
+		Name:                vm.Name,
+		ChainID:             vm.ChainID,
+		Group:               vm.getGroup(),
+		Tags:                vm.Tags,
+		FullNode:            vm.FullNode,
+		AlertLevel:          stats.AlertLevel,
+		Height:              stats.Height,
+		Timestamp:           stats.Timestamp,
+		Uptime:              stats.SlashingPeriodUptime,
+		RecentMissedBlocks:  stats.RecentMissedBlocks,
+		RecentBlocksChecked: vm.RecentBlocksToCheck,
+		RPCError:            stats.RPCError,
+		Updated:             time.Now(),
+
+		RPCConsecutiveFailures: stats.RPCConsecutiveFailures,
+
+		RecentMissedBlockStreak: stats.RecentMissedBlockStreak,
+	}
+	if !stats.RPCLastSuccess.IsZero() {
+		rpcLastSuccess := stats.RPCLastSuccess
+		status.RPCLastSuccess = &rpcLastSuccess
+	}
+	if stats.UptimeTrendKnown {
+		uptimeTrend := stats.UptimeTrend
+		status.UptimeTrend = &uptimeTrend
+		status.UptimeTrendDirection = getUptimeTrendDirection(uptimeTrend)
+	}
+	for _, sentryStats := range stats.SentryStats {
+		status.Sentries = append(status.Sentries, SentryStatus{
+			Name:          sentryStats.Name,
+			GRPCReachable: sentryStats.grpcReachable(),
+			Status:        sentryAlertTypeNames[sentryStats.SentryAlertType],
+		})
+	}
+	registry.lock.Lock()
+	defer registry.lock.Unlock()
+	registry.statuses[vm.Name] = status
+}
+
+// summary groups the validators' latest statuses, groups are in the order they first appear
+// in the config with the default group last. The tag filters the validators when not empty.
+func (registry *validatorStatusRegistry) summary(validators []*ValidatorMonitor, tag string) *StatusSummary {
+	registry.lock.Lock()
+	defer registry.lock.Unlock()
+	summary := &StatusSummary{AlertLevel: alertLevelNone}
+	groupIndex := make(map[string]int)
+	var defaultGroup *StatusGroup
+	for _, vm := range validators {
+		if tag != "" && !vm.hasTag(tag) {
+			continue
+		}
+		status, ok := registry.statuses[vm.Name]
+		if !ok {
+			status = ValidatorStatus{
+				Name:     vm.Name,
+				ChainID:  vm.ChainID,
+				Group:    vm.getGroup(),
+				Tags:     vm.Tags,
+				FullNode: vm.FullNode,
+			}
+		}
+		var group *StatusGroup
+		if status.Group == defaultStatusGroup {
+			if defaultGroup == nil {
+				defaultGroup = &StatusGroup{Name: defaultStatusGroup, AlertLevel: alertLevelNone}
+			}
+			group = defaultGroup
+		} else {
+			i, ok := groupIndex[status.Group]
+			if !ok {
+				i = len(summary.Groups)
+				groupIndex[status.Group] = i
+				summary.Groups = append(summary.Groups, StatusGroup{Name: status.Group, AlertLevel: alertLevelNone})
+			}
+			group = &summary.Groups[i]
+		}
+		group.Validators = append(group.Validators, status)
+		if status.AlertLevel > group.AlertLevel {
+			group.AlertLevel = status.AlertLevel
+		}
+		if status.AlertLevel > summary.AlertLevel {
+			summary.AlertLevel = status.AlertLevel
+		}
+	}
+	if defaultGroup != nil {
+		summary.Groups = append(summary.Groups, *defaultGroup)
+	}
+	return summary
+}
+
+// serveStatus serves the status summary as JSON, optionally filtered by the tag query parameter
+func serveStatus(validators []*ValidatorMonitor) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		writeJSON(w, validatorStatuses.summary(validators, r.URL.Query().Get("tag")))
+	}
+}
+
+// getStatusSummarySender returns the underlying notification service when it can post a status summary
+func getStatusSummarySender(service NotificationService) (StatusSummarySender, bool) {
+	for service != nil {
+		if sender, ok := service.(StatusSummarySender); ok {
+			return sender, true
+		}
+		service = unwrapNotificationService(service)
+	}
+	return nil, false
+}
+
+// runStatusSummary posts the grouped status summary every check interval
+func runStatusSummary(
+	sender StatusSummarySender,
+	configFile string,
+	config *HalfLifeConfig,
+	validators []*ValidatorMonitor,
+	writeConfigMutex *sync.Mutex,
+) {
+	for {
+		time.Sleep(checkInterval)
+		if err := sender.UpdateStatusSummary(configFile, config, validatorStatuses.summary(validators, ""), writeConfigMutex); err != nil {
+			fmt.Printf("Error updating status summary: %s\n", redactError(err))
+		}
+	}
+}
@@ -0,0 +1,205 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+	"sync"
+	"text/tabwriter"
+	"time"
+
+	"github.com/spf13/cobra"
+)
+
+const statusQueryTimeout = 5 * time.Second
+
+var alertLevelANSIColors = map[AlertLevel]string{
+	alertLevelNone:     "\033[32m",   // green
+	alertLevelWarning:  "\033[33m",   // yellow
+	alertLevelHigh:     "\033[31m",   // red
+	alertLevelCritical: "\033[1;31m", // bold red
+}
+
+const ansiReset = "\033[0m"
+
+var statusCmd = &cobra.Command{
+	Use:   "status",
+	Short: "Show the status of validators",
+	Long: "Queries the /status endpoint of a running half-life monitor and prints the status of each validator. " +
+		"When no monitor is reachable, a single check of each validator is run instead, without sending notifications.",
+	Run: func(cmd *cobra.Command, args []string) {
+		// config loading and the checks log to stdout, so it is redirected to stderr to keep the status output parseable
+		stdout := os.Stdout
+		os.Stdout = os.Stderr
+
+		configFile, err := getConfigFile(cmd)
+		if err != nil {
+			log.Fatalf("Error resolving config file: %v", err)
+		}
+		config, err := loadConfig(configFile)
+		if err != nil {
+			log.Fatalf("Error loading config: %s", redactError(err))
+		}
+		statusURL, _ := cmd.Flags().GetString("url")
+		tag, _ := cmd.Flags().GetString("tag")
+		asJSON, _ := cmd.Flags().GetBool("json")
+
+		if statusURL == "" {
+			statusURL = getStatusURL(config)
+		}
+		var summary *StatusSummary
+		if statusURL != "" {
+			summary, err = queryStatus(statusURL, tag)
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "Could not query half-life at %s: %v\n", statusURL, err)
+			}
+		}
+		if summary == nil {
+			fmt.Fprintln(os.Stderr, "Running a single check of each validator")
+			summary = runStatusCheck(configFile, config, tag)
+		}
+
+		if asJSON {
+			encoder := json.NewEncoder(stdout)
+			encoder.SetIndent("", "  ")
+			if err := encoder.Encode(summary); err != nil {
+				log.Fatalf("Error writing status: %v", err)
+			}
+			return
+		}
+		printStatusTable(stdout, summary, useANSIColors(stdout))
+	},
+}
+
+// getStatusURL returns the /status URL of the http listen address in the config, or empty when it is not set
+func getStatusURL(config *HalfLifeConfig) string {
+	if config.HTTP == nil || config.HTTP.Listen == "" {
+		return ""
+	}
+	host := config.HTTP.Listen
+	if strings.HasPrefix(host, ":") {
+		host = "localhost" + host
+	}
+	return "http://" + host + "/status"
+}
+
+func queryStatus(statusURL string, tag string) (*StatusSummary, error) {
+	if tag != "" {
+		u, err := url.Parse(statusURL)
+		if err != nil {
+			return nil, err
+		}
+		query := u.Query()
+		query.Set("tag", tag)
+		u.RawQuery = query.Encode()
+		statusURL = u.String()
+	}
+	client := http.Client{Timeout: statusQueryTimeout}
+	res, err := client.Get(statusURL)
+	if err != nil {
+		return nil, err
+	}
+	defer res.Body.Close()
+	if res.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("unexpected response: %s", res.Status)
+	}
+	var summary StatusSummary
+	if err := json.NewDecoder(res.Body).Decode(&summary); err != nil {
+		return nil, fmt.Errorf("error parsing status: %w", err)
+	}
+	return &summary, nil
+}
+
+// statusCheckService discards notifications, so that the single check of the status command never alerts
+type statusCheckService struct{}
+
+// implements NotificationService interface
+func (statusCheckService) SendValidatorAlertNotification(*HalfLifeConfig, *ValidatorMonitor, ValidatorStats, *ValidatorAlertNotification) error {
+	return nil
+}
+
+// implements NotificationService interface
+func (statusCheckService) UpdateValidatorRealtimeStatus(string, *HalfLifeConfig, *ValidatorMonitor, ValidatorStats, *sync.Mutex) error {
+	return nil
+}
+
+// implements NotificationService interface
+func (statusCheckService) CheckReachability() error {
+	return nil
+}
+
+// runStatusCheck runs a single check of each enabled validator and returns the status summary
+func runStatusCheck(configFile string, config *HalfLifeConfig, tag string) *StatusSummary {
+	validators := config.enabledValidators()
+	var checked []*ValidatorMonitor
+	for _, vm := range validators {
+		if tag != "" && !vm.hasTag(tag) {
+			continue
+		}
+		if err := resolveValidatorAddress(config, vm); err != nil {
+			fmt.Printf("Error resolving validator %s: %s\n", vm.Name, redactError(err))
+			continue
+		}
+		checked = append(checked, vm)
+	}
+	sharedRPCs.register(checked)
+	alertState := make(map[string]*ValidatorAlertState)
+	for _, vm := range checked {
+		alertState[vm.Name] = newValidatorAlertState()
+	}
+	writeConfigMutex := sync.Mutex{}
+	runMonitorOnce(statusCheckService{}, alertState, configFile, config, checked, &writeConfigMutex, nil)
+	return validatorStatuses.summary(validators, tag)
+}
+
+// useANSIColors is true when the output is a terminal and NO_COLOR is not set
+func useANSIColors(out *os.File) bool {
+	if _, ok := os.LookupEnv("NO_COLOR"); ok {
+		return false
+	}
+	info, err := out.Stat()
+	return err == nil && info.Mode()&os.ModeCharDevice != 0
+}
+
+// printStatusTable prints a row for each validator, the alert level is last so that its color does not affect the alignment
+func printStatusTable(out io.Writer, summary *StatusSummary, colors bool) {
+	w := tabwriter.NewWriter(out, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "GROUP\tNAME\tCHAIN ID\tHEIGHT\tUPTIME\tMISSED\tALERT LEVEL")
+	for _, group := range summary.Groups {
+		for _, status := range group.Validators {
+			height, uptime, missed := "N/A", "N/A", "N/A"
+			if status.Height > 0 && !status.RPCError {
+				height = fmt.Sprint(status.Height)
+			}
+			if status.Uptime > 0 && !status.FullNode {
+				uptime = fmt.Sprintf("%.02f%%", status.Uptime)
+			}
+			if status.RecentBlocksChecked > 0 && !status.FullNode && !status.Updated.IsZero() {
+				missed = fmt.Sprintf("%d/%d", status.RecentMissedBlocks, status.RecentBlocksChecked)
+			}
+			alertLevel := status.AlertLevel.String()
+			if status.Updated.IsZero() {
+				alertLevel = "not checked"
+			} else if status.RPCError {
+				alertLevel += " (rpc error)"
+			}
+			if colors {
+				alertLevel = alertLevelANSIColors[status.AlertLevel] + alertLevel + ansiReset
+			}
+			fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\t%s\t%s\n", group.Name, status.Name, status.ChainID, height, uptime, missed, alertLevel)
+		}
+	}
+	w.Flush()
+}
+
+func init() {
+	rootCmd.AddCommand(statusCmd)
+	statusCmd.Flags().String("url", "", "URL of the /status endpoint of a running monitor (default from http.listen in the config)")
+	statusCmd.Flags().String("tag", "", "Only show validators with the tag")
+	statusCmd.Flags().Bool("json", false, "Print the status summary as JSON")
+}
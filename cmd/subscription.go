@@ -0,0 +1,209 @@
+package cmd
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"reflect"
+	"sync"
+	"time"
+
+	"github.com/tendermint/tendermint/libs/bytes"
+	rpchttp "github.com/tendermint/tendermint/rpc/client/http"
+	tmtypes "github.com/tendermint/tendermint/types"
+)
+
+const (
+	blockSubscriber             = "halflife"
+	blockSubscriptionStale      = 2 * time.Minute // resubscribe when no new blocks are received for this long
+	blockSubscriptionRetryDelay = 5 * time.Second
+	blockSubscriptionMaxDelay   = time.Minute
+)
+
+// blockSubscriptions holds the new block subscriptions by validator name
+var blockSubscriptions = blockSubscriptionRegistry{subscriptions: make(map[string]*blockSubscription)}
+
+type blockSubscriptionRegistry struct {
+	lock          sync.Mutex
+	subscriptions map[string]*blockSubscription
+}
+
+func (registry *blockSubscriptionRegistry) get(name string) *blockSubscription {
+	registry.lock.Lock()
+	defer registry.lock.Unlock()
+	return registry.subscriptions[name]
+}
+
+func (registry *blockSubscriptionRegistry) set(name string, subscription *blockSubscription) {
+	registry.lock.Lock()
+	defer registry.lock.Unlock()
+	registry.subscriptions[name] = subscription
+}
+
+// blockSigningInfo is whether the validator signed a block, and any double sign evidence against it in the block
+type blockSigningInfo struct {
+	height     int64
+	time       time.Time
+	signed     bool
+	doubleSign *DoubleSignError
+}
+
+func getBlockSigningInfo(block *tmtypes.Block, hexAddress []byte) blockSigningInfo {
+	info := blockSigningInfo{
+		height:     block.Height,
+		time:       block.Time,
+		doubleSign: findDoubleSignEvidence(block, hexAddress),
+	}
+	for _, voter := range block.LastCommit.Signatures {
+		if reflect.DeepEqual(voter.ValidatorAddress, bytes.HexBytes(hexAddress)) {
+			info.signed = true
+			break
+		}
+	}
+	return info
+}
+
+// blockSubscription receives new blocks over websocket, caching their signing info so that they don't need to be
+// fetched each check, and waking the monitor early when the validator starts or stops missing blocks.
+// Blocks that are not cached, e.g. while the subscription is down, are fetched by polling as usual.
+type blockSubscription struct {
+	vm         *ValidatorMonitor
+	hexAddress []byte
+	wake       chan struct{}
+
+	lock        sync.Mutex
+	blocks      map[int64]blockSigningInfo
+	latest      int64
+	lastMissing bool
+}
+
+// startBlockSubscription subscribes to new blocks for the validator when enabled, returns nil otherwise
+func startBlockSubscription(vm *ValidatorMonitor) *blockSubscription {
+	if vm.FullNode || !vm.SubscribeBlocks {
+		return nil
+	}
+	hexAddress, err := vm.getChainType().consensusAddress(vm.Address)
+	if err != nil {
+		fmt.Printf("Not subscribing to blocks for %s: %s\n", vm.Name, redactError(err))
+		return nil
+	}
+	subscription := &blockSubscription{
+		vm:         vm,
+		hexAddress: hexAddress,
+		wake:       make(chan struct{}, 1),
+		blocks:     make(map[int64]blockSigningInfo),
+	}
+	blockSubscriptions.set(vm.Name, subscription)
+	go subscription.run()
+	return subscription
+}
+
+// get returns the cached signing info for the height, safe to call on a nil subscription
+func (s *blockSubscription) get(height int64) (blockSigningInfo, bool) {
+	if s == nil {
+		return blockSigningInfo{}, false
+	}
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	info, ok := s.blocks[height]
+	return info, ok
+}
+
+// wait sleeps for the duration, or until woken by a change in block signing, safe to call on a nil subscription
+func (s *blockSubscription) wait(d time.Duration) {
+	if s == nil {
+		time.Sleep(d)
+		return
+	}
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-timer.C:
+	case <-s.wake:
+	}
+}
+
+func (s *blockSubscription) add(info blockSigningInfo) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	s.blocks[info.height] = info
+	if info.height > s.latest {
+		s.latest = info.height
+	}
+
+	// keep twice the recent blocks window so blocks are still cached if a check runs late
+	keep := 2 * s.vm.RecentBlocksToCheck
+	missed := int64(0)
+	for height, block := range s.blocks {
+		if height <= s.latest-keep {
+			delete(s.blocks, height)
+			continue
+		}
+		if height > s.latest-s.vm.RecentBlocksToCheck && !block.signed {
+			missed++
+		}
+	}
+
+	missing := missed > s.vm.getMissedBlocksThreshold()
+	if missing != s.lastMissing || info.doubleSign != nil {
+		s.lastMissing = missing
+		select {
+		case s.wake <- struct{}{}:
+		default:
+		}
+	}
+}
+
+func (s *blockSubscription) run() {
+	delay := blockSubscriptionRetryDelay
+	for {
+		err := s.subscribe()
+		fmt.Printf("Block subscription for %s dropped, falling back to polling: %s\n", s.vm.Name, redactError(err))
+		time.Sleep(delay)
+		delay *= 2
+		if delay > blockSubscriptionMaxDelay {
+			delay = blockSubscriptionMaxDelay
+		}
+	}
+}
+
+func (s *blockSubscription) subscribe() error {
+	client, err := rpchttp.New(s.vm.getRPCAddress(), "/websocket")
+	if err != nil {
+		return err
+	}
+	if err := client.Start(); err != nil {
+		return err
+	}
+	defer client.Stop()
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Duration(time.Second*RPCTimeoutSeconds))
+	events, err := client.Subscribe(ctx, blockSubscriber, tmtypes.QueryForEvent(tmtypes.EventNewBlock).String())
+	cancel()
+	if err != nil {
+		return err
+	}
+	fmt.Printf("Subscribed to new blocks for %s\n", s.vm.Name)
+
+	stale := time.NewTimer(blockSubscriptionStale)
+	defer stale.Stop()
+	for {
+		select {
+		case event, ok := <-events:
+			if !ok {
+				return errors.New("subscription closed")
+			}
+			data, ok := event.Data.(tmtypes.EventDataNewBlock)
+			if !ok || data.Block == nil {
+				continue
+			}
+			s.add(getBlockSigningInfo(data.Block, s.hexAddress))
+			if !stale.Stop() {
+				<-stale.C
+			}
+			stale.Reset(blockSubscriptionStale)
+		case <-stale.C:
+			return fmt.Errorf("no new blocks received for %s", blockSubscriptionStale)
+		}
+	}
+}
@@ -0,0 +1,261 @@
+package cmd
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+)
+
+const teamsHTTPTimeout = 20 * time.Second
+
+// TeamsNotificationService posts alerts to a Microsoft Teams incoming webhook as message cards.
+// Teams webhooks can't edit prior messages, so status cards are only posted when a status interval is configured.
+type TeamsNotificationService struct {
+	webhookURL     string
+	statusInterval time.Duration
+	verbosity      Verbosity
+	httpClient     *http.Client
+
+	statusLock   sync.Mutex
+	statusPosted map[string]time.Time
+}
+
+func NewTeamsNotificationService(config TeamsConfig, httpClient *http.Client) *TeamsNotificationService {
+	if httpClient == nil {
+		httpClient = &http.Client{Timeout: teamsHTTPTimeout}
+	}
+	service := &TeamsNotificationService{
+		webhookURL:   config.WebhookURL,
+		verbosity:    getVerbosity(config.Verbosity),
+		httpClient:   httpClient,
+		statusPosted: make(map[string]time.Time),
+	}
+	if config.StatusInterval != nil {
+		service.statusInterval = *config.StatusInterval
+	}
+	return service
+}
+
+type teamsMessageCard struct {
+	Type       string              `json:"@type"`
+	Context    string              `json:"@context"`
+	Summary    string              `json:"summary"`
+	ThemeColor string              `json:"themeColor"`
+	Title      string              `json:"title"`
+	Sections   []teamsMessageEntry `json:"sections,omitempty"`
+}
+
+type teamsMessageEntry struct {
+	ActivityTitle string      `json:"activityTitle,omitempty"`
+	Text          string      `json:"text,omitempty"`
+	Facts         []teamsFact `json:"facts,omitempty"`
+}
+
+type teamsFact struct {
+	Name  string `json:"name"`
+	Value string `json:"value"`
+}
+
+func newTeamsMessageCard(title string, alertLevel AlertLevel, sections ...teamsMessageEntry) teamsMessageCard {
+	return teamsMessageCard{
+		Type:       "MessageCard",
+		Context:    "https://schema.org/extensions",
+		Summary:    title,
+		ThemeColor: fmt.Sprintf("%06X", getColorForAlertLevel(defaultAlertLevelColors, alertLevel)),
+		Title:      title,
+		Sections:   sections,
+	}
+}
+
+func (service *TeamsNotificationService) post(card teamsMessageCard) error {
+	body, err := json.Marshal(card)
+	if err != nil {
+		return err
+	}
+	res, err := service.httpClient.Post(service.webhookURL, "application/json", bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	defer res.Body.Close()
+	if res.StatusCode < 200 || res.StatusCode >= 300 {
+		resBody, _ := io.ReadAll(io.LimitReader(res.Body, 512))
+		return fmt.Errorf("teams webhook returned %s: %s", res.Status, strings.TrimSpace(string(resBody)))
+	}
+	return nil
+}
+
+func getTeamsAlertsText(alerts []string) string {
+	var lines []string
+	for _, alert := range alerts {
+		lines = append(lines, "- "+alert)
+	}
+	return strings.Join(lines, "\n")
+}
+
+// implements NotificationService interface
+func (service *TeamsNotificationService) CheckReachability() error {
+	// posting is the only way to check a Teams webhook, which would post a message on every start
+	if !strings.HasPrefix(service.webhookURL, "https://") {
+		return fmt.Errorf("teams webhook-url must be an https URL")
+	}
+	return nil
+}
+
+// implements NotificationService interface
+func (service *TeamsNotificationService) UpdateValidatorRealtimeStatus(
+	configFile string,
+	config *HalfLifeConfig,
+	vm *ValidatorMonitor,
+	stats ValidatorStats,
+	writeConfigMutex *sync.Mutex,
+) error {
+	if service.statusInterval <= 0 {
+		return nil
+	}
+	service.statusLock.Lock()
+	if time.Since(service.statusPosted[vm.Name]) < service.statusInterval {
+		service.statusLock.Unlock()
+		return nil
+	}
+	service.statusPosted[vm.Name] = time.Now()
+	service.statusLock.Unlock()
+
+	facts := []teamsFact{{Name: "Chain ID", Value: vm.ChainID}}
+	if stats.RPCError || stats.Height == 0 {
+		facts = append(facts, teamsFact{Name: "Height", Value: "N/A"})
+	} else {
+		facts = append(facts, teamsFact{Name: "Height", Value: fmt.Sprintf("%d (%s)", stats.Height, stats.Timestamp.UTC().Format(time.RFC3339))})
+		if !vm.FullNode {
+			facts = append(facts, teamsFact{Name: "Latest Blocks Signed", Value: fmt.Sprintf("%d/%d", vm.RecentBlocksToCheck-stats.RecentMissedBlocks, vm.RecentBlocksToCheck)})
+			if stats.UptimeTrendKnown {
+				facts = append(facts, teamsFact{Name: "Uptime Trend", Value: formatUptimeTrend(stats.UptimeTrend)})
+			}
+		}
+	}
+	if stats.RPCError {
+		facts = append(facts, teamsFact{Name: "RPC", Value: getRPCHealth(stats)})
+	}
+	if service.verbosity == verbosityDetailed && stats.BondStatus != "" {
+		facts = append(facts, teamsFact{Name: "Bond Status", Value: fmt.Sprintf("%s - Voting Power %d (rank %d)", stats.BondStatus, stats.VotingPower, stats.VotingPowerRank)})
+	}
+	for _, sentryStats := range stats.SentryStats {
+		if service.verbosity == verbosityMinimal {
+			break
+		}
+		height := "N/A"
+		if sentryStats.Height != 0 {
+			height = fmt.Sprint(sentryStats.Height)
+		}
+		value := "Height " + height
+		if !sentryStats.grpcReachable() {
+			value += " - gRPC unreachable"
+		}
+		facts = append(facts, teamsFact{Name: sentryStats.Name, Value: value})
+	}
+	card := newTeamsMessageCard(getValidatorTitle(vm, stats), stats.AlertLevel, teamsMessageEntry{Facts: facts})
+	if err := service.post(card); err != nil {
+		return fmt.Errorf("error sending teams status message: %w", err)
+	}
+	return nil
+}
+
+// implements NotificationService interface
+func (service *TeamsNotificationService) SendValidatorAlertNotification(
+	config *HalfLifeConfig,
+	vm *ValidatorMonitor,
+	stats ValidatorStats,
+	alertNotification *ValidatorAlertNotification,
+) error {
+	var errs []string
+	title := getValidatorTitle(vm, stats)
+	alerts, clearedAlerts := alertNotification.Alerts, alertNotification.ClearedAlerts
+	if service.verbosity == verbosityMinimal {
+		alerts, clearedAlerts = []string{getAlertsSummary(alerts)}, []string{getAlertsSummary(clearedAlerts)}
+	}
+	if len(alertNotification.Alerts) > 0 {
+		sections := []teamsMessageEntry{{
+			ActivityTitle: "Errors:",
+			Text:          getTeamsAlertsText(alerts),
+		}}
+		if service.verbosity == verbosityDetailed {
+			sections = append(sections, teamsMessageEntry{
+				ActivityTitle: "Details:",
+				Text:          getTeamsAlertsText(getStatsDetails(vm, stats)),
+			})
+		}
+		card := newTeamsMessageCard(title, alertNotification.AlertLevel, sections...)
+		if err := service.post(card); err != nil {
+			errs = append(errs, fmt.Sprintf("error sending teams alert message: %v", err))
+		}
+	}
+	if len(alertNotification.ClearedAlerts) > 0 {
+		card := newTeamsMessageCard(title, alertLevelNone, teamsMessageEntry{
+			ActivityTitle: "Errors cleared:",
+			Text:          getTeamsAlertsText(clearedAlerts),
+		})
+		if err := service.post(card); err != nil {
+			errs = append(errs, fmt.Sprintf("error sending teams cleared alerts message: %v", err))
+		}
+	}
+	if len(errs) > 0 {
+		return errors.New(strings.Join(errs, ", "))
+	}
+	return nil
+}
+
+func getTeamsDigestSections(groups []AlertDigestGroup) []teamsMessageEntry {
+	var sections []teamsMessageEntry
+	for _, group := range groups {
+		alertType := string(group.AlertType)
+		if alertType == "" {
+			alertType = "other"
+		}
+		var alerts []string
+		for _, entry := range group.Entries {
+			alerts = append(alerts, fmt.Sprintf("**%s**: %s", entry.Validator, entry.Message))
+		}
+		sections = append(sections, teamsMessageEntry{
+			ActivityTitle: fmt.Sprintf("%s - %s (%d)", group.AlertLevel, alertType, len(group.Entries)),
+			Text:          getTeamsAlertsText(alerts),
+		})
+	}
+	return sections
+}
+
+// implements AlertDigestSender interface
+func (service *TeamsNotificationService) SendAlertDigest(config *HalfLifeConfig, digest *AlertDigest) error {
+	var errs []string
+	title := fmt.Sprintf("Alert digest (%d validators)", digest.Validators())
+	if len(digest.Alerts) > 0 {
+		if err := service.post(newTeamsMessageCard(title+" - Errors", digest.AlertLevel, getTeamsDigestSections(digest.AlertGroups())...)); err != nil {
+			errs = append(errs, fmt.Sprintf("error sending teams alert digest message: %v", err))
+		}
+	}
+	if len(digest.ClearedAlerts) > 0 {
+		if err := service.post(newTeamsMessageCard(title+" - Errors cleared", alertLevelNone, getTeamsDigestSections(digest.ClearedAlertGroups())...)); err != nil {
+			errs = append(errs, fmt.Sprintf("error sending teams alert digest message: %v", err))
+		}
+	}
+	if len(errs) > 0 {
+		return errors.New(strings.Join(errs, ", "))
+	}
+	return nil
+}
+
+// implements LifecycleSender interface
+func (service *TeamsNotificationService) SendLifecycleNotification(config *HalfLifeConfig, lifecycle *LifecycleNotification) error {
+	var sections []teamsMessageEntry
+	if lines := lifecycle.validatorLines(); len(lines) > 0 {
+		sections = append(sections, teamsMessageEntry{ActivityTitle: "Validators", Text: getTeamsAlertsText(lines)})
+	}
+	if err := service.post(newTeamsMessageCard(lifecycle.Message, alertLevelNone, sections...)); err != nil {
+		return fmt.Errorf("error sending teams %s message: %w", lifecycle.Event, err)
+	}
+	return nil
+}
@@ -0,0 +1,339 @@
+package cmd
+
+import (
+	"bytes"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	defaultTracingServiceName = "halflife"
+	tracingExportTimeout      = 10 * time.Second
+	tracingExportPath         = "/v1/traces"
+)
+
+// span kinds and status codes of the OTLP protocol
+const (
+	otlpSpanKindInternal = 1
+	otlpSpanKindClient   = 3
+
+	otlpStatusOK    = 1
+	otlpStatusError = 2
+)
+
+const (
+	traceOutcomeOK    = "ok"
+	traceOutcomeError = "error"
+)
+
+// TracingConfig exports a trace of each monitoring cycle to an OpenTelemetry collector over OTLP/HTTP
+type TracingConfig struct {
+	Endpoint    string            `yaml:"endpoint"`     // collector OTLP/HTTP address, e.g. http://localhost:4318
+	Headers     map[string]string `yaml:"headers"`      // sent with each export, e.g. an API key of a hosted collector
+	ServiceName string            `yaml:"service-name"` // service.name of the traces, default halflife
+}
+
+func (c *TracingConfig) validate() error {
+	u, err := url.Parse(c.Endpoint)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return fmt.Errorf("tracing endpoint %q must be an http or https URL", redactSecrets(c.Endpoint))
+	}
+	return nil
+}
+
+func (c *TracingConfig) getServiceName() string {
+	if c.ServiceName == "" {
+		return defaultTracingServiceName
+	}
+	return c.ServiceName
+}
+
+// getExportURL returns the traces URL of the collector, the endpoint is used as is when it already has the path
+func (c *TracingConfig) getExportURL() string {
+	endpoint := strings.TrimSuffix(c.Endpoint, "/")
+	if strings.HasSuffix(endpoint, tracingExportPath) {
+		return endpoint
+	}
+	return endpoint + tracingExportPath
+}
+
+// tracing is shared by the checks of every validator. Until it is registered with a tracing config, no spans are
+// started, and the span methods are no-ops on the nil spans.
+var tracing = traceExporter{}
+
+type traceExporter struct {
+	config     *TracingConfig
+	httpClient *http.Client
+	pending    sync.WaitGroup // exports in flight
+}
+
+// traceAttribute is a key and a string or int64 value of a span
+type traceAttribute struct {
+	key   string
+	value interface{}
+}
+
+// traceSpan is a span being recorded. The spans of a trace are collected until the root span ends, then the trace
+// is exported in one request.
+type traceSpan struct {
+	trace    *traceRecord
+	spanID   string
+	parentID string
+	name     string
+	kind     int
+	start    time.Time
+
+	lock       sync.Mutex
+	attributes []traceAttribute
+}
+
+type traceRecord struct {
+	traceID string
+
+	lock  sync.Mutex
+	spans []otlpSpan
+}
+
+// register enables tracing when the tracing config is set
+func (exporter *traceExporter) register(config *HalfLifeConfig) {
+	if config.Tracing == nil {
+		return
+	}
+	exporter.config = config.Tracing
+	exporter.httpClient = newProxyHTTPClient(config.Proxy, tracingExportTimeout)
+	if exporter.httpClient == nil {
+		exporter.httpClient = &http.Client{Timeout: tracingExportTimeout}
+	}
+	fmt.Printf("Exporting traces of each monitoring cycle to %s\n", redactSecrets(config.Tracing.getExportURL()))
+}
+
+// startTrace starts the root span of a new trace, nil when tracing is not enabled
+func (exporter *traceExporter) startTrace(name string, attributes ...traceAttribute) *traceSpan {
+	if exporter.config == nil {
+		return nil
+	}
+	return &traceSpan{
+		trace:      &traceRecord{traceID: newTraceID(16)},
+		spanID:     newTraceID(8),
+		name:       name,
+		kind:       otlpSpanKindInternal,
+		start:      time.Now(),
+		attributes: attributes,
+	}
+}
+
+// flush waits for the exports in flight, for a single cycle to be exported before exiting
+func (exporter *traceExporter) flush() {
+	exporter.pending.Wait()
+}
+
+// startChild starts a span within the span, nil when the span is not traced
+func (s *traceSpan) startChild(name string, attributes ...traceAttribute) *traceSpan {
+	if s == nil {
+		return nil
+	}
+	return &traceSpan{
+		trace:      s.trace,
+		spanID:     newTraceID(8),
+		parentID:   s.spanID,
+		name:       name,
+		kind:       otlpSpanKindInternal,
+		start:      time.Now(),
+		attributes: attributes,
+	}
+}
+
+// startCall starts a client span of an rpc or grpc call to the endpoint, nil when the span is not traced
+func (s *traceSpan) startCall(name string, endpoint string) *traceSpan {
+	if s == nil {
+		return nil
+	}
+	call := s.startChild(name, stringAttribute("halflife.endpoint", redactSecrets(endpoint)))
+	call.kind = otlpSpanKindClient
+	return call
+}
+
+// set adds attributes to the span
+func (s *traceSpan) set(attributes ...traceAttribute) {
+	if s == nil {
+		return
+	}
+	s.lock.Lock()
+	s.attributes = append(s.attributes, attributes...)
+	s.lock.Unlock()
+}
+
+// endCall ends a call span with the height it returned, when known, and its outcome
+func (s *traceSpan) endCall(height int64, err error) {
+	if s == nil {
+		return
+	}
+	if height > 0 {
+		s.set(intAttribute("halflife.height", height))
+	}
+	s.end(err)
+}
+
+// end records the span with its outcome. Ending the root span exports the trace in the background.
+func (s *traceSpan) end(err error) {
+	if s == nil {
+		return
+	}
+	end := time.Now()
+	status := otlpStatus{Code: otlpStatusOK}
+	outcome := traceOutcomeOK
+	if err != nil {
+		status = otlpStatus{Code: otlpStatusError, Message: redactError(err)}
+		outcome = traceOutcomeError
+	}
+	s.lock.Lock()
+	attributes := append(s.attributes, stringAttribute("halflife.outcome", outcome))
+	s.lock.Unlock()
+	span := otlpSpan{
+		TraceID:           s.trace.traceID,
+		SpanID:            s.spanID,
+		ParentSpanID:      s.parentID,
+		Name:              s.name,
+		Kind:              s.kind,
+		StartTimeUnixNano: strconv.FormatInt(s.start.UnixNano(), 10),
+		EndTimeUnixNano:   strconv.FormatInt(end.UnixNano(), 10),
+		Attributes:        getOTLPAttributes(attributes),
+		Status:            status,
+	}
+	s.trace.lock.Lock()
+	s.trace.spans = append(s.trace.spans, span)
+	spans := s.trace.spans
+	s.trace.lock.Unlock()
+	if s.parentID != "" {
+		return
+	}
+	tracing.pending.Add(1)
+	go func() {
+		defer tracing.pending.Done()
+		if err := tracing.export(spans); err != nil {
+			fmt.Printf("Error exporting trace of %s: %s\n", s.name, redactError(err))
+		}
+	}()
+}
+
+// export posts the spans of a trace to the collector as OTLP/HTTP JSON
+func (exporter *traceExporter) export(spans []otlpSpan) error {
+	request := otlpExportRequest{ResourceSpans: []otlpResourceSpans{{
+		Resource: otlpResource{Attributes: getOTLPAttributes([]traceAttribute{
+			stringAttribute("service.name", exporter.config.getServiceName()),
+		})},
+		ScopeSpans: []otlpScopeSpans{{
+			Scope: otlpScope{Name: defaultTracingServiceName},
+			Spans: spans,
+		}},
+	}}}
+	body, err := json.Marshal(request)
+	if err != nil {
+		return err
+	}
+	req, err := http.NewRequest(http.MethodPost, exporter.config.getExportURL(), bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	for name, value := range exporter.config.Headers {
+		req.Header.Set(name, value)
+	}
+	res, err := exporter.httpClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer res.Body.Close()
+	if res.StatusCode < 200 || res.StatusCode > 299 {
+		return fmt.Errorf("collector responded with %s", res.Status)
+	}
+	return nil
+}
+
+func stringAttribute(key string, value string) traceAttribute {
+	return traceAttribute{key: key, value: value}
+}
+
+func intAttribute(key string, value int64) traceAttribute {
+	return traceAttribute{key: key, value: value}
+}
+
+func newTraceID(size int) string {
+	id := make([]byte, size)
+	_, _ = rand.Read(id)
+	return hex.EncodeToString(id)
+}
+
+type otlpExportRequest struct {
+	ResourceSpans []otlpResourceSpans `json:"resourceSpans"`
+}
+
+type otlpResourceSpans struct {
+	Resource   otlpResource     `json:"resource"`
+	ScopeSpans []otlpScopeSpans `json:"scopeSpans"`
+}
+
+type otlpResource struct {
+	Attributes []otlpAttribute `json:"attributes"`
+}
+
+type otlpScopeSpans struct {
+	Scope otlpScope  `json:"scope"`
+	Spans []otlpSpan `json:"spans"`
+}
+
+type otlpScope struct {
+	Name string `json:"name"`
+}
+
+type otlpSpan struct {
+	TraceID           string          `json:"traceId"`
+	SpanID            string          `json:"spanId"`
+	ParentSpanID      string          `json:"parentSpanId,omitempty"`
+	Name              string          `json:"name"`
+	Kind              int             `json:"kind"`
+	StartTimeUnixNano string          `json:"startTimeUnixNano"`
+	EndTimeUnixNano   string          `json:"endTimeUnixNano"`
+	Attributes        []otlpAttribute `json:"attributes,omitempty"`
+	Status            otlpStatus      `json:"status"`
+}
+
+type otlpStatus struct {
+	Code    int    `json:"code"`
+	Message string `json:"message,omitempty"`
+}
+
+type otlpAttribute struct {
+	Key   string    `json:"key"`
+	Value otlpValue `json:"value"`
+}
+
+// otlpValue is an attribute value, the OTLP JSON encoding of an int64 is a string
+type otlpValue struct {
+	StringValue *string `json:"stringValue,omitempty"`
+	IntValue    *string `json:"intValue,omitempty"`
+}
+
+func getOTLPAttributes(attributes []traceAttribute) []otlpAttribute {
+	otlpAttributes := make([]otlpAttribute, 0, len(attributes))
+	for _, attribute := range attributes {
+		var value otlpValue
+		switch v := attribute.value.(type) {
+		case string:
+			value.StringValue = &v
+		case int64:
+			s := strconv.FormatInt(v, 10)
+			value.IntValue = &s
+		}
+		otlpAttributes = append(otlpAttributes, otlpAttribute{Key: attribute.key, Value: value})
+	}
+	return otlpAttributes
+}
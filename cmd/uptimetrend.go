@@ -0,0 +1,133 @@
+package cmd
+
+import (
+	"fmt"
+	"math"
+	"sync"
+	"time"
+)
+
+const (
+	defaultUptimeTrendWindow = time.Hour
+	uptimeTrendSamples       = 12   // samples taken over the trend window
+	uptimeTrendSteadyRate    = 0.01 // percentage points per hour under which the uptime is steady
+)
+
+const (
+	uptimeTrendRising  = "rising"
+	uptimeTrendFalling = "falling"
+	uptimeTrendSteady  = "steady"
+)
+
+// UptimeSample is the slashing period uptime of a validator at a check
+type UptimeSample struct {
+	Time   time.Time `yaml:"time"`
+	Uptime float64   `yaml:"uptime"`
+}
+
+// uptimeTrends holds the recent uptime samples of each validator by validator name. The samples are saved to the
+// state file when it is configured, so that the trend survives restarts, and are otherwise only kept in memory.
+var uptimeTrends = uptimeTrendRegistry{samples: make(map[string][]UptimeSample)}
+
+type uptimeTrendRegistry struct {
+	lock    sync.Mutex
+	samples map[string][]UptimeSample
+}
+
+// add samples the uptime at most uptimeTrendSamples times per window, dropping the samples older than the window.
+// It returns the samples within the window, and whether a sample was added.
+func (registry *uptimeTrendRegistry) add(vm *ValidatorMonitor, now time.Time, uptime float64) ([]UptimeSample, bool) {
+	window := vm.getUptimeTrendWindow()
+	registry.lock.Lock()
+	defer registry.lock.Unlock()
+	var samples []UptimeSample
+	for _, sample := range registry.samples[vm.Name] {
+		if now.Sub(sample.Time) <= window {
+			samples = append(samples, sample)
+		}
+	}
+	added := len(samples) == 0 || now.Sub(samples[len(samples)-1].Time) >= window/uptimeTrendSamples
+	if added {
+		samples = append(samples, UptimeSample{Time: now, Uptime: uptime})
+	}
+	registry.samples[vm.Name] = samples
+	return append([]UptimeSample(nil), samples...), added
+}
+
+func (registry *uptimeTrendRegistry) get(name string) []UptimeSample {
+	registry.lock.Lock()
+	defer registry.lock.Unlock()
+	return append([]UptimeSample(nil), registry.samples[name]...)
+}
+
+func (registry *uptimeTrendRegistry) restore(name string, samples []UptimeSample) {
+	registry.lock.Lock()
+	defer registry.lock.Unlock()
+	registry.samples[name] = samples
+}
+
+func (vm *ValidatorMonitor) getUptimeTrendWindow() time.Duration {
+	if vm.UptimeTrendWindow == nil {
+		return defaultUptimeTrendWindow
+	}
+	return *vm.UptimeTrendWindow
+}
+
+// getUptimeTrend returns the least squares slope of the samples in percentage points per hour. The trend is only
+// known once the samples span half the window, so that it is not determined from a few minutes after a start.
+func getUptimeTrend(samples []UptimeSample, window time.Duration) (float64, bool) {
+	if len(samples) < 3 || samples[len(samples)-1].Time.Sub(samples[0].Time) < window/2 {
+		return 0, false
+	}
+	var sumX, sumY, sumXY, sumXX float64
+	for _, sample := range samples {
+		x := sample.Time.Sub(samples[0].Time).Hours()
+		sumX += x
+		sumY += sample.Uptime
+		sumXY += x * sample.Uptime
+		sumXX += x * x
+	}
+	n := float64(len(samples))
+	denominator := n*sumXX - sumX*sumX
+	if denominator == 0 {
+		return 0, false
+	}
+	return (n*sumXY - sumX*sumY) / denominator, true
+}
+
+// getUptimeTrendDirection describes the trend as rising, falling, or steady
+func getUptimeTrendDirection(trend float64) string {
+	switch {
+	case math.Abs(trend) < uptimeTrendSteadyRate:
+		return uptimeTrendSteady
+	case trend > 0:
+		return uptimeTrendRising
+	default:
+		return uptimeTrendFalling
+	}
+}
+
+// formatUptimeTrend describes the trend with its rate, e.g. falling (-0.42%/h)
+func formatUptimeTrend(trend float64) string {
+	return fmt.Sprintf("%s (%+.02f%%/h)", getUptimeTrendDirection(trend), trend)
+}
+
+// determineUptimeTrend samples the uptime and determines its trend. The decline alert is issued when the uptime is
+// falling faster than uptime-decline-threshold percentage points per hour. It returns whether a sample was added.
+func (stats *ValidatorStats) determineUptimeTrend(config *HalfLifeConfig, vm *ValidatorMonitor, now time.Time) (errs []error, added bool) {
+	if vm.FullNode || stats.SlashingPeriodUptime == 0 || stats.RPCError {
+		return
+	}
+	window := vm.getUptimeTrendWindow()
+	var samples []UptimeSample
+	samples, added = uptimeTrends.add(vm, now, stats.SlashingPeriodUptime)
+	stats.UptimeTrend, stats.UptimeTrendKnown = getUptimeTrend(samples, window)
+	if !stats.UptimeTrendKnown || vm.UptimeDeclineThreshold == nil || -stats.UptimeTrend <= *vm.UptimeDeclineThreshold {
+		return
+	}
+	uptimeDeclineErr := newUptimeDeclineError(stats.SlashingPeriodUptime, -stats.UptimeTrend, *vm.UptimeDeclineThreshold, window)
+	if uptimeDeclineErr.Active(config.AlertConfig) {
+		errs = append(errs, uptimeDeclineErr)
+	}
+	return
+}
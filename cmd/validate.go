@@ -0,0 +1,44 @@
+package cmd
+
+import (
+	"fmt"
+	"log"
+
+	"github.com/spf13/cobra"
+)
+
+var validateCmd = &cobra.Command{
+	Use:   "validate",
+	Short: "Validate config.yaml",
+	Long:  "Parses config.yaml and checks that the configured notification service is reachable",
+	Run: func(cmd *cobra.Command, args []string) {
+		configFile, err := getConfigFile(cmd)
+		if err != nil {
+			log.Fatalf("Error resolving config file: %v", err)
+		}
+		config, err := loadConfig(configFile)
+		if err != nil {
+			log.Fatalf("Error loading config: %s", redactError(err))
+		}
+
+		for _, vm := range config.enabledValidators() {
+			if err := resolveValidatorAddress(config, vm); err != nil {
+				log.Fatalf("Error resolving validator %s: %s", vm.Name, redactError(err))
+			}
+		}
+
+		notificationService, err := getNotificationService(config)
+		if err != nil {
+			log.Fatalf("Error configuring notifications: %s", redactError(err))
+		}
+		if err := notificationService.CheckReachability(); err != nil {
+			log.Fatalf("Error checking notifications: %s", redactError(err))
+		}
+
+		fmt.Printf("Config is valid: %s\n", configFile)
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(validateCmd)
+}
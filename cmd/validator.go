@@ -3,12 +3,21 @@ package cmd
 import (
 	"context"
 	"fmt"
+	"hash/fnv"
+	"math/rand"
 	"reflect"
+	"sort"
+	"strings"
 	"sync"
 	"time"
 
+	sdk "github.com/cosmos/cosmos-sdk/types"
 	"github.com/cosmos/cosmos-sdk/types/bech32"
+	slashingtypes "github.com/cosmos/cosmos-sdk/x/slashing/types"
+	stakingtypes "github.com/cosmos/cosmos-sdk/x/staking/types"
+	upgradetypes "github.com/cosmos/cosmos-sdk/x/upgrade/types"
 	"github.com/tendermint/tendermint/libs/bytes"
+	tmtypes "github.com/tendermint/tendermint/types"
 )
 
 const (
@@ -16,117 +25,327 @@ const (
 	outOfSyncThreshold           = 5
 	haltThresholdNanoseconds     = 3e11 // if nodes are stuck for > 5 minutes, will be considered halt
 	defaultMissedBlocksThreshold = 0
+	blockTimeSampleBlocks        = 100 // number of blocks to average over when estimating block time
+	upgradeHighAlertBlocks       = 10  // alert level is raised to high when an upgrade is this many blocks away
+	blockTimeEstimateWeight      = 0.2 // weight of the newest sample in the rolling block time estimate
+	defaultRPCRetryBaseDelay     = time.Second
+	defaultRPCRetryMaxDelay      = 16 * time.Second
+	checkInterval                = 30 * time.Second
 )
 
+// alertClock is the time alerts are evaluated at, which the replay command sets to the time of the replayed blocks
+var alertClock = time.Now
+
+const (
+	defaultMissedBlockStreakThreshold  int64 = 5 // consecutive missed blocks, a streak means the signer is down rather than a degraded network
+	defaultMissedBlockStreakAlertLevel       = alertLevelHigh
+)
+
+// blocks remaining before an upgrade at which alerts are re-sent
+var upgradeAlertMilestones = []int64{1000, 100, 10}
+
+// resolveMonikerAddress fills in the validator consensus address from the staking validator set by moniker,
+// when the address is not set or was resolved on a different chain-id
+func resolveMonikerAddress(config *HalfLifeConfig, vm *ValidatorMonitor) error {
+	if vm.Moniker == "" || vm.FullNode || (vm.Address != "" && vm.MonikerChainID == vm.ChainID) {
+		return nil
+	}
+	if !vm.getChainType().hasSDKModules() {
+		return fmt.Errorf("moniker lookup requires the staking module, provide the address for chain-type %s", vm.getChainType())
+	}
+	client, err := getCosmosClient(vm.getRPCAddress(), vm.ChainID, getProxy(config, vm), getRPCTLS(config, vm))
+	if err != nil {
+		return err
+	}
+	validators, err := getStakingValidators(context.Background(), client)
+	if err != nil {
+		return err
+	}
+
+	var matches stakingtypes.Validators
+	for _, validator := range validators {
+		if strings.EqualFold(strings.TrimSpace(validator.GetMoniker()), strings.TrimSpace(vm.Moniker)) {
+			matches = append(matches, validator)
+		}
+	}
+	switch len(matches) {
+	case 0:
+		return fmt.Errorf("no validator found with moniker %q on %s", vm.Moniker, vm.ChainID)
+	case 1:
+	default:
+		operators := make([]string, len(matches))
+		for i, match := range matches {
+			operators[i] = match.OperatorAddress
+		}
+		return fmt.Errorf("multiple validators found with moniker %q on %s: %s", vm.Moniker, vm.ChainID, strings.Join(operators, ", "))
+	}
+
+	validator := matches[0]
+	operatorPrefix, _, err := bech32.DecodeAndConvert(validator.OperatorAddress)
+	if err != nil {
+		return err
+	}
+	consAddress, err := validator.GetConsAddr()
+	if err != nil {
+		return err
+	}
+	address, err := bech32.ConvertAndEncode(strings.TrimSuffix(operatorPrefix, "valoper")+"valcons", consAddress)
+	if err != nil {
+		return err
+	}
+	fmt.Printf("Resolved moniker %q on %s to %s\n", vm.Moniker, vm.ChainID, address)
+	vm.Address = address
+	vm.MonikerChainID = vm.ChainID
+	return nil
+}
+
+// resolveConsensusAddress fills in the validator address from the consensus-address. For cosmos-sdk chains the
+// valcons prefix is taken from the validator's operator address in the staking validator set.
+func resolveConsensusAddress(config *HalfLifeConfig, vm *ValidatorMonitor) error {
+	if vm.ConsensusAddress == "" || vm.FullNode {
+		return nil
+	}
+	consAddress, err := parseConsensusAddress(vm.ConsensusAddress)
+	if err != nil {
+		return err
+	}
+	chainType := vm.getChainType()
+	if vm.Address != "" {
+		if address, err := chainType.consensusAddress(vm.Address); err == nil && bytes.HexBytes(address).String() == bytes.HexBytes(consAddress).String() {
+			return nil
+		}
+	}
+	if !chainType.hasSDKModules() {
+		vm.Address = bytes.HexBytes(consAddress).String()
+		return nil
+	}
+	client, err := getCosmosClient(vm.getRPCAddress(), vm.ChainID, getProxy(config, vm), getRPCTLS(config, vm))
+	if err != nil {
+		return err
+	}
+	validators, err := getStakingValidators(context.Background(), client)
+	if err != nil {
+		return err
+	}
+	for _, validator := range validators {
+		validatorConsAddress, err := validator.GetConsAddr()
+		if err != nil || !validatorConsAddress.Equals(sdk.ConsAddress(consAddress)) {
+			continue
+		}
+		operatorPrefix, _, err := bech32.DecodeAndConvert(validator.OperatorAddress)
+		if err != nil {
+			return err
+		}
+		address, err := bech32.ConvertAndEncode(strings.TrimSuffix(operatorPrefix, "valoper")+"valcons", consAddress)
+		if err != nil {
+			return err
+		}
+		fmt.Printf("Resolved consensus address %s on %s to %s\n", bytes.HexBytes(consAddress), vm.ChainID, address)
+		vm.Address = address
+		return nil
+	}
+	return fmt.Errorf("no validator found with consensus address %s on %s", bytes.HexBytes(consAddress), vm.ChainID)
+}
+
+// resolveValidatorAddress fills in the validator address from the moniker or consensus-address when they are set
+func resolveValidatorAddress(config *HalfLifeConfig, vm *ValidatorMonitor) error {
+	if err := resolveMonikerAddress(config, vm); err != nil {
+		return err
+	}
+	return resolveConsensusAddress(config, vm)
+}
+
 func monitorValidator(
+	ctx context.Context,
 	config *HalfLifeConfig,
 	vm *ValidatorMonitor,
 	stats *ValidatorStats,
 ) (errs []IgnorableError) {
 	stats.LastSignedBlockHeight = -1
+	stats.BlocksUntilJailed = -1
 	fmt.Printf("Monitoring validator: %s\n", vm.Name)
-	client, err := getCosmosClient(vm.RPC, vm.ChainID)
+	client, err := getCosmosClient(vm.getRPCAddress(), vm.ChainID, getProxy(config, vm), getRPCTLS(config, vm))
 	if err != nil {
 		errs = append(errs, newGenericRPCError(err.Error()))
 		return
 	}
 	slashingPeriod := int64(10000)
+	// the signing info, when it and the signing window were queried, for reading the missed blocks bitmap
+	var windowSigningInfo *slashingtypes.ValidatorSigningInfo
 	var hexAddress []byte
+	chainType := vm.getChainType()
 	if !vm.FullNode {
-		_, hexAddress, err = bech32.DecodeAndConvert(vm.Address)
+		hexAddress, err = chainType.consensusAddress(vm.Address)
 		if err != nil {
 			errs = append(errs, newIgnorableError(err))
 			return
 		}
-		valInfo, err := getSigningInfo(client, vm.Address)
+	}
+	if !vm.FullNode && chainType.hasSDKModules() {
+		span := stats.span.startCall("signing info", vm.RPC)
+		valInfo, err := getCachedSigningInfo(ctx, client, vm)
+		span.endCall(0, err)
 		if err != nil {
 			errs = append(errs, newGenericRPCError(err.Error()))
 		} else {
 			signingInfo := valInfo.ValSigningInfo
-			if signingInfo.Tombstoned {
-				errs = append(errs, newTombstonedError())
+			tombstoned := signingInfo.Tombstoned
+			jailed := signingInfo.JailedUntil.After(alertClock())
+			var verified bool
+			if (tombstoned || jailed) && vm.ConfirmRPC != "" {
+				tombstoned, jailed, verified = verifySigningInfo(ctx, config, vm, tombstoned, jailed)
+			}
+			if tombstoned {
+				errs = append(errs, newTombstonedError(verified))
 			}
-			if signingInfo.JailedUntil.After(time.Now()) {
-				errs = append(errs, newJailedError(signingInfo.JailedUntil))
+			if jailed {
+				errs = append(errs, newJailedError(signingInfo.JailedUntil, verified))
 			}
-			slashingInfo, err := getSlashingInfo(client)
+			span := stats.span.startCall("slashing params", vm.RPC)
+			slashingInfo, err := getCachedSlashingParams(ctx, config, client, vm)
+			span.endCall(0, err)
 			if err != nil {
 				errs = append(errs, newGenericRPCError(err.Error()))
 			} else {
 				slashingPeriod = slashingInfo.Params.SignedBlocksWindow
-				stats.SlashingPeriodUptime = 100.0 - 100.0*(float64(signingInfo.MissedBlocksCounter)/float64(slashingPeriod))
-
-				if stats.SlashingPeriodUptime < vm.SlashingPeriodUptimeErrorThreshold {
-					errs = append(errs, newSlashingSLAError(stats.SlashingPeriodUptime, vm.SlashingPeriodUptimeErrorThreshold))
-				}
+				windowSigningInfo = &signingInfo
+				errs = append(errs, stats.determineSlashingUptime(vm, signingInfo, slashingInfo.Params)...)
 			}
 		}
+		span = stats.span.startCall("staking validators", vm.RPC)
+		validators, err := getCachedStakingValidators(ctx, config, client, vm)
+		span.endCall(0, err)
+		if err != nil {
+			errs = append(errs, newGenericRPCError(err.Error()))
+		} else {
+			stats.determineVotingPower(validators, hexAddress)
+		}
 	}
 	node, err := client.GetNode()
 	if err != nil {
 		errs = append(errs, newGenericRPCError(err.Error()))
 		return
 	}
-	statusCtx, statusCtxCancel := context.WithTimeout(context.Background(), time.Duration(time.Second*RPCTimeoutSeconds))
+	statusSpan := stats.span.startCall("status", vm.RPC)
+	statusCtx, statusCtxCancel := context.WithTimeout(ctx, time.Duration(time.Second*RPCTimeoutSeconds))
 	status, err := node.Status(statusCtx)
 	statusCtxCancel()
 	if err != nil {
+		statusSpan.endCall(0, err)
 		errs = append(errs, newGenericRPCError(err.Error()))
 	} else {
+		statusSpan.endCall(status.SyncInfo.LatestBlockHeight, nil)
+		if vm.ChainID != "" && status.NodeInfo.Network != vm.ChainID {
+			errs = append(errs, newChainIDMismatchError(vm.ChainID, status.NodeInfo.Network))
+		}
 		if status.SyncInfo.CatchingUp {
 			errs = append(errs, newOutOfSyncError(vm.RPC))
 		} else {
-			timeSinceLastBlock := time.Now().UnixNano() - status.SyncInfo.LatestBlockTime.UnixNano()
+			timeSinceLastBlock := alertClock().UnixNano() - status.SyncInfo.LatestBlockTime.UnixNano()
 			if timeSinceLastBlock > haltThresholdNanoseconds {
-				errs = append(errs, newChainHaltError(timeSinceLastBlock))
+				errs = append(errs, getHaltError(ctx, config, vm, timeSinceLastBlock))
 			}
 		}
 		stats.Height = status.SyncInfo.LatestBlockHeight
 		stats.Timestamp = status.SyncInfo.LatestBlockTime
 		stats.RecentMissedBlocks = 0
-		if !vm.FullNode {
-			for i := stats.Height; i > stats.Height-vm.RecentBlocksToCheck && i > 0; i-- {
-				blockCtx, blockCtxCancel := context.WithTimeout(context.Background(), time.Duration(time.Second*RPCTimeoutSeconds))
-				block, err := node.Block(blockCtx, &i)
+		stats.RecentMissedBlockStreak = 0
+		if !vm.FullNode && !chainType.hasSDKModules() {
+			span := stats.span.startCall("validator set", vm.RPC)
+			validators, err := getValidatorSet(ctx, node, stats.Height)
+			span.endCall(stats.Height, err)
+			if err != nil {
+				errs = append(errs, newGenericRPCError(err.Error()))
+			} else {
+				stats.determineValidatorSetPower(validators, hexAddress)
+			}
+		}
+		var plan *upgradetypes.Plan
+		if chainType.hasSDKModules() {
+			span := stats.span.startCall("upgrade plan", vm.RPC)
+			plan, err = getCachedUpgradePlan(ctx, config, client, vm, stats.Height)
+			span.endCall(stats.Height, err)
+		}
+		if err != nil {
+			errs = append(errs, newGenericRPCError(err.Error()))
+		} else if plan != nil && plan.Height > stats.Height {
+			stats.UpgradeName = plan.Name
+			stats.UpgradeHeight = plan.Height
+			// sample block time so an upgrade ETA is available before a rolling estimate exists
+			sampleHeight := stats.Height - blockTimeSampleBlocks
+			if vm.BlockTime == nil && sampleHeight > 0 {
+				blockCtx, blockCtxCancel := context.WithTimeout(ctx, time.Duration(time.Second*RPCTimeoutSeconds))
+				block, err := node.Block(blockCtx, &sampleHeight)
 				blockCtxCancel()
-				if err != nil {
-					// generic RPC error for this one so it will be included in the generic RPC error retry
-					errs = append(errs, newGenericRPCError(newBlockFetchError(i, vm.RPC).Error()))
-					continue
+				if err == nil {
+					stats.BlockTime = stats.Timestamp.Sub(block.Block.Time) / blockTimeSampleBlocks
 				}
-				if i == 1 {
-					break
+			}
+		}
+		usedBitmap := false
+		if !vm.FullNode && windowSigningInfo != nil && vm.usesMissedBlockBitmap(status.NodeInfo.Version) {
+			span := stats.span.startCall("missed blocks bitmap", vm.RPC)
+			bitmapErrs, err := stats.determineBitmapRecentBlocks(ctx, vm, node, newMissedBlockBitmap(node, hexAddress), *windowSigningInfo, slashingPeriod)
+			span.endCall(stats.Height, err)
+			if err != nil {
+				fmt.Printf("Error reading missed blocks bitmap of %s, scanning the recent blocks instead: %s\n", vm.Name, redactError(err))
+				stats.RecentMissedBlocks = 0
+				stats.RecentMissedBlockStreak = 0
+				stats.LastSignedBlockHeight = -1
+			} else {
+				usedBitmap = true
+				errs = append(errs, bitmapErrs...)
+			}
+		}
+		if !vm.FullNode && !usedBitmap {
+			// blocks received by the new block subscription don't need to be fetched
+			subscription := blockSubscriptions.get(vm.Name)
+			concurrency := defaultRecentBlocksConcurrency
+			if vm.RecentBlocksConcurrency != nil {
+				concurrency = *vm.RecentBlocksConcurrency
+			}
+			span := stats.span.startCall("recent blocks", vm.RPC)
+			scanCtx, scanCtxCancel := context.WithTimeout(ctx, recentBlocksScanTimeout)
+			recentBlocks := scanRecentBlocks(scanCtx, stats.Height, vm.RecentBlocksToCheck, concurrency, func(ctx context.Context, height int64) (blockSigningInfo, error) {
+				if signingInfo, ok := subscription.get(height); ok {
+					return signingInfo, nil
 				}
-				found := false
-				for _, voter := range block.Block.LastCommit.Signatures {
-					if reflect.DeepEqual(voter.ValidatorAddress, bytes.HexBytes(hexAddress)) {
-						if block.Block.Height > stats.LastSignedBlockHeight {
-							stats.LastSignedBlockHeight = block.Block.Height
-							stats.LastSignedBlockTimestamp = block.Block.Time
-						}
-						found = true
-						break
-					}
+				block, err := getCachedBlock(ctx, node, vm, height)
+				if err != nil {
+					return blockSigningInfo{}, err
 				}
-				if !found {
-					stats.RecentMissedBlocks++
+				return getBlockSigningInfo(block, hexAddress), nil
+			})
+			scanCtxCancel()
+			var skipped, failed int64
+			for _, recentBlock := range recentBlocks {
+				if recentBlock.skipped {
+					skipped++
+				} else if recentBlock.err != nil {
+					failed++
 				}
 			}
+			span.set(intAttribute("halflife.blocks", int64(len(recentBlocks))), intAttribute("halflife.blocks_skipped", skipped),
+				intAttribute("halflife.blocks_failed", failed))
+			var scanErr error
+			if skipped+failed > 0 {
+				scanErr = fmt.Errorf("%d of %d blocks not fetched", skipped+failed, len(recentBlocks))
+			}
+			span.endCall(stats.Height, scanErr)
+			if skipped > 0 {
+				// the missed blocks are undercounted, so the scan is reported as a block fetch error
+				fmt.Printf("Recent blocks scan of %s did not complete within %s, %d of %d blocks not checked, lower recent_blocks_to_check or raise recent-blocks-concurrency\n",
+					vm.Name, recentBlocksScanTimeout, skipped, len(recentBlocks))
+				errs = append(errs, newBlockFetchError(recentBlocks[len(recentBlocks)-int(skipped)].height, vm.RPC))
+			}
+			errs = append(errs, stats.determineRecentBlocks(vm, recentBlocks)...)
 		}
 
-		var missedBlocksThreshold int64
-		if vm.MissedBlocksThreshold == nil {
-			missedBlocksThreshold = defaultMissedBlocksThreshold
-		} else {
-			missedBlocksThreshold = *vm.MissedBlocksThreshold
-		}
-
-		if !vm.FullNode && stats.RecentMissedBlocks > missedBlocksThreshold {
-			errs = append(errs, newMissedRecentBlocksError(stats.RecentMissedBlocks, vm.RecentBlocksToCheck))
+		if !vm.FullNode && !usedBitmap && stats.RecentMissedBlocks > vm.getMissedBlocksThreshold() {
 			// Go back to find last signed block
 			if stats.LastSignedBlockHeight == -1 {
 				for i := stats.Height - vm.RecentBlocksToCheck; stats.LastSignedBlockHeight == -1 && i > (stats.Height-slashingPeriod) && i > 0; i-- {
-					blockCtx, blockCtxCancel := context.WithTimeout(context.Background(), time.Duration(time.Second*RPCTimeoutSeconds))
+					blockCtx, blockCtxCancel := context.WithTimeout(ctx, time.Duration(time.Second*RPCTimeoutSeconds))
 					block, err := node.Block(blockCtx, &i)
 					blockCtxCancel()
 					if err != nil {
@@ -154,60 +373,242 @@ func monitorValidator(
 	return
 }
 
+// determineSlashingUptime sets the slashing period uptime and the missed blocks left before jailing from the signing
+// info, and returns the slashing SLA error when uptime is under the error threshold or too few blocks are left
+func (stats *ValidatorStats) determineSlashingUptime(
+	vm *ValidatorMonitor,
+	signingInfo slashingtypes.ValidatorSigningInfo,
+	params slashingtypes.Params,
+) (errs []IgnorableError) {
+	stats.SlashingPeriodUptime = 100.0 - 100.0*(float64(signingInfo.MissedBlocksCounter)/float64(params.SignedBlocksWindow))
+	stats.BlocksUntilJailed = getBlocksUntilJailed(params, signingInfo.MissedBlocksCounter)
+
+	if stats.SlashingPeriodUptime < vm.SlashingPeriodUptimeErrorThreshold {
+		errs = append(errs, newSlashingSLAError(stats.SlashingPeriodUptime, vm.SlashingPeriodUptimeErrorThreshold, stats.BlocksUntilJailed))
+	} else if vm.JailBufferBlocks != nil && stats.BlocksUntilJailed < *vm.JailBufferBlocks {
+		errs = append(errs, newSlashingJailBufferError(stats.SlashingPeriodUptime, stats.BlocksUntilJailed, *vm.JailBufferBlocks))
+	}
+	return
+}
+
+// determineRecentBlocks counts the missed blocks and the longest missed block streak in the recent blocks, in order
+// from the height down, and returns the double sign, block fetch, and missed blocks errors found in them
+func (stats *ValidatorStats) determineRecentBlocks(vm *ValidatorMonitor, recentBlocks []recentBlock) (errs []IgnorableError) {
+	// blocks that could not be fetched neither extend nor end a streak
+	var streak int64
+	for _, recentBlock := range recentBlocks {
+		i, signingInfo := recentBlock.height, recentBlock.info
+		if recentBlock.skipped {
+			break
+		}
+		if recentBlock.err != nil {
+			// generic RPC error for this one so it will be included in the generic RPC error retry
+			errs = append(errs, newGenericRPCError(newBlockFetchError(i, vm.RPC).Error()))
+			continue
+		}
+		if signingInfo.doubleSign != nil {
+			errs = append(errs, signingInfo.doubleSign)
+		}
+		if i == 1 {
+			break
+		}
+		if signingInfo.signed {
+			streak = 0
+			if signingInfo.height > stats.LastSignedBlockHeight {
+				stats.LastSignedBlockHeight = signingInfo.height
+				stats.LastSignedBlockTimestamp = signingInfo.time
+			}
+		} else {
+			stats.RecentMissedBlocks++
+			streak++
+			if streak > stats.RecentMissedBlockStreak {
+				stats.RecentMissedBlockStreak = streak
+			}
+		}
+	}
+
+	if stats.RecentMissedBlockStreak > vm.getMissedBlockStreakThreshold() {
+		errs = append(errs, newMissedBlockStreakError(stats.RecentMissedBlockStreak, stats.RecentMissedBlocks, vm.RecentBlocksToCheck))
+	}
+	if stats.RecentMissedBlocks > vm.getMissedBlocksThreshold() {
+		errs = append(errs, newMissedRecentBlocksError(stats.RecentMissedBlocks, stats.RecentMissedBlockStreak, vm.RecentBlocksToCheck))
+	}
+	return
+}
+
+// getReferenceRPC returns the independent rpc the validator's halts are cross-checked against, or empty
+func getReferenceRPC(config *HalfLifeConfig, vm *ValidatorMonitor) string {
+	if vm.ReferenceRPC != "" {
+		return vm.ReferenceRPC
+	}
+	return config.ReferenceRPCs[vm.ChainID]
+}
+
+// getHaltError cross-checks a stalled height against the reference rpc. When the reference is also stalled, block
+// production has stopped on the whole chain, otherwise the halt is local to the validator's rpc node. Without a
+// reachable reference rpc, the halt is reported as before.
+func getHaltError(ctx context.Context, config *HalfLifeConfig, vm *ValidatorMonitor, timeSinceLastBlock int64) IgnorableError {
+	referenceRPC := getReferenceRPC(config, vm)
+	if referenceRPC == "" {
+		return newChainHaltError(timeSinceLastBlock)
+	}
+	status, err := getRPCStatus(ctx, referenceRPC, getProxy(config, vm), getRPCTLS(config, vm))
+	if err != nil {
+		fmt.Printf("Error querying reference rpc %s of %s: %s\n", redactSecrets(referenceRPC), vm.Name, redactError(err))
+		return newChainHaltError(timeSinceLastBlock)
+	}
+	if status.NodeInfo.Network != vm.ChainID {
+		fmt.Printf("Reference rpc %s of %s is on chain-id %s, expected %s\n", redactSecrets(referenceRPC), vm.Name, status.NodeInfo.Network, vm.ChainID)
+		return newChainHaltError(timeSinceLastBlock)
+	}
+	referenceHeight := status.SyncInfo.LatestBlockHeight
+	if alertClock().Sub(status.SyncInfo.LatestBlockTime) > haltThresholdNanoseconds {
+		return newNetworkHaltError(vm.ChainID, timeSinceLastBlock, referenceHeight)
+	}
+	return newLocalHaltError(timeSinceLastBlock, referenceHeight)
+}
+
+// findDoubleSignEvidence returns an error for evidence in the block of the validator equivocating
+func findDoubleSignEvidence(block *tmtypes.Block, hexAddress []byte) *DoubleSignError {
+	for _, evidence := range block.Evidence.Evidence {
+		switch ev := evidence.(type) {
+		case *tmtypes.DuplicateVoteEvidence:
+			if ev.VoteA != nil && reflect.DeepEqual(ev.VoteA.ValidatorAddress, bytes.HexBytes(hexAddress)) {
+				return newDoubleSignError("duplicate vote", ev.Height(), block.Height)
+			}
+		case *tmtypes.LightClientAttackEvidence:
+			for _, validator := range ev.ByzantineValidators {
+				if reflect.DeepEqual(validator.Address, bytes.HexBytes(hexAddress)) {
+					return newDoubleSignError("light client attack", ev.Height(), block.Height)
+				}
+			}
+		}
+	}
+	return nil
+}
+
 func monitorSentry(
+	ctx context.Context,
+	config *HalfLifeConfig,
 	wg *sync.WaitGroup,
+	sentryStats *[]*SentryStats,
 	errs *[]error,
 	errsLock *sync.Mutex,
 	sentry Sentry,
-	stats *ValidatorStats,
+	checkSpan *traceSpan,
 	vm *ValidatorMonitor,
-	alertState *ValidatorAlertState,
-	alertStateLock *sync.Mutex,
 ) {
-	nodeInfo, syncInfo, err := getSentryInfo(sentry.GRPC)
+	span := checkSpan.startCall("sentry grpc", sentry.GRPC)
+	span.set(stringAttribute("halflife.sentry", sentry.Name))
+	nodeInfo, syncInfo, err := getSentryInfo(ctx, sentry, vm.Name+"/"+sentry.Name, getProxy(config, vm), config.SentryGRPC)
+	if err != nil {
+		span.endCall(0, err)
+	} else {
+		span.endCall(syncInfo.Block.Header.Height, nil)
+	}
 	var errsToAdd []error
-	sentryStats := SentryStats{Name: sentry.Name, SentryAlertType: sentryAlertTypeNone}
+	stat := SentryStats{Name: sentry.Name, Peers: -1, SentryAlertType: sentryAlertTypeNone}
 	if err != nil {
-		errsToAdd = append(errsToAdd, newSentryGRPCError(sentry.Name, err.Error()))
-		sentryStats.SentryAlertType = sentryAlertTypeGRPCError
+		errsToAdd = append(errsToAdd, newSentryGRPCError(sentry.Name, err.Error(), isGRPCTransportError(err)))
+		stat.SentryAlertType = sentryAlertTypeGRPCError
 	} else {
-		sentryStats.Height = syncInfo.Block.Header.Height
-		sentryStats.Version = nodeInfo.ApplicationVersion.GetVersion()
-		alertStateLock.Lock()
-		blockDelta := syncInfo.Block.Header.Height - alertState.SentryLatestHeight[sentry.Name]
-		alertState.SentryLatestHeight[sentry.Name] = syncInfo.Block.Header.Height
-		alertStateLock.Unlock()
-		if blockDelta == 0 {
-			timeSinceLastBlock := time.Now().UnixNano() - syncInfo.Block.Header.Time.UnixNano()
-			if timeSinceLastBlock > haltThresholdNanoseconds {
-				errsToAdd = append(errsToAdd, newSentryHaltError(sentry.Name, timeSinceLastBlock))
-				sentryStats.SentryAlertType = sentryAlertTypeHalt
+		stat.Height = syncInfo.Block.Header.Height
+		stat.Version = nodeInfo.ApplicationVersion.GetVersion()
+		stat.blockTime = syncInfo.Block.Header.Time
+	}
+	if sentry.RPC != "" {
+		span := checkSpan.startCall("sentry peers", sentry.RPC)
+		span.set(stringAttribute("halflife.sentry", sentry.Name))
+		peers, err := getSentryPeerCount(ctx, sentry.getRPCAddress(), getProxy(config, vm), getRPCTLS(config, vm))
+		span.endCall(0, err)
+		if err != nil {
+			fmt.Printf("Error fetching peer count for sentry %s: %s\n", sentry.Name, redactError(err))
+		} else {
+			stat.Peers = peers
+			minPeers := defaultSentryMinPeers
+			if vm.SentryMinPeers != nil {
+				minPeers = *vm.SentryMinPeers
+			}
+			if peers < minPeers {
+				errsToAdd = append(errsToAdd, newSentryLowPeersError(sentry.Name, peers, minPeers))
+				if stat.SentryAlertType == sentryAlertTypeNone {
+					stat.SentryAlertType = sentryAlertTypeLowPeers
+				}
 			}
 		}
 	}
+	if sentry.NodeExporterURL != "" {
+		if err := checkSentryHost(ctx, config, vm, sentry); err != nil {
+			errsToAdd = append(errsToAdd, err)
+		}
+	}
 	errsLock.Lock()
-	stats.SentryStats = append(stats.SentryStats, &sentryStats)
+	*sentryStats = append(*sentryStats, &stat)
 	*errs = append(*errs, errsToAdd...)
 	errsLock.Unlock()
 	wg.Done()
 }
 
+// monitorSentries checks the sentries of the validator, returning their stats and errors. The alert state is not
+// touched, the sentry heights are recorded once the check completes.
 func monitorSentries(
-	stats *ValidatorStats,
+	ctx context.Context,
+	config *HalfLifeConfig,
+	checkSpan *traceSpan,
 	vm *ValidatorMonitor,
-	alertState *ValidatorAlertState,
-	alertStateLock *sync.Mutex,
-) []error {
+) ([]*SentryStats, []error) {
+	sentryStats := make([]*SentryStats, 0)
 	errs := make([]error, 0)
 	wg := sync.WaitGroup{}
 	errsLock := sync.Mutex{}
 	sentries := *vm.Sentries
 	wg.Add(len(sentries))
 	for _, sentry := range sentries {
-		go monitorSentry(&wg, &errs, &errsLock, sentry, stats, vm, alertState, alertStateLock)
+		go monitorSentry(ctx, config, &wg, &sentryStats, &errs, &errsLock, sentry, checkSpan, vm)
 	}
 	wg.Wait()
-	return errs
+	return sentryStats, errs
+}
+
+// determineSentryHaltErrors records the latest height of each sentry that responded, and returns a halt error for a
+// sentry whose height has not moved since the last check and whose latest block is older than the halt threshold
+// requires locked alertState
+func (stats *ValidatorStats) determineSentryHaltErrors(alertState *ValidatorAlertState) (errs []error) {
+	for _, sentryStat := range stats.SentryStats {
+		if sentryStat.SentryAlertType == sentryAlertTypeGRPCError {
+			continue
+		}
+		blockDelta := sentryStat.Height - alertState.SentryLatestHeight[sentryStat.Name]
+		alertState.SentryLatestHeight[sentryStat.Name] = sentryStat.Height
+		if blockDelta != 0 {
+			continue
+		}
+		timeSinceLastBlock := alertClock().UnixNano() - sentryStat.blockTime.UnixNano()
+		if timeSinceLastBlock > haltThresholdNanoseconds {
+			errs = append(errs, newSentryHaltError(sentryStat.Name, sentryStat.Height, timeSinceLastBlock))
+			sentryStat.SentryAlertType = sentryAlertTypeHalt
+		}
+	}
+	return
+}
+
+// getStartOffset returns the delay before the first check of the validator, within the start jitter and at most the
+// check interval. The offset is derived from the validator name, so each validator keeps the same place in the interval.
+func getStartOffset(config *HalfLifeConfig, vm *ValidatorMonitor) time.Duration {
+	jitter := config.StartJitter
+	if vm.StartJitter != nil {
+		jitter = vm.StartJitter
+	}
+	if jitter == nil || *jitter <= 0 {
+		return 0
+	}
+	maxOffset := *jitter
+	if maxOffset > checkInterval {
+		maxOffset = checkInterval
+	}
+	hash := fnv.New64a()
+	_, _ = hash.Write([]byte(vm.Name))
+	return time.Duration(hash.Sum64() % uint64(maxOffset))
 }
 
 func runMonitor(
@@ -218,114 +619,637 @@ func runMonitor(
 	config *HalfLifeConfig,
 	vm *ValidatorMonitor,
 	writeConfigMutex *sync.Mutex,
+	history *AlertHistory,
 ) {
+	subscription := startBlockSubscription(vm)
+	if offset := getStartOffset(config, vm); offset > 0 {
+		fmt.Printf("Starting checks of %s in %s\n", vm.Name, offset.Round(time.Millisecond))
+		time.Sleep(offset)
+	}
 	for {
-		stats := ValidatorStats{}
-		var valErrs []IgnorableError
-		var sentryErrs []error
+		// the cycle deadline includes the wait for a check slot
+		cycleCtx, cancel := newCycleContext(config)
+		if checkSchedule.acquire(vm, true) {
+			cycle := tracing.startTrace("monitor cycle", stringAttribute("halflife.validator", vm.Name))
+			runMonitorCycle(cycleCtx, notificationService, alertState, alertStateLock, configFile, config, vm, writeConfigMutex, history, cycle)
+			cycle.end(nil)
+			checkSchedule.release()
+		}
+		cancel()
+		subscription.wait(checkInterval)
+	}
+}
 
-		wg := sync.WaitGroup{}
-		fmt.Printf("Monitoring validator\n")
-		wg.Add(1)
-		go func() {
-			var rpcRetries int
-			if vm.RPCRetries != nil {
-				rpcRetries = *vm.RPCRetries
-			} else {
-				rpcRetries = rpcErrorRetries
-			}
+// runMonitorCycle runs a single check of the validator and its sentries, sends any notifications,
+// and returns the worst alert level encountered. The check is traced as a span of the cycle, when it is traced.
+// A check that runs past its check-deadline or the cycle deadline is abandoned, leaving the alert state untouched,
+// and returns false for checked. The validator and sentry checks collect their results locally, which are only merged
+// into the stats and the alert state once both complete.
+func runMonitorCycle(
+	cycleCtx context.Context,
+	notificationService NotificationService,
+	alertState *ValidatorAlertState,
+	alertStateLock *sync.Mutex,
+	configFile string,
+	config *HalfLifeConfig,
+	vm *ValidatorMonitor,
+	writeConfigMutex *sync.Mutex,
+	history *AlertHistory,
+	cycle *traceSpan,
+) (alertLevel AlertLevel, checked bool) {
+	started := time.Now()
+	if cycleCtx.Err() != nil {
+		logAbandonedCheck(config, vm, cycleCtx, started)
+		return alertLevelNone, false
+	}
+	ctx, cancel := context.WithTimeout(cycleCtx, getCheckDeadline(config, vm))
+	defer cancel()
+	stats := ValidatorStats{}
+	stats.span = cycle.startChild("check validator",
+		stringAttribute("halflife.validator", vm.Name), stringAttribute("halflife.chain_id", vm.ChainID))
+	var valErrs []IgnorableError
+	var sentryErrs []error
+	valStats := ValidatorStats{span: stats.span}
+	var sentryStats []*SentryStats
+
+	wg := sync.WaitGroup{}
+	fmt.Printf("Monitoring validator\n")
+	wg.Add(1)
+	go func() {
+		var rpcRetries int
+		if vm.RPCRetries != nil {
+			rpcRetries = *vm.RPCRetries
+		} else {
+			rpcRetries = rpcErrorRetries
+		}
 
-			for i := 0; i < rpcRetries; i++ {
-				valErrs = monitorValidator(config, vm, &stats)
-				if len(valErrs) == 0 {
-					fmt.Printf("No errors found for validator: %s\n", vm.Name)
+		retryStart := time.Now()
+		for i := 0; i < rpcRetries && ctx.Err() == nil; i++ {
+			valErrs = monitorValidator(ctx, config, vm, &valStats)
+			if len(valErrs) == 0 {
+				fmt.Printf("No errors found for validator: %s\n", vm.Name)
+				break
+			}
+			fmt.Printf("Got validator errors: +%v\n", valErrs)
+			foundNonRPCError := false
+			for _, err := range valErrs {
+				if _, ok := err.(*GenericRPCError); !ok {
+					foundNonRPCError = true
 					break
 				}
-				fmt.Printf("Got validator errors: +%v\n", valErrs)
-				foundNonRPCError := false
-				for _, err := range valErrs {
-					if _, ok := err.(*GenericRPCError); !ok {
-						foundNonRPCError = true
-						break
-					}
-				}
-				if foundNonRPCError {
+			}
+			if foundNonRPCError {
+				break
+			}
+			if i < rpcRetries-1 {
+				delay := rpcRetryDelay(vm, i)
+				if time.Since(retryStart)+delay > checkInterval {
+					fmt.Printf("Found only RPC errors, retry budget exhausted for validator: %s\n", vm.Name)
 					break
 				}
-				if i < rpcRetries-1 {
-					fmt.Println("Found only RPC errors, retrying")
-					time.Sleep(time.Duration((i*i)+1) * time.Second) // exponential backoff retry
+				fmt.Printf("Found only RPC errors, retrying in %s\n", delay)
+				if !sleepContext(ctx, delay) {
+					break
 				}
-				// loop again up to n times if we are hitting only generic RPC errors
+			}
+			// loop again up to n times if we are hitting only generic RPC errors
+		}
+		wg.Done()
+	}()
+
+	if vm.Sentries != nil {
+		wg.Add(1)
+		go func() {
+			sentryStats, sentryErrs = monitorSentries(ctx, config, stats.span, vm)
+			if len(sentryErrs) == 0 {
+				fmt.Printf("No errors found for validator sentries: %s\n", vm.Name)
+			} else {
+				fmt.Printf("Got validator sentry errors: +%v\n", sentryErrs)
 			}
 			wg.Done()
 		}()
+	}
 
-		if vm.Sentries != nil {
-			wg.Add(1)
-			go func() {
-				sentryErrs = monitorSentries(&stats, vm, alertState, alertStateLock)
-				if len(sentryErrs) == 0 {
-					fmt.Printf("No errors found for validator sentries: %s\n", vm.Name)
-				} else {
-					fmt.Printf("Got validator sentry errors: +%v\n", sentryErrs)
-				}
-				wg.Done()
-			}()
+	done := make(chan struct{})
+	go func() {
+		wg.Wait()
+		close(done)
+	}()
+	select {
+	case <-done:
+	case <-ctx.Done():
+	}
+	// results cut short by the deadline would count as rpc errors, so they are discarded along with a check still running
+	if ctx.Err() != nil {
+		logAbandonedCheck(config, vm, cycleCtx, started)
+		// every call of the check is bound by the context, so the check winds down promptly, and the check slot is
+		// held until it has, so that abandoned checks don't overrun max-concurrent-checks
+		cancel()
+		<-done
+		stats.span.end(errCheckAbandoned)
+		return alertLevelNone, false
+	}
+	stats = valStats
+	stats.SentryStats = sentryStats
+
+	errs := []error{}
+	if len(valErrs) > 0 {
+		for _, e := range valErrs {
+			if e.Active(config.AlertConfig) {
+				errs = append(errs, e)
+			}
 		}
+	}
+	if len(sentryErrs) > 0 {
+		errs = append(errs, sentryErrs...)
+	}
 
-		wg.Wait()
+	alertStateLock.Lock()
+	errs = append(errs, stats.determineSentryHaltErrors(alertState)...)
+	// the block time is determined first for the out-of-sync duration threshold
+	stats.determineBlockTime(vm, alertState)
+	errs = append(errs, stats.determineAggregatedErrorsAndAlertLevel(vm)...)
+	errs = stats.determineConfirmedErrors(vm, alertState, errs)
+	errs = append(errs, stats.determineSlashingSLAErrors(config, vm, alertState, errs)...)
+	uptimeTrendErrs, uptimeSampled := stats.determineUptimeTrend(config, vm, alertClock())
+	errs = append(errs, uptimeTrendErrs...)
+	errs = append(errs, stats.determineBondStatusErrors(config, vm, alertState)...)
+	errs = append(errs, stats.determineVotingPowerErrors(config, vm, alertState)...)
+	errs = append(errs, stats.determineUpgradeErrors(config, vm, alertState)...)
+	errs = append(errs, stats.determineRPCFailureErrors(config, vm, alertState, errs)...)
+	errs = append(errs, stats.determineSentryDivergenceErrors(config, vm, alertState)...)
+	notification := getAlertNotification(config, vm, &stats, alertState, errs)
+	inhibited := alertState.getInhibitedAlertTypes(config.AlertConfig.getInhibitRules())
+	validatorStates.update(vm, stats, alertState)
+	alertStateLock.Unlock()
 
-		errs := []error{}
-		if len(valErrs) > 0 {
-			for _, e := range valErrs {
-				if e.Active(config.AlertConfig) {
-					errs = append(errs, e)
-				}
+	history.record(vm, stats, notification)
+	history.sampleUptime(vm, stats)
+	if uptimeSampled && config.StateFile != "" {
+		saveConfig(configFile, config, writeConfigMutex)
+	}
+
+	alertLevel = stats.AlertLevel
+	if notification != nil && notification.AlertLevel > alertLevel {
+		alertLevel = notification.AlertLevel
+	}
+	stats.span.set(intAttribute("halflife.height", stats.Height), stringAttribute("halflife.alert_level", alertLevel.String()),
+		intAttribute("halflife.errors", int64(len(errs))))
+	stats.span.end(nil)
+
+	notification = notification.filterInhibited(vm, inhibited).filterByMinNotifyLevel(getMinNotifyLevel(config, vm)).filterQuietHours(getQuietHours(config, vm), alertClock())
+	if notification != nil {
+		if err := notificationService.SendValidatorAlertNotification(config, vm, stats, notification); err != nil {
+			fmt.Printf("Error sending alert notification for %s: %s\n", vm.Name, redactError(err))
+		} else {
+			alertStateLock.Lock()
+			alertState.recordAlertsSent(config, notification)
+			alertStateLock.Unlock()
+		}
+	}
+
+	validatorStatuses.update(vm, stats)
+
+	if !isStatusMessageDisabled(config, vm) {
+		if err := notificationService.UpdateValidatorRealtimeStatus(configFile, config, vm, stats, writeConfigMutex); err != nil {
+			fmt.Printf("Error updating status for %s: %s\n", vm.Name, redactError(err))
+		}
+	}
+
+	return alertLevel, true
+}
+
+// recordAlertsSent starts the cooldowns of the alerts of a notification once it is sent, so that alerts that were
+// filtered out or failed to send don't hold back the next one
+// requires locked alertState
+func (alertState *ValidatorAlertState) recordAlertsSent(config *HalfLifeConfig, notification *ValidatorAlertNotification) {
+	if notification == nil {
+		return
+	}
+	for _, key := range notification.AlertKeys {
+		if config.AlertConfig.Cooldowns[key.AlertType] > 0 {
+			alertState.AlertLastSent[key.AlertType] = alertClock()
+		}
+	}
+}
+
+// determineVotingPower finds the validator by consensus address and records its bond status, voting power and rank within the active set
+func (stats *ValidatorStats) determineVotingPower(validators stakingtypes.Validators, consAddress []byte) {
+	var bonded stakingtypes.Validators
+	for _, validator := range validators {
+		if validator.IsBonded() {
+			bonded = append(bonded, validator)
+		}
+	}
+	sort.SliceStable(bonded, func(i, j int) bool {
+		return bonded[i].Tokens.GT(bonded[j].Tokens)
+	})
+
+	for _, validator := range validators {
+		validatorConsAddress, err := validator.GetConsAddr()
+		if err != nil || !validatorConsAddress.Equals(sdk.ConsAddress(consAddress)) {
+			continue
+		}
+		stats.BondStatus = validator.Status.String()
+		stats.BondedTokens = validator.Tokens.String()
+		stats.VotingPower = validator.PotentialConsensusPower(sdk.DefaultPowerReduction)
+		for i, bondedValidator := range bonded {
+			if bondedValidator.OperatorAddress == validator.OperatorAddress {
+				stats.VotingPowerRank = i + 1
+				break
+			}
+		}
+		return
+	}
+}
+
+// requires locked alertState
+func (stats *ValidatorStats) determineVotingPowerErrors(
+	config *HalfLifeConfig,
+	vm *ValidatorMonitor,
+	alertState *ValidatorAlertState,
+) (errs []error) {
+	if vm.FullNode || stats.BondStatus == "" {
+		return
+	}
+	if stats.VotingPower > alertState.VotingPowerMax {
+		alertState.VotingPowerMax = stats.VotingPower
+	}
+
+	// a validator that isn't bonded is alerted on by determineBondStatusErrors
+	if stats.BondStatus != stakingtypes.Bonded.String() {
+		return
+	}
+
+	var votingPowerErr *VotingPowerError
+	if vm.MinVotingPower != nil && stats.VotingPower < *vm.MinVotingPower {
+		votingPowerErr = newVotingPowerError(fmt.Sprintf("voting power below minimum of %d", *vm.MinVotingPower), stats.VotingPower, stats.VotingPowerRank)
+	} else if alertState.VotingPowerMax > 0 {
+		dropThreshold := defaultVotingPowerDropThreshold
+		if vm.VotingPowerDropThreshold != nil {
+			dropThreshold = *vm.VotingPowerDropThreshold
+		}
+		drop := 100.0 * float64(alertState.VotingPowerMax-stats.VotingPower) / float64(alertState.VotingPowerMax)
+		if drop > dropThreshold {
+			votingPowerErr = newVotingPowerError(fmt.Sprintf("voting power dropped %.02f%% from %d", drop, alertState.VotingPowerMax), stats.VotingPower, stats.VotingPowerRank)
+			// the drop is alerted once, then the current power is the baseline for further drops, so that a
+			// deliberate undelegation doesn't keep alerting until the old peak is regained
+			alertState.VotingPowerMax = stats.VotingPower
+		}
+	}
+
+	if votingPowerErr != nil && votingPowerErr.Active(config.AlertConfig) {
+		errs = append(errs, votingPowerErr)
+	}
+	return
+}
+
+// determineBondStatusErrors alerts while the validator is unbonding or unbonded, e.g. after being kicked from
+// the active set or a self-undelegation, until it is bonded again.
+// requires locked alertState
+func (stats *ValidatorStats) determineBondStatusErrors(
+	config *HalfLifeConfig,
+	vm *ValidatorMonitor,
+	alertState *ValidatorAlertState,
+) (errs []error) {
+	if vm.FullNode || stats.BondStatus == "" {
+		return
+	}
+	previous := alertState.BondStatus
+	alertState.BondStatus = stats.BondStatus
+	if stats.BondStatus == stakingtypes.Bonded.String() {
+		return
+	}
+	bondStatusErr := newBondStatusError(stats.BondStatus, previous, stats.BondedTokens)
+	if bondStatusErr.Active(config.AlertConfig) {
+		errs = append(errs, bondStatusErr)
+	}
+	return
+}
+
+// getBlocksUntilJailed returns how many more blocks can be missed in the signing window before the validator is jailed.
+// The validator is jailed once the missed blocks exceed the window less the min signed blocks, so the
+// estimate is at most that number plus one while no blocks are missed, and never negative.
+func getBlocksUntilJailed(params slashingtypes.Params, missedBlocks int64) int64 {
+	minSigned := params.MinSignedPerWindow.MulInt64(params.SignedBlocksWindow).RoundInt64()
+	maxMissed := params.SignedBlocksWindow - minSigned
+	blocksUntilJailed := maxMissed - missedBlocks + 1
+	if blocksUntilJailed < 0 {
+		return 0
+	}
+	if blocksUntilJailed > maxMissed+1 {
+		return maxMissed + 1
+	}
+	return blocksUntilJailed
+}
+
+func (vm *ValidatorMonitor) getSlashingPeriodUptimeClearThreshold() float64 {
+	if vm.SlashingPeriodUptimeClearThreshold != nil {
+		return *vm.SlashingPeriodUptimeClearThreshold
+	}
+	return vm.SlashingPeriodUptimeErrorThreshold
+}
+
+// determineSlashingSLAErrors keeps the slashing sla alert active once uptime falls under the error threshold
+// until it recovers above the clear threshold, so that the alert does not flap while uptime slowly recovers
+// around the error threshold. The alert state is left as is when uptime could not be queried.
+// requires locked alertState
+func (stats *ValidatorStats) determineSlashingSLAErrors(
+	config *HalfLifeConfig,
+	vm *ValidatorMonitor,
+	alertState *ValidatorAlertState,
+	cycleErrs []error,
+) (errs []error) {
+	for _, err := range cycleErrs {
+		if err, ok := err.(*SlashingSLAError); ok {
+			// only uptime under the error threshold arms the alert, the jail buffer alert clears as soon as it passes
+			if err.jailBuffer == 0 && !err.recovering {
+				alertState.SlashingSLAArmed = true
 			}
+			return
+		}
+	}
+	if vm.FullNode || !alertState.SlashingSLAArmed || stats.SlashingPeriodUptime == 0 {
+		return
+	}
+	clearThreshold := vm.getSlashingPeriodUptimeClearThreshold()
+	if stats.SlashingPeriodUptime >= clearThreshold {
+		alertState.SlashingSLAArmed = false
+		return
+	}
+	slashingSLAErr := newSlashingSLARecoveringError(stats.SlashingPeriodUptime, clearThreshold, stats.BlocksUntilJailed)
+	if slashingSLAErr.Active(config.AlertConfig) {
+		errs = append(errs, slashingSLAErr)
+	}
+	return
+}
+
+// verifySigningInfo checks a jailed or tombstoned signing info against the confirm rpc. Conditions that the confirm rpc
+// does not report are dropped, and the conditions are verified when it reports them too. When the confirm rpc can't be
+// queried, the conditions are kept unverified so that they are confirmed by consecutive checks instead.
+func verifySigningInfo(ctx context.Context, config *HalfLifeConfig, vm *ValidatorMonitor, tombstoned bool, jailed bool) (bool, bool, bool) {
+	client, err := getCosmosClient(vm.ConfirmRPC, vm.ChainID, getProxy(config, vm), getRPCTLS(config, vm))
+	if err != nil {
+		fmt.Printf("Error creating confirm rpc client for %s: %s\n", vm.Name, redactError(err))
+		return tombstoned, jailed, false
+	}
+	valInfo, err := getSigningInfo(ctx, client, vm.Address)
+	if err != nil {
+		fmt.Printf("Error verifying signing info of %s against confirm rpc: %s\n", vm.Name, redactError(err))
+		return tombstoned, jailed, false
+	}
+	confirmTombstoned := valInfo.ValSigningInfo.Tombstoned
+	confirmJailed := valInfo.ValSigningInfo.JailedUntil.After(alertClock())
+	if (tombstoned && !confirmTombstoned) || (jailed && !confirmJailed) {
+		fmt.Printf("Confirm rpc does not report %s as jailed or tombstoned, ignoring\n", vm.Name)
+	}
+	return tombstoned && confirmTombstoned, jailed && confirmJailed, true
+}
+
+// determineConfirmedErrors drops jailed and tombstoned errors until they have been seen for the confirm checks in a row,
+// or were verified against the confirm rpc, so that a single bad rpc response does not page. Alerts that are already
+// active are kept.
+// requires locked alertState
+func (stats *ValidatorStats) determineConfirmedErrors(vm *ValidatorMonitor, alertState *ValidatorAlertState, cycleErrs []error) []error {
+	confirmChecks := defaultJailConfirmChecks
+	if vm.JailConfirmChecks != nil {
+		confirmChecks = *vm.JailConfirmChecks
+	}
+	seen := make(map[AlertType]bool)
+	var errs []error
+	for _, err := range cycleErrs {
+		var alertType AlertType
+		var verified bool
+		switch err := err.(type) {
+		case *JailedError:
+			alertType, verified = alertTypeJailed, err.verified
+		case *TombstonedError:
+			alertType, verified = alertTypeTombstoned, err.verified
+		default:
+			errs = append(errs, err)
+			continue
 		}
-		if len(sentryErrs) > 0 {
-			errs = append(errs, sentryErrs...)
+		seen[alertType] = true
+		alertState.PendingConfirmCounts[alertType]++
+		if verified || alertState.AlertTypeCounts[alertType] > 0 || alertState.PendingConfirmCounts[alertType] >= confirmChecks {
+			errs = append(errs, err)
+			continue
 		}
+		fmt.Printf("%s reported for %s, waiting for confirmation (%d/%d checks)\n", alertType, vm.Name, alertState.PendingConfirmCounts[alertType], confirmChecks)
+	}
+	for alertType := range alertState.PendingConfirmCounts {
+		if !seen[alertType] {
+			delete(alertState.PendingConfirmCounts, alertType)
+		}
+	}
+	return errs
+}
 
-		aggregatedErrs := stats.determineAggregatedErrorsAndAlertLevel(vm)
-		if len(aggregatedErrs) > 0 {
-			errs = append(errs, aggregatedErrs...)
+// determineRPCFailureErrors tracks consecutive checks with rpc errors, escalating once the streak
+// reaches the configured threshold. The streak resets when a check succeeds.
+// The rpc health is copied to the stats for the status message.
+// requires locked alertState
+func (stats *ValidatorStats) determineRPCFailureErrors(
+	config *HalfLifeConfig,
+	vm *ValidatorMonitor,
+	alertState *ValidatorAlertState,
+	cycleErrs []error,
+) (errs []error) {
+	rpcFailed := false
+	for _, err := range cycleErrs {
+		if _, ok := err.(*GenericRPCError); ok {
+			rpcFailed = true
+			break
 		}
+	}
+	defer func() {
+		stats.RPCLastSuccess = alertState.RPCLastSuccess
+		stats.RPCConsecutiveFailures = alertState.ConsecutiveRPCFailures
+	}()
+	if !rpcFailed {
+		alertState.ConsecutiveRPCFailures = 0
+		alertState.RPCLastSuccess = alertClock()
+		return
+	}
+	if alertState.ConsecutiveRPCFailures == 0 {
+		alertState.RPCFailingSince = alertClock()
+	}
+	alertState.ConsecutiveRPCFailures++
 
-		alertStateLock.Lock()
-		notification := getAlertNotification(config, vm, &stats, alertState, errs)
-		alertStateLock.Unlock()
+	failureStreak := defaultRPCFailureStreak
+	if vm.RPCFailureStreak != nil {
+		failureStreak = *vm.RPCFailureStreak
+	}
+	if failureStreak > 0 && alertState.ConsecutiveRPCFailures >= failureStreak {
+		rpcUnreachableErr := newRPCUnreachableError(alertState.ConsecutiveRPCFailures, alertState.RPCFailingSince)
+		if rpcUnreachableErr.Active(config.AlertConfig) {
+			errs = append(errs, rpcUnreachableErr)
+		}
+	}
+	return
+}
 
-		if notification != nil {
-			notificationService.SendValidatorAlertNotification(config, vm, stats, notification)
+// determineSentryDivergenceErrors alerts when the spread between the fastest and slowest sentry heights
+// exceeds the threshold, which catches a forked or partitioned sentry that is not behind the validator's RPC.
+// Only sentries that responded this check are compared.
+// requires locked alertState
+func (stats *ValidatorStats) determineSentryDivergenceErrors(
+	config *HalfLifeConfig,
+	vm *ValidatorMonitor,
+	alertState *ValidatorAlertState,
+) (errs []error) {
+	threshold := defaultSentryDivergenceThreshold
+	if vm.SentryDivergenceThreshold != nil {
+		threshold = *vm.SentryDivergenceThreshold
+	}
+	if threshold <= 0 {
+		return
+	}
+	var fastest, slowest string
+	var fastestHeight, slowestHeight int64
+	responded := 0
+	for _, sentryStat := range stats.SentryStats {
+		if sentryStat.SentryAlertType == sentryAlertTypeGRPCError {
+			continue
+		}
+		height := alertState.SentryLatestHeight[sentryStat.Name]
+		if responded == 0 || height > fastestHeight {
+			fastest, fastestHeight = sentryStat.Name, height
+		}
+		if responded == 0 || height < slowestHeight {
+			slowest, slowestHeight = sentryStat.Name, height
 		}
+		responded++
+	}
+	if responded < 2 || fastestHeight-slowestHeight <= threshold {
+		return
+	}
+	divergenceErr := newSentryDivergenceError(fastest, fastestHeight, slowest, slowestHeight, threshold)
+	if divergenceErr.Active(config.AlertConfig) {
+		stats.increaseAlertLevel(alertLevelWarning)
+		errs = append(errs, divergenceErr)
+	}
+	return
+}
 
-		notificationService.UpdateValidatorRealtimeStatus(configFile, config, vm, stats, writeConfigMutex)
+// determineBlockTime sets the block time from config, or from a rolling estimate
+// of the delta between the last two observed heights and timestamps.
+// requires locked alertState
+func (stats *ValidatorStats) determineBlockTime(vm *ValidatorMonitor, alertState *ValidatorAlertState) {
+	if stats.Height > alertState.LastHeight && !alertState.LastTimestamp.IsZero() && stats.Timestamp.After(alertState.LastTimestamp) {
+		sample := stats.Timestamp.Sub(alertState.LastTimestamp) / time.Duration(stats.Height-alertState.LastHeight)
+		if alertState.BlockTimeEstimate == 0 {
+			alertState.BlockTimeEstimate = sample
+		} else {
+			alertState.BlockTimeEstimate = time.Duration((1-blockTimeEstimateWeight)*float64(alertState.BlockTimeEstimate) + blockTimeEstimateWeight*float64(sample))
+		}
+	} else if alertState.BlockTimeEstimate == 0 && stats.BlockTime > 0 {
+		// seed with the sampled block time
+		alertState.BlockTimeEstimate = stats.BlockTime
+	}
+	if stats.Height > 0 {
+		alertState.LastHeight = stats.Height
+		alertState.LastTimestamp = stats.Timestamp
+	}
 
-		time.Sleep(30 * time.Second)
+	if vm.BlockTime != nil {
+		stats.BlockTime = *vm.BlockTime
+	} else {
+		stats.BlockTime = alertState.BlockTimeEstimate
 	}
 }
 
+// requires locked alertState
+func (stats *ValidatorStats) determineUpgradeErrors(
+	config *HalfLifeConfig,
+	vm *ValidatorMonitor,
+	alertState *ValidatorAlertState,
+) (errs []error) {
+	if stats.UpgradeHeight == 0 {
+		return
+	}
+	if stats.UpgradeHeight != alertState.UpgradeHeight {
+		// new or rescheduled upgrade plan, start milestones over
+		alertState.UpgradeHeight = stats.UpgradeHeight
+		alertState.UpgradeMilestone = 0
+	}
+
+	alertBlocks := defaultUpgradeAlertBlocks
+	if vm.UpgradeAlertBlocks != nil {
+		alertBlocks = *vm.UpgradeAlertBlocks
+	}
+	remaining := stats.UpgradeHeight - stats.Height
+	if remaining > alertBlocks {
+		return
+	}
+
+	milestone := alertBlocks
+	for _, m := range upgradeAlertMilestones {
+		if m < milestone && remaining <= m {
+			milestone = m
+		}
+	}
+
+	eta := time.Duration(remaining) * stats.BlockTime
+	upgradeErr := newUpgradeError(stats.UpgradeName, stats.UpgradeHeight, remaining, eta, milestone)
+	if upgradeErr.Active(config.AlertConfig) {
+		errs = append(errs, upgradeErr)
+	}
+	return
+}
+
+// rpcRetryDelay returns the exponential backoff delay, with jitter, to wait before the given retry attempt
+func rpcRetryDelay(vm *ValidatorMonitor, attempt int) time.Duration {
+	baseDelay := defaultRPCRetryBaseDelay
+	if vm.RPCRetryBaseDelay != nil {
+		baseDelay = *vm.RPCRetryBaseDelay
+	}
+	maxDelay := defaultRPCRetryMaxDelay
+	if vm.RPCRetryMaxDelay != nil {
+		maxDelay = *vm.RPCRetryMaxDelay
+	}
+	delay := maxDelay
+	if attempt < 32 && baseDelay<<attempt > 0 && baseDelay<<attempt < maxDelay {
+		delay = baseDelay << attempt
+	}
+	if delay <= 0 {
+		return 0
+	}
+	// equal jitter, wait between half and all of the backoff delay
+	return delay/2 + time.Duration(rand.Int63n(int64(delay/2)+1))
+}
+
 func (stats *ValidatorStats) increaseAlertLevel(alertLevel AlertLevel) {
 	if stats.AlertLevel < alertLevel {
 		stats.AlertLevel = alertLevel
 	}
 }
 
+// getSentryOutOfSyncThreshold returns the number of blocks a sentry can be behind the RPC before it is out of sync.
+// The out-of-sync duration is converted to blocks with the block time when both are known, otherwise the block count
+// threshold is used.
+func (vm *ValidatorMonitor) getSentryOutOfSyncThreshold(blockTime time.Duration) int64 {
+	if vm.SentryOutOfSyncDuration != nil && blockTime > 0 {
+		return int64(*vm.SentryOutOfSyncDuration / blockTime)
+	}
+	if vm.SentryOutOfSyncBlocksThreshold != nil {
+		return *vm.SentryOutOfSyncBlocksThreshold
+	}
+	return outOfSyncThreshold
+}
+
 // determine alert level and any additional errors now that RPC And sentry checks are complete
 func (stats *ValidatorStats) determineAggregatedErrorsAndAlertLevel(vm *ValidatorMonitor) (errs []error) {
 	sentryErrorCount := 0
+	threshold := vm.getSentryOutOfSyncThreshold(stats.BlockTime)
 	for _, sentryStat := range stats.SentryStats {
-		var threshold int64
-		if vm.SentryOutOfSyncBlocksThreshold != nil {
-			threshold = *vm.SentryOutOfSyncBlocksThreshold
-		} else {
-			threshold = outOfSyncThreshold
-		}
 		if sentryStat.SentryAlertType != sentryAlertTypeGRPCError {
-			if stats.Height-sentryStat.Height > threshold {
-				errs = append(errs, newSentryOutOfSyncError(sentryStat.Name, fmt.Sprintf("Height: %d not in sync with RPC Height: %d", sentryStat.Height, stats.Height)))
+			if lag := stats.Height - sentryStat.Height; lag > threshold {
+				message := fmt.Sprintf("Height: %d not in sync with RPC Height: %d", sentryStat.Height, stats.Height)
+				if vm.SentryOutOfSyncDuration != nil && stats.BlockTime > 0 {
+					message += fmt.Sprintf(", about %s behind", (time.Duration(lag) * stats.BlockTime).Round(time.Second))
+				}
+				errs = append(errs, newSentryOutOfSyncError(sentryStat.Name, message))
 				sentryStat.SentryAlertType = sentryAlertTypeOutOfSyncError
 			}
 		}
@@ -346,66 +1270,108 @@ func (stats *ValidatorStats) determineAggregatedErrorsAndAlertLevel(vm *Validato
 		stats.increaseAlertLevel(alertLevelHigh)
 	}
 
-	if !vm.FullNode {
-		// Missed blocks alert color logic: use config thresholds, not hardcoded values
-		var missedBlocksGreenTo int64 = 49
-		var missedBlocksYellowFrom int64 = 50
-		var missedBlocksYellowTo int64 = 99
-		var missedBlocksRedFrom int64 = 100
-		if vm.MissedBlocksGreenTo != nil {
-			missedBlocksGreenTo = *vm.MissedBlocksGreenTo
-		}
-		if vm.MissedBlocksYellowFrom != nil {
-			missedBlocksYellowFrom = *vm.MissedBlocksYellowFrom
-		}
-		if vm.MissedBlocksYellowTo != nil {
-			missedBlocksYellowTo = *vm.MissedBlocksYellowTo
-		}
-		if vm.MissedBlocksRedFrom != nil {
-			missedBlocksRedFrom = *vm.MissedBlocksRedFrom
-		}
-		if stats.RecentMissedBlocks <= missedBlocksGreenTo {
-			stats.RecentMissedBlockAlertLevel = alertLevelNone
-		} else if stats.RecentMissedBlocks >= missedBlocksRedFrom {
-			stats.RecentMissedBlockAlertLevel = alertLevelHigh
-			stats.increaseAlertLevel(alertLevelHigh)
-		} else if stats.RecentMissedBlocks >= missedBlocksYellowFrom && stats.RecentMissedBlocks <= missedBlocksYellowTo {
-			stats.RecentMissedBlockAlertLevel = alertLevelWarning
-			stats.increaseAlertLevel(alertLevelWarning)
-		}
+	if vm.FullNode {
+		// full nodes don't sign, so the alert level only reflects the sync, halt, and RPC checks
 		return
 	}
 
-	if stats.Height == stats.LastSignedBlockHeight {
-		if stats.RecentMissedBlocks == 0 {
-			if stats.SlashingPeriodUptime > vm.SlashingPeriodUptimeWarningThreshold {
-				// no recent missed blocks and above warning threshold for slashing period uptime, all good
-				return
-			}
-			// Warning for recovering from downtime. Not error because we are currently signing
-			stats.increaseAlertLevel(alertLevelWarning)
-			return
+	// Missed blocks alert color logic: use config thresholds, not hardcoded values
+	stats.RecentMissedBlockAlertLevel = vm.getMissedBlocksBands().alertLevel(stats.RecentMissedBlocks, vm.RecentBlocksToCheck)
+	if stats.RecentMissedBlockStreak > vm.getMissedBlockStreakThreshold() {
+		// a streak is active downtime, so it is shown at the streak alert level even when few blocks were missed overall
+		if streakAlertLevel := vm.getMissedBlockStreakAlertLevel(); streakAlertLevel > stats.RecentMissedBlockAlertLevel {
+			stats.RecentMissedBlockAlertLevel = streakAlertLevel
 		}
-		// Warning for missing recent blocks, but have signed current block
-		stats.increaseAlertLevel(alertLevelWarning)
-		return
 	}
+	stats.increaseAlertLevel(stats.RecentMissedBlockAlertLevel)
+	return
+}
+
+// getMissedBlocksThreshold returns the number of recent missed blocks above which the missed blocks alert is issued
+func (vm *ValidatorMonitor) getMissedBlocksThreshold() int64 {
+	if vm.MissedBlocksThreshold == nil {
+		return defaultMissedBlocksThreshold
+	}
+	return vm.MissedBlocksThreshold.blocks(vm.RecentBlocksToCheck)
+}
 
-	// past this, we have not signed the most recent block
+// getMissedBlockStreakThreshold returns the number of consecutive missed blocks above which the streak alert is issued
+func (vm *ValidatorMonitor) getMissedBlockStreakThreshold() int64 {
+	if vm.MissedBlockStreakThreshold == nil {
+		return defaultMissedBlockStreakThreshold
+	}
+	return vm.MissedBlockStreakThreshold.blocks(vm.RecentBlocksToCheck)
+}
 
-	if stats.RecentMissedBlocks < vm.RecentBlocksToCheck {
-		// we have missed some, but not all, of the recent blocks to check
-		if stats.SlashingPeriodUptime > vm.SlashingPeriodUptimeErrorThreshold {
-			stats.increaseAlertLevel(alertLevelWarning)
-		} else {
-			// we are below slashing period uptime error threshold
-			stats.increaseAlertLevel(alertLevelHigh)
+func (vm *ValidatorMonitor) getMissedBlockStreakAlertLevel() AlertLevel {
+	if vm.MissedBlockStreakAlertLevel == nil {
+		return defaultMissedBlockStreakAlertLevel
+	}
+	return *vm.MissedBlockStreakAlertLevel
+}
+
+// getMinNotifyLevel returns the minimum alert level for which notifications are sent,
+// preferring the validator setting over the global notifications setting
+func getMinNotifyLevel(config *HalfLifeConfig, vm *ValidatorMonitor) AlertLevel {
+	if vm.MinNotifyLevel != nil {
+		return *vm.MinNotifyLevel
+	}
+	if config.Notifications != nil && config.Notifications.MinNotifyLevel != nil {
+		return *config.Notifications.MinNotifyLevel
+	}
+	return alertLevelNone
+}
+
+// isStatusMessageDisabled returns whether only alerts are sent for the validator, without a status message,
+// preferring the validator setting over the global notifications setting
+func isStatusMessageDisabled(config *HalfLifeConfig, vm *ValidatorMonitor) bool {
+	if vm.DisableStatusMessage != nil {
+		return *vm.DisableStatusMessage
+	}
+	return config.Notifications != nil && config.Notifications.DisableStatusMessage
+}
+
+// filterByMinNotifyLevel drops alerts and cleared alerts below the minimum notify level.
+// Returns nil if nothing is left to notify.
+func (n *ValidatorAlertNotification) filterByMinNotifyLevel(minNotifyLevel AlertLevel) *ValidatorAlertNotification {
+	if n == nil {
+		return nil
+	}
+	filtered := *n
+	filtered.Alerts, filtered.AlertKeys, filtered.AlertLevels = nil, nil, nil
+	filtered.AlertLevel = alertLevelNone
+	for i, alert := range n.Alerts {
+		if n.AlertLevels[i] < minNotifyLevel {
+			continue
+		}
+		filtered.Alerts = append(filtered.Alerts, alert)
+		filtered.AlertKeys = append(filtered.AlertKeys, n.AlertKeys[i])
+		filtered.AlertLevels = append(filtered.AlertLevels, n.AlertLevels[i])
+		if n.AlertLevels[i] > filtered.AlertLevel {
+			filtered.AlertLevel = n.AlertLevels[i]
 		}
-	} else {
-		// Error, missed all of the recent blocks to check
-		stats.increaseAlertLevel(alertLevelHigh)
 	}
-	return
+	if filtered.ClearedAlertLevel < minNotifyLevel {
+		filtered.ClearedAlerts = nil
+		filtered.ClearedAlertKeys = nil
+	}
+	if len(filtered.Alerts) == 0 && len(filtered.ClearedAlerts) == 0 {
+		return nil
+	}
+	return &filtered
+}
+
+// withoutSigningErrors drops the errors of the signing checks, as full nodes don't sign
+func withoutSigningErrors(errs []error) []error {
+	var filtered []error
+	for _, err := range errs {
+		switch err.(type) {
+		case *JailedError, *TombstonedError, *MissedRecentBlocksError, *MissedBlockStreakError, *SlashingSLAError:
+			continue
+		}
+		filtered = append(filtered, err)
+	}
+	return filtered
 }
 
 // requires locked alertState
@@ -420,7 +1386,12 @@ func getAlertNotification(
 	var foundSentryGRPCErrors []string
 	var foundSentryOutOfSyncErrors []string
 	var foundSentryHaltErrors []string
+	var foundSentryLowPeersErrors []string
+	var foundSentryHostErrors []string
 	alertNotification := ValidatorAlertNotification{AlertLevel: alertLevelNone}
+	if vm.FullNode {
+		errs = withoutSigningErrors(errs)
+	}
 
 	setAlertLevel := func(al AlertLevel) {
 		if alertNotification.AlertLevel < al {
@@ -428,22 +1399,137 @@ func getAlertNotification(
 		}
 	}
 
-	addAlert := func(err error) {
+	addAlert := func(err error, alertType AlertType, sentry string, alertLevel AlertLevel) {
+		if _, acked := alertState.AckedAlerts[AlertKey{AlertType: alertType, Sentry: sentry}]; acked {
+			return
+		}
+		if cooldown := config.AlertConfig.Cooldowns[alertType]; alertType != "" && cooldown > 0 {
+			if lastSent, ok := alertState.AlertLastSent[alertType]; ok && alertClock().Sub(lastSent) < cooldown {
+				return
+			}
+		}
 		alertNotification.Alerts = append(alertNotification.Alerts, err.Error())
+		alertNotification.AlertKeys = append(alertNotification.AlertKeys, AlertKey{AlertType: alertType, Sentry: sentry})
+		alertNotification.AlertLevels = append(alertNotification.AlertLevels, alertLevel)
+		setAlertLevel(alertLevel)
+	}
+
+	// record alert transitions, a fired transition is recorded the first time an alert is seen
+	addFiredTransition := func(alertType AlertType, sentry string, alertLevel AlertLevel, err error) {
+		alertNotification.Transitions = append(alertNotification.Transitions, AlertTransition{
+			AlertType:  alertType,
+			Sentry:     sentry,
+			AlertLevel: alertLevel,
+			Message:    err.Error(),
+			DedupKey:   AlertKey{AlertType: alertType, Sentry: sentry}.dedupKey(vm),
+		})
+	}
+
+	// Alerts seen during the startup grace period are counted, but only notified if they are still active after it
+	inStartupGrace := alertClock().Before(alertState.StartupGraceUntil)
+	suppressAtStartup := func(alertType AlertType, sentry string) bool {
+		if !inStartupGrace || !startupGraceAlertTypes[alertType] {
+			return false
+		}
+		alertState.StartupSuppressed[AlertKey{AlertType: alertType, Sentry: sentry}] = true
+		return true
+	}
+
+	// wasSuppressedAtStartup returns whether the alert was suppressed during the startup grace period, and forgets it
+	wasSuppressedAtStartup := func(alertType AlertType, sentry string) bool {
+		key := AlertKey{AlertType: alertType, Sentry: sentry}
+		suppressed := alertState.StartupSuppressed[key]
+		delete(alertState.StartupSuppressed, key)
+		return suppressed
+	}
+
+	addClearedAlert := func(alertType AlertType, sentry string, clearedAlert string) {
+		delete(alertState.AckedAlerts, AlertKey{AlertType: alertType, Sentry: sentry})
+		if wasSuppressedAtStartup(alertType, sentry) {
+			// never notified, so there is nothing to clear
+			return
+		}
+		alertNotification.ClearedAlerts = append(alertNotification.ClearedAlerts, clearedAlert)
+		alertNotification.ClearedAlertKeys = append(alertNotification.ClearedAlertKeys, AlertKey{AlertType: alertType, Sentry: sentry})
+		alertNotification.Transitions = append(alertNotification.Transitions, AlertTransition{
+			Cleared:   true,
+			AlertType: alertType,
+			Sentry:    sentry,
+			Message:   clearedAlert,
+			DedupKey:  AlertKey{AlertType: alertType, Sentry: sentry}.dedupKey(vm),
+		})
+	}
+
+	// addSentryRecovered clears a sentry alert with a message naming the sentry and the condition that recovered.
+	// The recovery mentions when the alert had escalated, or for every notified sentry alert with notify-sentry-recovery.
+	addSentryRecovered := func(alertType AlertType, sentryName string, counts map[string]int64, notifyThreshold int64, heldChecks int64) {
+		key := AlertKey{AlertType: alertType, Sentry: sentryName}
+		if counts[sentryName] <= heldChecks {
+			// recovered before the alert was notified
+			counts[sentryName] = 0
+			return
+		}
+		_, notified := alertState.SentryLastNotified[key]
+		if counts[sentryName] > notifyThreshold || (notified && vm.NotifySentryRecovery) {
+			alertNotification.NotifyForClear = true
+		}
+		addClearedAlert(alertType, sentryName, fmt.Sprintf("%s recovered: %s after %d checks", sentryName, sentryRecoveries[alertType], counts[sentryName]))
+		counts[sentryName] = 0
+		delete(alertState.SentryLastNotified, key)
 	}
 
 	shouldNotifyForFoundAlertType := func(alertType AlertType) bool {
 		foundAlertTypes = append(foundAlertTypes, alertType)
-		shouldNotify := alertState.AlertTypeCounts[alertType]%vm.NotifyEvery == 0
+		shouldNotify := alertState.AlertTypeCounts[alertType]%int64(vm.NotifyEvery) == 0
 		alertState.AlertTypeCounts[alertType]++
 		return shouldNotify
 	}
 
 	handleGenericAlert := func(err error, alertType AlertType, alertLevel AlertLevel) {
-		if shouldNotifyForFoundAlertType(alertType) {
-			addAlert(err)
-			setAlertLevel(alertLevel)
+		firstOccurrence := alertState.AlertTypeCounts[alertType] == 0
+		if suppressAtStartup(alertType, "") {
+			shouldNotifyForFoundAlertType(alertType)
+			return
 		}
+		suppressed := wasSuppressedAtStartup(alertType, "")
+		if shouldNotifyForFoundAlertType(alertType) || suppressed {
+			addAlert(err, alertType, "", alertLevel)
+			if firstOccurrence || suppressed {
+				addFiredTransition(alertType, "", alertLevel, err)
+			}
+		}
+	}
+
+	sentryNotifyEvery := defaultSentryNotifyEvery
+	if vm.SentryNotifyEvery != nil {
+		sentryNotifyEvery = *vm.SentryNotifyEvery
+	}
+
+	// After the initial alert, a sentry alert is only repeated when it escalates, when the halted height
+	// changes, or at the reduced sentry notify cadence while nothing changes.
+	// the first heldChecks consecutive errors of a sentry are counted but not notified
+	handleSentryAlert := func(err error, alertType AlertType, sentryName string, height int64, counts map[string]int64, notifyThreshold int64, heldChecks int64) {
+		key := AlertKey{AlertType: alertType, Sentry: sentryName}
+		count := counts[sentryName]
+		if suppressAtStartup(alertType, sentryName) || count < heldChecks {
+			counts[sentryName]++
+			return
+		}
+		suppressed := wasSuppressedAtStartup(alertType, sentryName)
+		alertLevel := alertLevelWarning
+		if count >= notifyThreshold {
+			alertLevel = alertLevelHigh
+		}
+		first := count == heldChecks
+		lastNotified := alertState.SentryLastNotified[key]
+		if first || suppressed || alertLevel > lastNotified.Level || height != lastNotified.Height || count-lastNotified.Count >= sentryNotifyEvery {
+			addAlert(err, alertType, sentryName, alertLevel)
+			if first || suppressed {
+				addFiredTransition(alertType, sentryName, alertLevel, err)
+			}
+			alertState.SentryLastNotified[key] = SentryNotifyState{Count: count, Height: height, Level: alertLevel}
+		}
+		counts[sentryName]++
 	}
 
 	recentMissedBlocksCounter := alertState.RecentMissedBlocksCounter
@@ -455,10 +1541,32 @@ func getAlertNotification(
 		sentryGRPCNotifyThreshold = sentryGRPCErrorNotifyThreshold
 	}
 
+	var sentryHaltNotifyThreshold int64
+	if vm.SentryHaltThreshold != nil {
+		sentryHaltNotifyThreshold = *vm.SentryHaltThreshold
+	} else {
+		sentryHaltNotifyThreshold = sentryHaltErrorNotifyThreshold
+	}
+	// a halt is only notified once seen at sentry-halt-threshold consecutive checks
+	var sentryHaltHeldChecks int64
+	if sentryHaltNotifyThreshold > 1 {
+		sentryHaltHeldChecks = sentryHaltNotifyThreshold - 1
+	}
+
 	for _, err := range errs {
 		switch err := err.(type) {
 		case *JailedError:
 			handleGenericAlert(err, alertTypeJailed, alertLevelHigh)
+		case *DoubleSignError:
+			// Evidence is seen again each check while it is within the recent blocks,
+			// so only notify once for each infraction height.
+			foundAlertTypes = append(foundAlertTypes, alertTypeDoubleSign)
+			alertState.AlertTypeCounts[alertTypeDoubleSign]++
+			if err.height != alertState.DoubleSignHeight {
+				alertState.DoubleSignHeight = err.height
+				addAlert(err, alertTypeDoubleSign, "", alertLevelCritical)
+				addFiredTransition(alertTypeDoubleSign, "", alertLevelCritical, err)
+			}
 		case *TombstonedError:
 			handleGenericAlert(err, alertTypeTombstoned, alertLevelCritical)
 		case *OutOfSyncError:
@@ -468,31 +1576,77 @@ func getAlertNotification(
 			fmt.Printf("found chain halt error\n")
 			handleGenericAlert(err, alertTypeHalt, alertLevelHigh)
 			stats.RPCError = true
+		case *NetworkHaltError:
+			// nothing can be done about a halt of the whole network, so it is notified at warning without mentions
+			handleGenericAlert(err, alertTypeNetworkHalt, alertLevelWarning)
+		case *ChainIDMismatchError:
+			handleGenericAlert(err, alertTypeChainIDMismatch, alertLevelCritical)
 		case *BlockFetchError:
 			handleGenericAlert(err, alertTypeBlockFetch, alertLevelWarning)
+		case *VotingPowerError:
+			handleGenericAlert(err, alertTypeVotingPower, alertLevelHigh)
+		case *BondStatusError:
+			alertLevel := alertLevelHigh
+			if err.status == stakingtypes.Unbonded.String() {
+				alertLevel = alertLevelCritical
+			}
+			if alertState.AlertTypeCounts[alertTypeUnbonding] > 0 && err.statusChanged() {
+				// alert right away when the status changes while already alerting, e.g. unbonding to unbonded
+				foundAlertTypes = append(foundAlertTypes, alertTypeUnbonding)
+				alertState.AlertTypeCounts[alertTypeUnbonding]++
+				addAlert(err, alertTypeUnbonding, "", alertLevel)
+			} else {
+				handleGenericAlert(err, alertTypeUnbonding, alertLevel)
+			}
+		case *UpgradeError:
+			// Only alert each time the upgrade gets closer by another milestone
+			// (e.g. 1000, 100, 10 blocks away) rather than every NotifyEvery.
+			foundAlertTypes = append(foundAlertTypes, alertTypeUpgrade)
+			firstOccurrence := alertState.AlertTypeCounts[alertTypeUpgrade] == 0
+			alertState.AlertTypeCounts[alertTypeUpgrade]++
+			if alertState.UpgradeMilestone == 0 || err.milestone < alertState.UpgradeMilestone {
+				alertState.UpgradeMilestone = err.milestone
+				alertLevel := alertLevelWarning
+				if err.remaining <= upgradeHighAlertBlocks {
+					alertLevel = alertLevelHigh
+				}
+				addAlert(err, alertTypeUpgrade, "", alertLevel)
+				if firstOccurrence {
+					addFiredTransition(alertTypeUpgrade, "", alertLevel, err)
+				}
+			}
 		case *SlashingSLAError:
 			// Because Slashing SLA is a 10,000 block sliding window,
 			// we will be alerting for many hours under typical outage scenarios
 			// if we alert every ~10 minutes like we do for other AlertTypes.
 			//
-			// Therefore, we only alert if we haven't already alerted:
+			// Therefore, we only alert if we haven't already alerted, or when the alert changes between the jail
+			// buffer and uptime under the sla:
 
 			foundAlertTypes = append(foundAlertTypes, alertTypeSlashingSLA)
 
-			if alertState.AlertTypeCounts[alertTypeSlashingSLA] == 0 {
+			jailBuffer := err.jailBuffer > 0
+			firstOccurrence := alertState.AlertTypeCounts[alertTypeSlashingSLA] == 0
+			if firstOccurrence || jailBuffer != alertState.SlashingSLAJailBuffer {
 				alertState.AlertTypeCounts[alertTypeSlashingSLA]++
-				addAlert(err)
-				setAlertLevel(alertLevelHigh)
+				alertState.SlashingSLAJailBuffer = jailBuffer
+				addAlert(err, alertTypeSlashingSLA, "", alertLevelHigh)
+				if firstOccurrence {
+					addFiredTransition(alertTypeSlashingSLA, "", alertLevelHigh, err)
+				}
 			}
 		case *MissedRecentBlocksError:
 			addRecentMissedBlocksAlertIfNecessary := func(alertLevel AlertLevel) {
+				firstOccurrence := alertState.AlertTypeCounts[alertTypeMissedRecentBlocks] == 0
 				if shouldNotifyForFoundAlertType(alertTypeMissedRecentBlocks) || stats.RecentMissedBlocks != recentMissedBlocksCounter {
-					addAlert(err)
-					setAlertLevel(alertLevel)
+					addAlert(err, alertTypeMissedRecentBlocks, "", alertLevel)
+					if firstOccurrence {
+						addFiredTransition(alertTypeMissedRecentBlocks, "", alertLevel, err)
+					}
 				}
 			}
 			if stats.RecentMissedBlocks > recentMissedBlocksCounter {
-				if stats.RecentMissedBlocks > vm.RecentMissedBlocksNotifyThreshold {
+				if stats.RecentMissedBlocks > vm.RecentMissedBlocksNotifyThreshold.blocks(vm.RecentBlocksToCheck) {
 					stats.RecentMissedBlockAlertLevel = alertLevelHigh
 					addRecentMissedBlocksAlertIfNecessary(alertLevelHigh)
 				} else {
@@ -503,52 +1657,39 @@ func getAlertNotification(
 				stats.RecentMissedBlockAlertLevel = alertLevelWarning
 				addRecentMissedBlocksAlertIfNecessary(alertLevelWarning)
 			}
-			alertState.RecentMissedBlocksCounter = stats.RecentMissedBlocks
-			if stats.RecentMissedBlocks > alertState.RecentMissedBlocksCounterMax {
-				alertState.RecentMissedBlocksCounterMax = stats.RecentMissedBlocks
-			}
+		case *MissedBlockStreakError:
+			handleGenericAlert(err, alertTypeMissedBlockStreak, vm.getMissedBlockStreakAlertLevel())
+		case *UptimeDeclineError:
+			handleGenericAlert(err, alertTypeUptimeDecline, alertLevelWarning)
+		case *RPCUnreachableError:
+			handleGenericAlert(err, alertTypeRPCUnreachable, alertLevelHigh)
+		case *SentryDivergenceError:
+			handleGenericAlert(err, alertTypeSentryDivergence, alertLevelWarning)
 		case *GenericRPCError:
 			handleGenericAlert(err, alertTypeGenericRPC, alertLevelWarning)
 			stats.RPCError = true
 		case *SentryGRPCError:
 			sentryName := err.sentry
 			foundSentryGRPCErrors = append(foundSentryGRPCErrors, sentryName)
-			if alertState.SentryGRPCErrorCounts[sentryName]%vm.NotifyEvery == 0 || alertState.SentryGRPCErrorCounts[sentryName] == sentryGRPCErrorNotifyThreshold {
-				addAlert(err)
-				if alertState.SentryGRPCErrorCounts[sentryName] >= sentryGRPCNotifyThreshold {
-					setAlertLevel(alertLevelHigh)
-				} else {
-					setAlertLevel(alertLevelWarning)
-				}
-			}
-			alertState.SentryGRPCErrorCounts[sentryName]++
+			handleSentryAlert(err, alertTypeSentryGRPC, sentryName, 0, alertState.SentryGRPCErrorCounts, sentryGRPCNotifyThreshold, 0)
 		case *SentryOutOfSyncError:
 			sentryName := err.sentry
 			foundSentryOutOfSyncErrors = append(foundSentryOutOfSyncErrors, sentryName)
-			if alertState.SentryOutOfSyncErrorCounts[sentryName]%vm.NotifyEvery == 0 || alertState.SentryOutOfSyncErrorCounts[sentryName] == sentryOutOfSyncErrorNotifyThreshold {
-				addAlert(err)
-				if alertState.SentryOutOfSyncErrorCounts[sentryName] >= sentryOutOfSyncErrorNotifyThreshold {
-					setAlertLevel(alertLevelHigh)
-				} else {
-					setAlertLevel(alertLevelWarning)
-				}
-			}
-			alertState.SentryOutOfSyncErrorCounts[sentryName]++
+			handleSentryAlert(err, alertTypeSentryOutOfSync, sentryName, 0, alertState.SentryOutOfSyncErrorCounts, sentryOutOfSyncErrorNotifyThreshold, 0)
 		case *SentryHaltError:
 			sentryName := err.sentry
 			foundSentryHaltErrors = append(foundSentryHaltErrors, sentryName)
-			if alertState.SentryHaltErrorCounts[sentryName]%vm.NotifyEvery == 0 || alertState.SentryHaltErrorCounts[sentryName] == sentryHaltErrorNotifyThreshold {
-				addAlert(err)
-				if alertState.SentryHaltErrorCounts[sentryName] >= sentryHaltErrorNotifyThreshold {
-					setAlertLevel(alertLevelHigh)
-				} else {
-					setAlertLevel(alertLevelWarning)
-				}
-			}
-			alertState.SentryHaltErrorCounts[sentryName]++
+			handleSentryAlert(err, alertTypeSentryHalt, sentryName, err.height, alertState.SentryHaltErrorCounts, sentryHaltNotifyThreshold, sentryHaltHeldChecks)
+		case *SentryLowPeersError:
+			sentryName := err.sentry
+			foundSentryLowPeersErrors = append(foundSentryLowPeersErrors, sentryName)
+			handleSentryAlert(err, alertTypeSentryLowPeers, sentryName, 0, alertState.SentryLowPeersErrorCounts, sentryLowPeersErrorNotifyThreshold, 0)
+		case *SentryHostError:
+			sentryName := err.sentry
+			foundSentryHostErrors = append(foundSentryHostErrors, sentryName)
+			handleSentryAlert(err, alertTypeSentryHost, sentryName, 0, alertState.SentryHostErrorCounts, sentryHostErrorNotifyThreshold, 0)
 		default:
-			addAlert(err)
-			setAlertLevel(alertLevelWarning)
+			addAlert(err, "", "", alertLevelWarning)
 		}
 	}
 
@@ -562,7 +1703,7 @@ func getAlertNotification(
 	}
 
 	isRPCError := func(alertType AlertType) bool {
-		return alertType == alertTypeGenericRPC || alertType == alertTypeOutOfSync
+		return alertType == alertTypeGenericRPC || alertType == alertTypeOutOfSync || alertType == alertTypeRPCUnreachable
 	}
 	foundRPCError := hasAlertType(alertTypeOutOfSync) || hasAlertType(alertTypeGenericRPC)
 
@@ -571,37 +1712,77 @@ func getAlertNotification(
 		// reset alert type if we didn't see it this time and it's either an RPC error or there are no RPC errors
 		// should only clear jailed, tombstoned, and missed recent blocks errors if there also isn't a generic RPC error or RPC server out of sync error
 		if !hasAlertType(i) && alertState.AlertTypeCounts[i] > 0 {
-			alertState.AlertTypeCounts[i] = 0
 			if isRPCError(i) || !foundRPCError {
 				alertState.AlertTypeCounts[i] = 0
 				switch i {
 				case alertTypeOutOfSync:
-					alertNotification.ClearedAlerts = append(alertNotification.ClearedAlerts, "rpc server out of sync")
+					addClearedAlert(i, "", "rpc server out of sync")
 				case alertTypeGenericRPC:
-					alertNotification.ClearedAlerts = append(alertNotification.ClearedAlerts, "generic rpc error")
+					addClearedAlert(i, "", "generic rpc error")
+				case alertTypeRPCUnreachable:
+					addClearedAlert(i, "", "rpc server reachable again")
+					alertNotification.NotifyForClear = true
 				case alertTypeJailed:
-					alertNotification.ClearedAlerts = append(alertNotification.ClearedAlerts, "jailed")
+					addClearedAlert(i, "", "jailed")
 					alertNotification.NotifyForClear = true
 				case alertTypeTombstoned:
-					alertNotification.ClearedAlerts = append(alertNotification.ClearedAlerts, "tombstoned")
+					addClearedAlert(i, "", "tombstoned")
 					alertNotification.NotifyForClear = true
 				case alertTypeBlockFetch:
-					alertNotification.ClearedAlerts = append(alertNotification.ClearedAlerts, "rpc block fetch error")
+					addClearedAlert(i, "", "rpc block fetch error")
 				case alertTypeMissedRecentBlocks:
-					alertNotification.ClearedAlerts = append(alertNotification.ClearedAlerts, "missed recent blocks")
-					if alertState.RecentMissedBlocksCounterMax > vm.RecentMissedBlocksNotifyThreshold {
+					addClearedAlert(i, "", "missed recent blocks")
+					if alertState.RecentMissedBlocksCounterMax > vm.RecentMissedBlocksNotifyThreshold.blocks(vm.RecentBlocksToCheck) {
 						alertNotification.NotifyForClear = true
 					}
-					alertState.RecentMissedBlocksCounter = 0
 					alertState.RecentMissedBlocksCounterMax = 0
+				case alertTypeMissedBlockStreak:
+					addClearedAlert(i, "", "missed block streak")
+					alertNotification.NotifyForClear = true
+					alertState.RecentMissedBlockStreakMax = 0
+				case alertTypeUptimeDecline:
+					addClearedAlert(i, "", "uptime no longer declining")
 				case alertTypeSlashingSLA:
-					alertNotification.ClearedAlerts = append(alertNotification.ClearedAlerts, "slashing sla uptime recovered")
+					addClearedAlert(i, "", "slashing sla uptime recovered")
+					alertNotification.NotifyForClear = true
+					alertState.SlashingSLAJailBuffer = false
+				case alertTypeVotingPower:
+					addClearedAlert(i, "", "voting power no longer under threshold")
+					alertNotification.NotifyForClear = true
+				case alertTypeUnbonding:
+					addClearedAlert(i, "", "validator bonded again")
 					alertNotification.NotifyForClear = true
+				case alertTypeNetworkHalt:
+					addClearedAlert(i, "", "network producing blocks again")
+				case alertTypeChainIDMismatch:
+					addClearedAlert(i, "", "rpc node chain-id matches again")
+					alertNotification.NotifyForClear = true
+				case alertTypeDoubleSign:
+					// evidence is only seen while it is within the recent blocks,
+					// double signing is never cleared
+				case alertTypeSentryDivergence:
+					addClearedAlert(i, "", "sentry heights diverged")
+				case alertTypeUpgrade:
+					addClearedAlert(i, "", "upcoming chain upgrade")
+					alertState.UpgradeMilestone = 0
 				default:
 				}
 			}
 		}
 	}
+	// The counter is recomputed from the blocks checked this cycle rather than accumulated, so it follows
+	// the validator back down when it resumes signing. It is left as is when RPC errors mean the blocks
+	// may not all have been checked. The max is the peak while the alert is active.
+	if !foundRPCError {
+		alertState.RecentMissedBlocksCounter = stats.RecentMissedBlocks
+		if hasAlertType(alertTypeMissedRecentBlocks) && stats.RecentMissedBlocks > alertState.RecentMissedBlocksCounterMax {
+			alertState.RecentMissedBlocksCounterMax = stats.RecentMissedBlocks
+		}
+		if hasAlertType(alertTypeMissedBlockStreak) && stats.RecentMissedBlockStreak > alertState.RecentMissedBlockStreakMax {
+			alertState.RecentMissedBlockStreakMax = stats.RecentMissedBlockStreak
+		}
+	}
+
 	for sentryName := range alertState.SentryGRPCErrorCounts {
 		sentryFound := false
 		for _, foundSentryName := range foundSentryGRPCErrors {
@@ -611,11 +1792,7 @@ func getAlertNotification(
 			}
 		}
 		if !sentryFound && alertState.SentryGRPCErrorCounts[sentryName] > 0 {
-			if alertState.SentryGRPCErrorCounts[sentryName] > sentryGRPCNotifyThreshold {
-				alertNotification.NotifyForClear = true
-			}
-			alertState.SentryGRPCErrorCounts[sentryName] = 0
-			alertNotification.ClearedAlerts = append(alertNotification.ClearedAlerts, fmt.Sprintf("%s grpc error", sentryName))
+			addSentryRecovered(alertTypeSentryGRPC, sentryName, alertState.SentryGRPCErrorCounts, sentryGRPCNotifyThreshold, 0)
 		}
 	}
 	for sentryName := range alertState.SentryHaltErrorCounts {
@@ -635,11 +1812,7 @@ func getAlertNotification(
 			}
 		}
 		if !sentryHasHaltError && !sentryHasGRPCError && alertState.SentryHaltErrorCounts[sentryName] > 0 {
-			if alertState.SentryHaltErrorCounts[sentryName] > sentryHaltErrorNotifyThreshold {
-				alertNotification.NotifyForClear = true
-			}
-			alertState.SentryHaltErrorCounts[sentryName] = 0
-			alertNotification.ClearedAlerts = append(alertNotification.ClearedAlerts, fmt.Sprintf("%s halt error", sentryName))
+			addSentryRecovered(alertTypeSentryHalt, sentryName, alertState.SentryHaltErrorCounts, sentryHaltNotifyThreshold, sentryHaltHeldChecks)
 		}
 	}
 	for sentryName := range alertState.SentryOutOfSyncErrorCounts {
@@ -659,12 +1832,47 @@ func getAlertNotification(
 			}
 		}
 		if !sentryHasOutOfSyncError && !sentryHasGRPCError && alertState.SentryOutOfSyncErrorCounts[sentryName] > 0 {
-			if alertState.SentryOutOfSyncErrorCounts[sentryName] > sentryOutOfSyncErrorNotifyThreshold {
-				alertNotification.NotifyForClear = true
+			addSentryRecovered(alertTypeSentryOutOfSync, sentryName, alertState.SentryOutOfSyncErrorCounts, sentryOutOfSyncErrorNotifyThreshold, 0)
+		}
+	}
+	for sentryName := range alertState.SentryLowPeersErrorCounts {
+		sentryHasLowPeersError := false
+		for _, foundSentryName := range foundSentryLowPeersErrors {
+			if foundSentryName == sentryName {
+				sentryHasLowPeersError = true
+				break
 			}
-			alertState.SentryOutOfSyncErrorCounts[sentryName] = 0
-			alertNotification.ClearedAlerts = append(alertNotification.ClearedAlerts, fmt.Sprintf("%s out of sync error", sentryName))
 		}
+		if !sentryHasLowPeersError && alertState.SentryLowPeersErrorCounts[sentryName] > 0 {
+			addSentryRecovered(alertTypeSentryLowPeers, sentryName, alertState.SentryLowPeersErrorCounts, sentryLowPeersErrorNotifyThreshold, 0)
+		}
+	}
+	for sentryName := range alertState.SentryHostErrorCounts {
+		sentryHasHostError := false
+		for _, foundSentryName := range foundSentryHostErrors {
+			if foundSentryName == sentryName {
+				sentryHasHostError = true
+				break
+			}
+		}
+		if !sentryHasHostError && alertState.SentryHostErrorCounts[sentryName] > 0 {
+			addSentryRecovered(alertTypeSentryHost, sentryName, alertState.SentryHostErrorCounts, sentryHostErrorNotifyThreshold, 0)
+		}
+	}
+
+	// cleared alerts are reported at the highest level notified while they were active
+	if alertNotification.AlertLevel > alertState.ActiveAlertLevel {
+		alertState.ActiveAlertLevel = alertNotification.AlertLevel
+	}
+	alertNotification.ClearedAlertLevel = alertState.ActiveAlertLevel
+	for i := range alertNotification.Transitions {
+		if alertNotification.Transitions[i].Cleared {
+			alertNotification.Transitions[i].AlertLevel = alertNotification.ClearedAlertLevel
+		}
+	}
+	if len(foundAlertTypes) == 0 && len(foundSentryGRPCErrors) == 0 && len(foundSentryOutOfSyncErrors) == 0 &&
+		len(foundSentryHaltErrors) == 0 && len(foundSentryLowPeersErrors) == 0 && len(foundSentryHostErrors) == 0 {
+		alertState.ActiveAlertLevel = alertLevelNone
 	}
 
 	if len(alertNotification.Alerts) == 0 && len(alertNotification.ClearedAlerts) == 0 {
@@ -0,0 +1,312 @@
+package cmd
+
+import (
+	"testing"
+	"time"
+
+	stakingtypes "github.com/cosmos/cosmos-sdk/x/staking/types"
+)
+
+func newTestValidatorMonitor() *ValidatorMonitor {
+	return &ValidatorMonitor{
+		Name:                              "validator",
+		ChainID:                           "chain-1",
+		RecentBlocksToCheck:               defaultRecentBlocksToCheck,
+		NotifyEvery:                       CheckCount(defaultNotifyEvery),
+		RecentMissedBlocksNotifyThreshold: BlockThreshold{count: defaultRecentMissedBlocksNotifyThreshold},
+	}
+}
+
+func hasClearedAlert(notification *ValidatorAlertNotification, clearedAlert string) bool {
+	if notification == nil {
+		return false
+	}
+	for _, cleared := range notification.ClearedAlerts {
+		if cleared == clearedAlert {
+			return true
+		}
+	}
+	return false
+}
+
+func TestGetAlertNotificationRecentMissedBlocksCounter(t *testing.T) {
+	config := &HalfLifeConfig{}
+	vm := newTestValidatorMonitor()
+	alertState := newValidatorAlertState()
+
+	cycles := []struct {
+		name        string
+		missed      int64
+		wantCounter int64
+		wantMax     int64
+		wantAlert   bool
+		wantCleared bool
+	}{
+		{name: "burst starts", missed: 3, wantCounter: 3, wantMax: 3, wantAlert: true},
+		{name: "burst grows", missed: 12, wantCounter: 12, wantMax: 12, wantAlert: true},
+		{name: "burst peaks", missed: 15, wantCounter: 15, wantMax: 15, wantAlert: true},
+		{name: "signing resumes", missed: 6, wantCounter: 6, wantMax: 15, wantAlert: true},
+		{name: "blocks leave the window", missed: 1, wantCounter: 1, wantMax: 15, wantAlert: true},
+		{name: "clean signing", missed: 0, wantCounter: 0, wantMax: 0, wantCleared: true},
+		{name: "still clean", missed: 0, wantCounter: 0, wantMax: 0},
+	}
+	for _, cycle := range cycles {
+		stats := &ValidatorStats{RecentMissedBlocks: cycle.missed}
+		var errs []error
+		if cycle.missed > vm.getMissedBlocksThreshold() {
+			errs = append(errs, newMissedRecentBlocksError(cycle.missed, 0, vm.RecentBlocksToCheck))
+		}
+		notification := getAlertNotification(config, vm, stats, alertState, errs)
+
+		if alertState.RecentMissedBlocksCounter != cycle.wantCounter {
+			t.Errorf("%s: RecentMissedBlocksCounter = %d, want %d", cycle.name, alertState.RecentMissedBlocksCounter, cycle.wantCounter)
+		}
+		if alertState.RecentMissedBlocksCounterMax != cycle.wantMax {
+			t.Errorf("%s: RecentMissedBlocksCounterMax = %d, want %d", cycle.name, alertState.RecentMissedBlocksCounterMax, cycle.wantMax)
+		}
+		alerted := notification != nil && len(notification.Alerts) > 0
+		if alerted != cycle.wantAlert {
+			t.Errorf("%s: alerted = %t, want %t", cycle.name, alerted, cycle.wantAlert)
+		}
+		if cleared := hasClearedAlert(notification, "missed recent blocks"); cleared != cycle.wantCleared {
+			t.Errorf("%s: missed recent blocks cleared = %t, want %t", cycle.name, cleared, cycle.wantCleared)
+		}
+		if cycle.wantCleared && !notification.NotifyForClear {
+			t.Errorf("%s: clear of a burst over the notify threshold is not notified", cycle.name)
+		}
+	}
+	if count := alertState.AlertTypeCounts[alertTypeMissedRecentBlocks]; count != 0 {
+		t.Errorf("missed recent blocks alert count = %d after clean signing, want 0", count)
+	}
+}
+
+func TestGetAlertNotificationFullNodeSkipsSigningAlerts(t *testing.T) {
+	config := &HalfLifeConfig{}
+	vm := newTestValidatorMonitor()
+	vm.FullNode = true
+	vm.SlashingPeriodUptimeErrorThreshold = 98
+	alertState := newValidatorAlertState()
+	signingAlertTypes := []AlertType{alertTypeJailed, alertTypeTombstoned, alertTypeMissedRecentBlocks, alertTypeSlashingSLA}
+
+	for cycle := 0; cycle < 3; cycle++ {
+		// signing stats and errors that a full node would alert on if it were a validator
+		stats := &ValidatorStats{RecentMissedBlocks: vm.RecentBlocksToCheck, SlashingPeriodUptime: 50, LastSignedBlockHeight: -1}
+		errs := []error{
+			newJailedError(alertClock().Add(time.Hour), true),
+			newTombstonedError(true),
+			newMissedRecentBlocksError(vm.RecentBlocksToCheck, vm.RecentBlocksToCheck, vm.RecentBlocksToCheck),
+			newSlashingSLAError(50, vm.SlashingPeriodUptimeErrorThreshold, 100),
+			newChainHaltError(int64(10 * time.Minute)),
+			newGenericRPCError("rpc error"),
+		}
+		errs = append(errs, stats.determineAggregatedErrorsAndAlertLevel(vm)...)
+		errs = append(errs, stats.determineSlashingSLAErrors(config, vm, alertState, errs)...)
+		notification := getAlertNotification(config, vm, stats, alertState, errs)
+
+		if stats.RecentMissedBlockAlertLevel != alertLevelNone {
+			t.Errorf("cycle %d: recent missed blocks alert level %s for a full node", cycle, stats.RecentMissedBlockAlertLevel)
+		}
+		for _, alertType := range signingAlertTypes {
+			if count := alertState.AlertTypeCounts[alertType]; count != 0 {
+				t.Errorf("cycle %d: %s counted %d times for a full node", cycle, alertType, count)
+			}
+		}
+		if cycle > 0 {
+			// halt and rpc alerts are only notified at the first check and then every notify-every checks
+			continue
+		}
+		if notification == nil {
+			t.Fatalf("cycle %d: no notification for halt and rpc errors", cycle)
+		}
+		notified := make(map[AlertType]bool)
+		for _, key := range notification.AlertKeys {
+			notified[key.AlertType] = true
+		}
+		for _, alertType := range signingAlertTypes {
+			if notified[alertType] {
+				t.Errorf("cycle %d: %s notified for a full node", cycle, alertType)
+			}
+		}
+		for _, alertType := range []AlertType{alertTypeHalt, alertTypeGenericRPC} {
+			if !notified[alertType] {
+				t.Errorf("cycle %d: %s not notified for a full node", cycle, alertType)
+			}
+		}
+	}
+	for _, alertType := range []AlertType{alertTypeHalt, alertTypeGenericRPC} {
+		if count := alertState.AlertTypeCounts[alertType]; count != 3 {
+			t.Errorf("%s counted %d times, want 3", alertType, count)
+		}
+	}
+}
+
+func TestGetAlertNotificationSlashingJailBufferThenSLA(t *testing.T) {
+	config := &HalfLifeConfig{}
+	vm := newTestValidatorMonitor()
+	vm.SlashingPeriodUptimeErrorThreshold = 95
+	clearThreshold := 98.0
+	vm.SlashingPeriodUptimeClearThreshold = &clearThreshold
+	alertState := newValidatorAlertState()
+
+	cycles := []struct {
+		name      string
+		err       error
+		uptime    float64
+		wantAlert bool
+		wantArmed bool
+	}{
+		{name: "jail buffer", err: newSlashingJailBufferError(96, 40, 50), uptime: 96, wantAlert: true},
+		{name: "jail buffer again", err: newSlashingJailBufferError(96, 40, 50), uptime: 96},
+		{name: "under sla", err: newSlashingSLAError(94, 95, 30), uptime: 94, wantAlert: true, wantArmed: true},
+		{name: "still under sla", err: newSlashingSLAError(94, 95, 30), uptime: 94, wantArmed: true},
+	}
+	for _, cycle := range cycles {
+		stats := &ValidatorStats{SlashingPeriodUptime: cycle.uptime, LastSignedBlockHeight: -1}
+		errs := []error{cycle.err}
+		errs = append(errs, stats.determineSlashingSLAErrors(config, vm, alertState, errs)...)
+		notification := getAlertNotification(config, vm, stats, alertState, errs)
+
+		alerted := notification != nil && len(notification.Alerts) > 0
+		if alerted != cycle.wantAlert {
+			t.Errorf("%s: alerted = %t, want %t", cycle.name, alerted, cycle.wantAlert)
+		}
+		if alertState.SlashingSLAArmed != cycle.wantArmed {
+			t.Errorf("%s: SlashingSLAArmed = %t, want %t", cycle.name, alertState.SlashingSLAArmed, cycle.wantArmed)
+		}
+	}
+}
+
+func TestDetermineSlashingSLAErrorsJailBufferDoesNotArm(t *testing.T) {
+	config := &HalfLifeConfig{}
+	vm := newTestValidatorMonitor()
+	vm.SlashingPeriodUptimeErrorThreshold = 95
+	clearThreshold := 98.0
+	vm.SlashingPeriodUptimeClearThreshold = &clearThreshold
+	alertState := newValidatorAlertState()
+
+	stats := &ValidatorStats{SlashingPeriodUptime: 96}
+	stats.determineSlashingSLAErrors(config, vm, alertState, []error{newSlashingJailBufferError(96, 40, 50)})
+	// the jail buffer passed, uptime is over the error threshold but under the clear threshold
+	if errs := stats.determineSlashingSLAErrors(config, vm, alertState, nil); len(errs) != 0 {
+		t.Errorf("recovering errors %v without an sla breach", errs)
+	}
+}
+
+func TestRecordAlertsSentSkipsFilteredAlerts(t *testing.T) {
+	config := &HalfLifeConfig{AlertConfig: AlertConfig{Cooldowns: map[AlertType]time.Duration{alertTypeGenericRPC: time.Hour}}}
+	vm := newTestValidatorMonitor()
+	alertState := newValidatorAlertState()
+
+	notification := getAlertNotification(config, vm, &ValidatorStats{}, alertState, []error{newGenericRPCError("rpc error")})
+	if notification == nil || len(notification.AlertKeys) != 1 {
+		t.Fatalf("notification %+v, want the rpc error alert", notification)
+	}
+	if _, ok := alertState.AlertLastSent[alertTypeGenericRPC]; ok {
+		t.Errorf("cooldown started before the notification was sent")
+	}
+
+	// a warning filtered out by the minimum notify level is not sent, so its cooldown does not start
+	alertState.recordAlertsSent(config, notification.filterByMinNotifyLevel(alertLevelHigh))
+	if _, ok := alertState.AlertLastSent[alertTypeGenericRPC]; ok {
+		t.Errorf("cooldown started for a filtered alert")
+	}
+
+	alertState.recordAlertsSent(config, notification)
+	if lastSent, ok := alertState.AlertLastSent[alertTypeGenericRPC]; !ok || lastSent.IsZero() {
+		t.Errorf("cooldown not started for a sent alert")
+	}
+}
+
+func TestDetermineVotingPowerErrorsRebaselinesAfterDrop(t *testing.T) {
+	config := &HalfLifeConfig{}
+	vm := newTestValidatorMonitor()
+	alertState := newValidatorAlertState()
+
+	checks := []struct {
+		name        string
+		votingPower int64
+		wantErr     bool
+		wantMax     int64
+	}{
+		{name: "peak", votingPower: 1000, wantMax: 1000},
+		{name: "undelegation", votingPower: 800, wantErr: true, wantMax: 800},
+		{name: "after undelegation", votingPower: 800, wantMax: 800},
+		{name: "small drop from new baseline", votingPower: 750, wantMax: 800},
+		{name: "drop from new baseline", votingPower: 700, wantErr: true, wantMax: 700},
+	}
+	for _, check := range checks {
+		stats := &ValidatorStats{BondStatus: stakingtypes.Bonded.String(), VotingPower: check.votingPower}
+		errs := stats.determineVotingPowerErrors(config, vm, alertState)
+		if (len(errs) > 0) != check.wantErr {
+			t.Errorf("%s: errors %v, want error %t", check.name, errs, check.wantErr)
+		}
+		if alertState.VotingPowerMax != check.wantMax {
+			t.Errorf("%s: VotingPowerMax = %d, want %d", check.name, alertState.VotingPowerMax, check.wantMax)
+		}
+	}
+}
+
+func TestFilterByMinNotifyLevelFiltersEachAlert(t *testing.T) {
+	notification := &ValidatorAlertNotification{
+		Alerts:      []string{"rpc error", "chain halt"},
+		AlertKeys:   []AlertKey{{AlertType: alertTypeGenericRPC}, {AlertType: alertTypeHalt}},
+		AlertLevels: []AlertLevel{alertLevelWarning, alertLevelHigh},
+		AlertLevel:  alertLevelHigh,
+	}
+	filtered := notification.filterByMinNotifyLevel(alertLevelHigh)
+	if filtered == nil || len(filtered.Alerts) != 1 || filtered.AlertKeys[0].AlertType != alertTypeHalt {
+		t.Fatalf("filtered %+v, want only the chain halt alert", filtered)
+	}
+	if filtered.AlertLevel != alertLevelHigh {
+		t.Errorf("AlertLevel = %s, want %s", filtered.AlertLevel, alertLevelHigh)
+	}
+	if filtered := notification.filterByMinNotifyLevel(alertLevelCritical); filtered != nil {
+		t.Errorf("filtered %+v, want nothing left to notify", filtered)
+	}
+}
+
+func TestGetAlertNotificationSentryHaltThreshold(t *testing.T) {
+	config := &HalfLifeConfig{}
+	vm := newTestValidatorMonitor()
+	threshold := int64(3)
+	vm.SentryHaltThreshold = &threshold
+	alertState := newValidatorAlertState()
+
+	cycles := []struct {
+		name        string
+		halted      bool
+		wantLevel   AlertLevel
+		wantCleared bool
+	}{
+		{name: "first stalled check", halted: true},
+		{name: "recovered before the threshold", halted: false},
+		{name: "stalled again", halted: true},
+		{name: "still stalled", halted: true},
+		{name: "stalled at threshold", halted: true, wantLevel: alertLevelWarning},
+		{name: "stalled past threshold", halted: true, wantLevel: alertLevelHigh},
+		{name: "recovered", halted: false, wantCleared: true},
+	}
+	for _, cycle := range cycles {
+		var errs []error
+		if cycle.halted {
+			errs = append(errs, newSentryHaltError("sentry-1", 100, int64(time.Minute)))
+		}
+		notification := getAlertNotification(config, vm, &ValidatorStats{}, alertState, errs)
+
+		alertLevel := alertLevelNone
+		if notification != nil {
+			for i, key := range notification.AlertKeys {
+				if key.AlertType == alertTypeSentryHalt {
+					alertLevel = notification.AlertLevels[i]
+				}
+			}
+		}
+		if alertLevel != cycle.wantLevel {
+			t.Errorf("%s: sentry halt notified at %s, want %s", cycle.name, alertLevel, cycle.wantLevel)
+		}
+		cleared := notification != nil && len(notification.ClearedAlertKeys) > 0
+		if cleared != cycle.wantCleared {
+			t.Errorf("%s: sentry halt cleared = %t, want %t", cycle.name, cleared, cycle.wantCleared)
+		}
+	}
+}
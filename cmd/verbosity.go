@@ -0,0 +1,130 @@
+package cmd
+
+import (
+	"fmt"
+	"time"
+)
+
+// Verbosity controls how much of the validator and sentry stats a notifier renders in its messages
+type Verbosity string
+
+const (
+	// one line per alert message, without the sentry breakdown in status messages
+	verbosityMinimal Verbosity = "minimal"
+	// the alerts and status messages as they have always been rendered, the default
+	verbosityNormal Verbosity = "normal"
+	// the alerts followed by the validator stats and the breakdown of each sentry
+	verbosityDetailed Verbosity = "detailed"
+)
+
+var verbosities = []Verbosity{verbosityMinimal, verbosityNormal, verbosityDetailed}
+
+// getVerbosity returns the verbosity, normal when it is not set
+func getVerbosity(verbosity Verbosity) Verbosity {
+	if verbosity == "" {
+		return verbosityNormal
+	}
+	return verbosity
+}
+
+func (v Verbosity) validate() error {
+	for _, verbosity := range verbosities {
+		if getVerbosity(v) == verbosity {
+			return nil
+		}
+	}
+	return fmt.Errorf("unsupported verbosity %q, expected minimal, normal, or detailed", v)
+}
+
+// validateVerbosities checks the verbosity of each configured notifier
+func (c *NotificationsConfig) validateVerbosities() error {
+	verbosities := make(map[string]Verbosity)
+	if c.Discord != nil {
+		verbosities["discord"] = c.Discord.Verbosity
+	}
+	if c.Matrix != nil {
+		verbosities["matrix"] = c.Matrix.Verbosity
+	}
+	if c.Teams != nil {
+		verbosities["teams"] = c.Teams.Verbosity
+	}
+	if c.Opsgenie != nil {
+		verbosities["opsgenie"] = c.Opsgenie.Verbosity
+	}
+	if c.Pushover != nil {
+		verbosities["pushover"] = c.Pushover.Verbosity
+	}
+	for notifier, verbosity := range verbosities {
+		if err := verbosity.validate(); err != nil {
+			return fmt.Errorf("%s: %w", notifier, err)
+		}
+	}
+	return nil
+}
+
+// getAlertsSummary returns the alerts as a single line, the first alert and how many more there are
+func getAlertsSummary(alerts []string) string {
+	if len(alerts) == 0 {
+		return ""
+	}
+	if len(alerts) == 1 {
+		return alerts[0]
+	}
+	return fmt.Sprintf("%s (+%d more)", alerts[0], len(alerts)-1)
+}
+
+// getStatsDetails returns the validator stats and each sentry as plain text lines, for detailed verbosity
+func getStatsDetails(vm *ValidatorMonitor, stats ValidatorStats) []string {
+	var lines []string
+	if stats.RPCError || stats.Height == 0 {
+		lines = append(lines, "Height N/A")
+	} else {
+		line := fmt.Sprintf("Height %d - %s", stats.Height, stats.Timestamp.UTC().Format(time.RFC3339))
+		if stats.BlockTime > 0 {
+			line += fmt.Sprintf(" - Block Time %s", stats.BlockTime.Round(10*time.Millisecond))
+		}
+		lines = append(lines, line)
+		if !vm.FullNode {
+			if stats.LastSignedBlockHeight == -1 {
+				lines = append(lines, "Last Signed N/A")
+			} else if stats.LastSignedBlockHeight != stats.Height {
+				lines = append(lines, fmt.Sprintf("Last Signed %d - %s", stats.LastSignedBlockHeight, stats.LastSignedBlockTimestamp.UTC().Format(time.RFC3339)))
+			}
+			line = fmt.Sprintf("Latest Blocks Signed: %d/%d", vm.RecentBlocksToCheck-stats.RecentMissedBlocks, vm.RecentBlocksToCheck)
+			if stats.RecentMissedBlockStreak > 1 {
+				line += fmt.Sprintf(" (longest miss streak %d)", stats.RecentMissedBlockStreak)
+			}
+			lines = append(lines, line)
+			if stats.BlocksUntilJailed >= 0 {
+				lines = append(lines, fmt.Sprintf("Missed Blocks Until Jailed: %d", stats.BlocksUntilJailed))
+			}
+			if stats.UptimeTrendKnown {
+				lines = append(lines, "Uptime Trend: "+formatUptimeTrend(stats.UptimeTrend))
+			}
+		}
+	}
+	if stats.BondStatus != "" {
+		lines = append(lines, fmt.Sprintf("Bond Status %s - Voting Power %d (rank %d)", stats.BondStatus, stats.VotingPower, stats.VotingPowerRank))
+	}
+	if stats.RPCError {
+		lines = append(lines, getRPCHealth(stats))
+	}
+	for _, sentryStats := range stats.SentryStats {
+		height, version := "N/A", "N/A"
+		if sentryStats.Height != 0 {
+			height = fmt.Sprint(sentryStats.Height)
+		}
+		if sentryStats.Version != "" {
+			version = sentryStats.Version
+		}
+		line := fmt.Sprintf("Sentry %s - Height %s - Version %s", sentryStats.Name, height, version)
+		if sentryStats.Peers >= 0 {
+			line += fmt.Sprintf(" - Peers %d", sentryStats.Peers)
+		}
+		if !sentryStats.grpcReachable() {
+			line += " - gRPC unreachable"
+		}
+		lines = append(lines, line)
+	}
+	return lines
+}